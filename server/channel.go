@@ -0,0 +1,75 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const defaultChannelRoomText = "not set (meetings use the room of the user who starts them)"
+
+// ChannelInfo holds the plugin settings of a channel.
+type ChannelInfo struct {
+	// ReminderMinutes is how long before scheduled meetings the channel is reminded. Nil uses the host's setting,
+	// 0 disables.
+	ReminderMinutes *int `json:"reminder_minutes,omitempty"`
+
+	// RoomID is the Personal Room shared by the meetings started in the channel, instead of the room of the user who
+	// starts them.
+	RoomID string `json:"room_id,omitempty"`
+}
+
+// canManageChannel reports whether mattermostUserID may change the plugin settings of channelID: channel admins
+// and system admins in public and private channels, and every member of direct and group messages.
+func (p *Plugin) canManageChannel(mattermostUserID, channelID string) bool {
+	member, appErr := p.API.GetChannelMember(channelID, mattermostUserID)
+	if appErr != nil {
+		return false
+	}
+
+	channel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		return false
+	}
+
+	if channel.IsGroupOrDirect() || member.SchemeAdmin {
+		return true
+	}
+	return p.API.HasPermissionTo(mattermostUserID, model.PermissionManageSystem)
+}
+
+// startChannelMeeting starts a meeting in the room of details.channelID when it has one, or else in the room of
+// details.meetingRoomOfUserID.
+func (p *Plugin) startChannelMeeting(ctx context.Context, details meetingDetails) (*meetingPosts, int, error) {
+	// Check before looking up the room on Webex, which can be slow.
+	if err := p.checkNoRunningMeeting(details); err != nil {
+		return nil, http.StatusConflict, err
+	}
+
+	channelInfo, err := p.store.LoadChannelInfo(details.channelID)
+	if err != nil {
+		p.errorf("error loading the channel info of: %s, error: %v", details.channelID, err)
+		return nil, http.StatusInternalServerError, errors.New("error loading channel info, please contact your system administrator")
+	}
+	if channelInfo.RoomID == "" {
+		return p.startMeeting(ctx, details)
+	}
+
+	siteHost := p.siteHostForChannel(details.channelID)
+	roomURL, err := p.getURLFromRoomID(ctx, siteHost, channelInfo.RoomID)
+	if message := describeWebexLookupError(siteHost, err); message != "" {
+		return nil, http.StatusBadRequest, errors.New(message)
+	}
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("no Personal Room link found at `%s` for this channel's room: `%s`. A channel admin can change it with `/webex channel room <room id>`.%s", siteHost, channelInfo.RoomID, explainLookupError(err))
+	}
+
+	details.roomURL = roomURL
+	return p.startMeetingFromRoomURL(details)
+}
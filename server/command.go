@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
@@ -15,15 +17,38 @@ import (
 const helpText = "###### Mattermost Webex Plugin - Slash Command Help\n" +
 	"* `/webex help` - This help text\n" +
 	"* `/webex info` - Display your current settings\n" +
-	"* `/webex start` - Start a Webex meeting in your room\n" +
+	"* `/webex start [--force]` - Start a Webex meeting in your room. When a meeting is already running in this channel, links to it instead, unless `--force` is given\n" +
+	"* `/webex start new [--force] [title]` - Start a new, unique Webex meeting. Requires a connected Webex account\n" +
+	"* `/webex list [today|week]` - List your upcoming and in-progress Webex meetings. Requires a connected Webex account\n" +
+	"* `/webex share <meeting id>` - Share one of your Webex meetings in this channel\n" +
+	"* `/webex end` - End the meeting you are hosting in this channel, or your latest meeting\n" +
+	"* `/webex schedule <when> <duration> [title] [@user ...] [~channel]` - Schedule a Webex meeting and share it in this channel or in `~channel`, inviting each `@user`. For example: `/webex schedule tomorrow 9:30am 15m Standup @alice @bob`. Requires a connected Webex account\n" +
+	"* `/webex notes <text>` - Adds a note to the thread of the latest meeting in this channel, or of the meeting whose thread you are in\n" +
+	"* `/webex actions [item]` - Adds an action item to the thread of the meeting, or lists its action items. They are listed in the summary posted when the meeting ends\n" +
+	"* `/webex connect` - Connect your Webex account so the plugin can manage meetings on your behalf\n" +
+	"* `/webex disconnect` - Disconnect your Webex account\n" +
 	"* `/webex <room id>` - Shares a Join Meeting link for the Webex Personal Room meeting that is associated with the specified Personal Room ID, whether it’s your Personal Meeting Room ID or someone else’s.\n" +
 	"* `/webex <@username>` - Shares a Join Meeting link for the Webex Personal Room meeting that is associated with that Mattermost team member.\n" +
+	"###### Reminder Settings\n" +
+	"* `/webex reminder [minutes|off]` - Shows or sets how long before the meetings you are invited to you are reminded by direct message. The default is 10 minutes\n" +
+	"* `/webex channel reminder [minutes|off]` - Shows or sets how long before meetings scheduled in this channel it is reminded. Only channel admins can change it\n" +
 	"###### Room Settings\n" +
-	"* `/webex room <room id>` - Sets your personal Meeting Room ID. Meetings you start will use this ID. This setting is required only if your Webex account email address is different from your Mattermost account email address, or if the username of your email does not match your Personal Meeting Room ID or User name on your Webex site.\n" +
-	"* `/webex room-reset` or `reset-room` - Removes your room setting."
+	"* `/webex room <room id> [--force]` - Sets your personal Meeting Room ID, once it is found on Webex, or even if it isn't with `--force`. Meetings you start will use this ID. This setting is required only if your Webex account email address is different from your Mattermost account email address, or if the username of your email does not match your Personal Meeting Room ID or User name on your Webex site.\n" +
+	"* `/webex room-reset` or `reset-room` - Removes your room setting.\n" +
+	"* `/webex channel room [room id] [--force]` - Shows or sets the room of this channel. Meetings started here with `/webex start` or the channel header button use it instead of your own room. Only channel admins can change it\n" +
+	"* `/webex channel room-reset` - Removes this channel's room setting, so meetings use the room of whoever starts them"
+
+const adminHelpText = "\n###### System Admin Commands\n" +
+	"* `/webex admin diagnose` - Checks that every configured Webex site answers, and that your Webex account connection works\n" +
+	"* `/webex admin import [--dry-run] [--force]` - Sets the rooms of users from the latest CSV file you posted in this channel, one `<username or email>,<room id>` per line. Each room is validated on Webex, and the report is sent to you by direct message. With `--dry-run`, only reports what would be imported. With `--force`, also imports the rooms of users whose Webex site is unknown, without validation\n" +
+	"* `/webex admin export` - Sends you the rooms set by users as a CSV file, which can be imported back"
 
 const defaultRoomText = "not set (using your Mattermost email as the default)"
 
+// commandTimeout bounds the Webex requests made by a slash command or an API request, including their retries, so a
+// slow Webex site can't hold them past the server's own timeout.
+const commandTimeout = 25 * time.Second
+
 type CommandHandlerFunc func(p *Plugin, c *plugin.Context, header *model.CommandArgs, args ...string) *model.CommandResponse
 
 type CommandHandler struct {
@@ -33,13 +58,29 @@ type CommandHandler struct {
 
 var webexCommandHandler = CommandHandler{
 	handlers: map[string]CommandHandlerFunc{
-		"help":       executeHelp,
-		"info":       executeInfo,
-		"start":      executeStart,
-		"room":       executeRoom,
-		"room-reset": executeRoomReset,
-		"reset-room": executeRoomReset,
-		"join":       executeStartWithArg, // Used as an alias for /webex <@username>/<room id> to allow for Autocomplete suggestions
+		"help":               executeHelp,
+		"info":               executeInfo,
+		"start":              executeStart,
+		"start/new":          executeStartNew,
+		"room":               executeRoom,
+		"end":                executeEnd,
+		"list":               executeList,
+		"share":              executeShare,
+		"notes":              executeNotes,
+		"actions":            executeActions,
+		"schedule":           executeSchedule,
+		"reminder":           executeReminder,
+		"channel/reminder":   executeChannelReminder,
+		"channel/room":       executeChannelRoom,
+		"channel/room-reset": executeChannelRoomReset,
+		"admin/diagnose":     executeAdminDiagnose,
+		"admin/import":       executeAdminImport,
+		"admin/export":       executeAdminExport,
+		"connect":            executeConnect,
+		"disconnect":         executeDisconnect,
+		"room-reset":         executeRoomReset,
+		"reset-room":         executeRoomReset,
+		"join":               executeStartWithArg, // Used as an alias for /webex <@username>/<room id> to allow for Autocomplete suggestions
 	},
 	defaultHandler: executeStartWithArg,
 }
@@ -59,7 +100,11 @@ func executeHelp(p *Plugin, _ *plugin.Context, header *model.CommandArgs, _ ...s
 }
 
 func (p *Plugin) help(header *model.CommandArgs) *model.CommandResponse {
-	p.postCommandResponse(header, helpText)
+	text := helpText
+	if p.isSystemAdmin(header.UserId) {
+		text += adminHelpText
+	}
+	p.postCommandResponse(header, text)
 	return &model.CommandResponse{}
 }
 
@@ -69,7 +114,8 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, commandArgs *model.CommandArg
 		return p.help(commandArgs), nil
 	}
 
-	if !p.getConfiguration().IsValid() {
+	// The admin commands help fixing the configuration, so they are available even when it is invalid.
+	if !p.getConfiguration().IsValid() && (len(args) < 2 || args[1] != "admin") {
 		return p.responsef(commandArgs, "The Webex plugin has not been configured correctly: the sitename has not been set. Please contact your system administrator."), nil
 	}
 
@@ -87,7 +133,7 @@ func (p *Plugin) getCommand() (*model.Command, error) {
 		DisplayName:          "Webex",
 		Description:          "Integration with Webex.",
 		AutoComplete:         true,
-		AutoCompleteDesc:     "Available commands: help, info, start, <room id/@username>, room, room-reset",
+		AutoCompleteDesc:     "Available commands: help, info, start, end, list, share, notes, actions, schedule, reminder, channel, connect, disconnect, <room id/@username>, room, room-reset",
 		AutoCompleteHint:     "[command]",
 		AutocompleteData:     getAutocompleteData(),
 		AutocompleteIconData: iconData,
@@ -95,7 +141,7 @@ func (p *Plugin) getCommand() (*model.Command, error) {
 }
 
 func getAutocompleteData() *model.AutocompleteData {
-	webexAutocomplete := model.NewAutocompleteData("webex", "[command]", "Available commands: help, info, start, <room id/@username>, room, room-reset")
+	webexAutocomplete := model.NewAutocompleteData("webex", "[command]", "Available commands: help, info, start, end, list, share, notes, actions, schedule, reminder, channel, connect, disconnect, <room id/@username>, room, room-reset")
 
 	help := model.NewAutocompleteData("help", "", "Display usage information")
 	webexAutocomplete.AddCommand(help)
@@ -104,10 +150,61 @@ func getAutocompleteData() *model.AutocompleteData {
 	webexAutocomplete.AddCommand(info)
 
 	start := model.NewAutocompleteData("start", "", "Start a Webex meeting in your room")
+	startNew := model.NewAutocompleteData("new", "[title]", "Start a new, unique Webex meeting")
+	startNew.AddTextArgument("Meeting title", "[title]", "")
+	start.AddCommand(startNew)
 	webexAutocomplete.AddCommand(start)
 
-	room := model.NewAutocompleteData("room", "<room id>", "Sets your personal Meeting Room ID")
-	room.AddTextArgument("Webex meeting room ID", "<room id>", "")
+	list := model.NewAutocompleteData("list", "[today|week]", "List your upcoming and in-progress Webex meetings")
+	list.AddStaticListArgument("", false, []model.AutocompleteListItem{
+		{Item: listRangeToday, HelpText: "Meetings of today"},
+		{Item: listRangeWeek, HelpText: "Meetings of the next 7 days"},
+	})
+	webexAutocomplete.AddCommand(list)
+
+	share := model.NewAutocompleteData("share", "<meeting id>", "Share one of your Webex meetings in this channel")
+	share.AddTextArgument("Webex meeting ID, as shown by /webex list", "<meeting id>", "")
+	webexAutocomplete.AddCommand(share)
+
+	end := model.NewAutocompleteData("end", "", "End the meeting you are hosting")
+	webexAutocomplete.AddCommand(end)
+
+	notes := model.NewAutocompleteData("notes", "<text>", "Add a note to the thread of the meeting")
+	notes.AddTextArgument("Note", "<text>", "")
+	webexAutocomplete.AddCommand(notes)
+
+	actions := model.NewAutocompleteData("actions", "[item]", "Add an action item to the meeting, or list them")
+	actions.AddTextArgument("Action item", "[item]", "")
+	webexAutocomplete.AddCommand(actions)
+
+	schedule := model.NewAutocompleteData("schedule", "<when> <duration> [title] [@user ...] [~channel]", "Schedule a Webex meeting")
+	schedule.AddTextArgument("When the meeting starts, and its duration. For example: tomorrow 9:30am 15m", "<when> <duration>", "")
+	schedule.AddTextArgument("Meeting title, invitees and channel", "[title] [@user ...] [~channel]", "")
+	webexAutocomplete.AddCommand(schedule)
+
+	reminder := model.NewAutocompleteData("reminder", "[minutes|off]", "Shows or sets how long before meetings you are reminded")
+	reminder.AddTextArgument("Number of minutes, or off", "[minutes|off]", "")
+	webexAutocomplete.AddCommand(reminder)
+
+	channel := model.NewAutocompleteData("channel", "[command]", "Channel settings: reminder, room, room-reset")
+	channelReminder := model.NewAutocompleteData("reminder", "[minutes|off]", "Shows or sets how long before meetings this channel is reminded")
+	channelReminder.AddTextArgument("Number of minutes, or off", "[minutes|off]", "")
+	channel.AddCommand(channelReminder)
+	channelRoom := model.NewAutocompleteData("room", "[room id] [--force]", "Shows or sets the room used by the meetings started in this channel")
+	channelRoom.AddTextArgument("Room ID, and --force to skip its validation", "[room id] [--force]", "")
+	channel.AddCommand(channelRoom)
+	channelRoomReset := model.NewAutocompleteData("room-reset", "", "Removes this channel's room, so meetings use the room of whoever starts them")
+	channel.AddCommand(channelRoomReset)
+	webexAutocomplete.AddCommand(channel)
+
+	connect := model.NewAutocompleteData("connect", "", "Connect your Webex account")
+	webexAutocomplete.AddCommand(connect)
+
+	disconnect := model.NewAutocompleteData("disconnect", "", "Disconnect your Webex account")
+	webexAutocomplete.AddCommand(disconnect)
+
+	room := model.NewAutocompleteData("room", "<room id> [--force]", "Sets your personal Meeting Room ID")
+	room.AddTextArgument("Webex meeting room ID, and --force to set it even if it isn't found on Webex", "<room id> [--force]", "")
 	webexAutocomplete.AddCommand(room)
 
 	roomReset := model.NewAutocompleteData("room-reset", "", "Removes your room setting")
@@ -117,6 +214,23 @@ func getAutocompleteData() *model.AutocompleteData {
 	join.AddTextArgument("Webex room ID or Mattermost username", "<room id>/<@username>", "")
 	webexAutocomplete.AddCommand(join)
 
+	admin := model.NewAutocompleteData("admin", "[command]", "System admin commands: diagnose, import, export")
+	admin.RoleID = model.SystemAdminRoleId
+	adminDiagnose := model.NewAutocompleteData("diagnose", "", "Check the connection to the configured Webex sites")
+	adminDiagnose.RoleID = model.SystemAdminRoleId
+	admin.AddCommand(adminDiagnose)
+	adminImport := model.NewAutocompleteData("import", "[--dry-run] [--force]", "Set the rooms of users from the latest CSV file you posted in this channel")
+	adminImport.RoleID = model.SystemAdminRoleId
+	adminImport.AddStaticListArgument("", false, []model.AutocompleteListItem{
+		{Item: "--dry-run", HelpText: "Only report what would be imported"},
+		{Item: "--force", HelpText: "Also import the rooms that can't be validated"},
+	})
+	admin.AddCommand(adminImport)
+	adminExport := model.NewAutocompleteData("export", "", "Send you the rooms set by users as a CSV file")
+	adminExport.RoleID = model.SystemAdminRoleId
+	admin.AddCommand(adminExport)
+	webexAutocomplete.AddCommand(admin)
+
 	return webexAutocomplete
 }
 
@@ -143,29 +257,43 @@ func executeRoom(p *Plugin, _ *plugin.Context, header *model.CommandArgs, args .
 		roomID = defaultRoomText
 	}
 
-	if len(args) != 1 {
+	force, newRoomID := parseForceFlag(args)
+	if len(newRoomID) != 1 {
 		return p.responsef(header, "Please enter one new room id. Current room id is: `%s`", roomID)
 	}
 
-	userInfo, _ := p.store.LoadUserInfo(header.UserId)
-	userInfo.RoomID = args[0]
-	err = p.store.StoreUserInfo(header.UserId, userInfo)
+	check, refusal := p.checkRoom(header.ChannelId, newRoomID[0], force, "/webex room")
+	if refusal != "" {
+		return p.responsef(header, "%s", refusal)
+	}
+
+	previousRoomID := ""
+	err = p.store.UpdateUserInfo(header.UserId, func(info *UserInfo) error {
+		previousRoomID = info.RoomID
+		info.RoomID = newRoomID[0]
+		return nil
+	})
 	if err != nil {
 		p.errorf("error in executeRoom: %v", err)
 		return p.responsef(header, "Error storing user info, please contact your system administrator")
 	}
+	p.invalidatePMRCache(header.UserId, previousRoomID, newRoomID[0])
 
-	return p.responsef(header, "Room is set to: `%v`", userInfo.RoomID)
+	return p.responsef(header, "%s", check.describe(fmt.Sprintf("Room is set to: `%v`", newRoomID[0]), "Your Personal Room"))
 }
 
 func executeRoomReset(p *Plugin, _ *plugin.Context, header *model.CommandArgs, _ ...string) *model.CommandResponse {
-	userInfo, _ := p.store.LoadUserInfo(header.UserId)
-	userInfo.RoomID = ""
-	err := p.store.StoreUserInfo(header.UserId, userInfo)
+	previousRoomID := ""
+	err := p.store.UpdateUserInfo(header.UserId, func(info *UserInfo) error {
+		previousRoomID = info.RoomID
+		info.RoomID = ""
+		return nil
+	})
 	if err != nil {
 		p.errorf("error in executeRoom: %v", err)
 		return p.responsef(header, "Error storing user info, please contact your system administrator")
 	}
+	p.invalidatePMRCache(header.UserId, previousRoomID)
 
 	return p.responsef(header, "Room is set to: `%s`", defaultRoomText)
 }
@@ -173,26 +301,542 @@ func executeRoomReset(p *Plugin, _ *plugin.Context, header *model.CommandArgs, _
 func executeInfo(p *Plugin, _ *plugin.Context, header *model.CommandArgs, _ ...string) *model.CommandResponse {
 	roomID, err := p.getRoom(header.UserId)
 	if err != nil && err != ErrUserNotFound {
-		fmt.Printf("<><> err: %+v type: %T", err, err)
+		p.errorf("error in executeInfo: %v", err)
 		return p.responsef(header, "%s", err.Error())
 	}
+	identity := ""
 	if roomID == "" {
 		roomID = defaultRoomText
+		if user, userErr := p.getUser(header.UserId); userErr == nil {
+			if mapped := p.getConfiguration().webexIdentity(user); mapped != (webexIdentity{Username: user.Username, Email: user.Email}) {
+				identity = fmt.Sprintf("\nLooked up on Webex as: %s", formatWebexIdentity(mapped))
+			}
+		}
+	}
+
+	// The token is checked as it is stored: refreshing it would write the user's settings.
+	connected := "not connected"
+	if userInfo, userErr := p.store.LoadUserInfo(header.UserId); userErr == nil {
+		if token, tokenErr := p.decryptUserToken(header.UserId, userInfo); tokenErr == nil && (token.Valid() || token.CanRefresh()) {
+			connected = "connected"
+		}
+	}
+
+	channelRoom := ""
+	if channelInfo, channelErr := p.store.LoadChannelInfo(header.ChannelId); channelErr == nil && channelInfo.RoomID != "" {
+		channelRoom = fmt.Sprintf("\nThis channel's meeting room: `%s`", channelInfo.RoomID)
+	}
+
+	return p.responsef(header, "Webex site hostname: `%s`\nYour personal meeting room: `%s`%s%s\nYour Webex account: %s", p.siteHostForChannel(header.ChannelId), roomID, identity, channelRoom, connected)
+}
+
+func executeReminder(p *Plugin, _ *plugin.Context, header *model.CommandArgs, args ...string) *model.CommandResponse {
+	userInfo, err := p.store.LoadUserInfo(header.UserId)
+	if err != nil && err != ErrUserNotFound {
+		p.errorf("error in executeReminder: %v", err)
+		return p.responsef(header, "Error loading user info, please contact your system administrator")
+	}
+
+	if len(args) == 0 {
+		return p.responsef(header, "Your meeting reminders: %s", formatReminderMinutes(reminderMinutes(userInfo.ReminderMinutes)))
+	}
+	if len(args) != 1 {
+		return p.responsef(header, "Please enter a number of minutes, or `off`.")
+	}
+
+	minutes, err := parseReminderMinutes(args[0])
+	if err != nil {
+		return p.responsef(header, "%s", err.Error())
+	}
+
+	err = p.store.UpdateUserInfo(header.UserId, func(info *UserInfo) error {
+		info.ReminderMinutes = &minutes
+		return nil
+	})
+	if err != nil {
+		p.errorf("error in executeReminder: %v", err)
+		return p.responsef(header, "Error storing user info, please contact your system administrator")
 	}
 
-	return p.responsef(header, "Webex site hostname: `%s`\nYour personal meeting room: `%s`", p.getConfiguration().SiteHost, roomID)
+	return p.responsef(header, "Your meeting reminders are set to: %s", formatReminderMinutes(minutes))
 }
 
-func executeStart(p *Plugin, _ *plugin.Context, header *model.CommandArgs, _ ...string) *model.CommandResponse {
+func executeChannelReminder(p *Plugin, _ *plugin.Context, header *model.CommandArgs, args ...string) *model.CommandResponse {
+	channelInfo, err := p.store.LoadChannelInfo(header.ChannelId)
+	if err != nil {
+		p.errorf("error in executeChannelReminder: %v", err)
+		return p.responsef(header, "Error loading channel info, please contact your system administrator")
+	}
+
+	if len(args) == 0 {
+		if channelInfo.ReminderMinutes == nil {
+			return p.responsef(header, "This channel's meeting reminders: not set (using the host's setting)")
+		}
+		return p.responsef(header, "This channel's meeting reminders: %s", formatReminderMinutes(*channelInfo.ReminderMinutes))
+	}
+	if len(args) != 1 {
+		return p.responsef(header, "Please enter a number of minutes, or `off`.")
+	}
+
+	if !p.canManageChannel(header.UserId, header.ChannelId) {
+		return p.responsef(header, "Only channel admins can change this channel's settings.")
+	}
+
+	minutes, err := parseReminderMinutes(args[0])
+	if err != nil {
+		return p.responsef(header, "%s", err.Error())
+	}
+
+	channelInfo.ReminderMinutes = &minutes
+	if err = p.store.StoreChannelInfo(header.ChannelId, channelInfo); err != nil {
+		p.errorf("error in executeChannelReminder: %v", err)
+		return p.responsef(header, "Error storing channel info, please contact your system administrator")
+	}
+
+	return p.responsef(header, "This channel's meeting reminders are set to: %s", formatReminderMinutes(minutes))
+}
+
+func executeChannelRoom(p *Plugin, _ *plugin.Context, header *model.CommandArgs, args ...string) *model.CommandResponse {
+	channelInfo, err := p.store.LoadChannelInfo(header.ChannelId)
+	if err != nil {
+		p.errorf("error in executeChannelRoom: %v", err)
+		return p.responsef(header, "Error loading channel info, please contact your system administrator")
+	}
+
+	if len(args) == 0 {
+		if channelInfo.RoomID == "" {
+			return p.responsef(header, "This channel's meeting room: %s", defaultChannelRoomText)
+		}
+		return p.responsef(header, "This channel's meeting room: `%s`", channelInfo.RoomID)
+	}
+
+	force, newRoomID := parseForceFlag(args)
+	if len(newRoomID) != 1 {
+		return p.responsef(header, "Please enter one new room id.")
+	}
+
+	if !p.canManageChannel(header.UserId, header.ChannelId) {
+		return p.responsef(header, "Only channel admins can change this channel's settings.")
+	}
+
+	check, refusal := p.checkRoom(header.ChannelId, newRoomID[0], force, "/webex channel room")
+	if refusal != "" {
+		return p.responsef(header, "%s", refusal)
+	}
+
+	channelInfo.RoomID = newRoomID[0]
+	if err = p.store.StoreChannelInfo(header.ChannelId, channelInfo); err != nil {
+		p.errorf("error in executeChannelRoom: %v", err)
+		return p.responsef(header, "Error storing channel info, please contact your system administrator")
+	}
+	p.deletePMRCacheEntries(nil, []string{channelInfo.RoomID})
+
+	return p.responsef(header, "%s", check.describe(fmt.Sprintf("This channel's meeting room is set to: `%v`", channelInfo.RoomID), "Meetings started in this channel use"))
+}
+
+func executeChannelRoomReset(p *Plugin, _ *plugin.Context, header *model.CommandArgs, _ ...string) *model.CommandResponse {
+	channelInfo, err := p.store.LoadChannelInfo(header.ChannelId)
+	if err != nil {
+		p.errorf("error in executeChannelRoomReset: %v", err)
+		return p.responsef(header, "Error loading channel info, please contact your system administrator")
+	}
+
+	if !p.canManageChannel(header.UserId, header.ChannelId) {
+		return p.responsef(header, "Only channel admins can change this channel's settings.")
+	}
+
+	channelInfo.RoomID = ""
+	if err = p.store.StoreChannelInfo(header.ChannelId, channelInfo); err != nil {
+		p.errorf("error in executeChannelRoomReset: %v", err)
+		return p.responsef(header, "Error storing channel info, please contact your system administrator")
+	}
+
+	return p.responsef(header, "This channel's meeting room: %s", defaultChannelRoomText)
+}
+
+func executeAdminDiagnose(p *Plugin, _ *plugin.Context, header *model.CommandArgs, _ ...string) *model.CommandResponse {
+	if !p.isSystemAdmin(header.UserId) {
+		return p.responsef(header, "Only system admins can run this command.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	return p.responsef(header, "%s", formatDiagnosis(p.diagnose(ctx, header.UserId)))
+}
+
+func executeAdminImport(p *Plugin, _ *plugin.Context, header *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !p.isSystemAdmin(header.UserId) {
+		return p.responsef(header, "Only system admins can run this command.")
+	}
+	force, args := parseForceFlag(args)
+	dryRun := len(args) == 1 && args[0] == "--dry-run"
+	if len(args) > 1 || (len(args) == 1 && !dryRun) {
+		return p.responsef(header, "Please use `/webex admin import [--dry-run] [--force]`.")
+	}
+
+	data, err := p.findRoomImportFile(header.UserId, header.ChannelId)
+	if err != nil {
+		return p.responsef(header, "%s", err.Error())
+	}
+
+	// Validating hundreds of rooms on Webex takes longer than a slash command may.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), roomImportTimeout)
+		defer cancel()
+
+		result, err := p.importRooms(ctx, data, dryRun, force)
+		if err != nil {
+			p.dm(header.UserId, fmt.Sprintf("Failed to import the Webex rooms: %s", err.Error()))
+			return
+		}
+		p.dm(header.UserId, formatRoomImport(result))
+	}()
+
+	return p.responsef(header, "Validating the rooms on Webex. The report will be sent to you by direct message.")
+}
+
+func executeAdminExport(p *Plugin, _ *plugin.Context, header *model.CommandArgs, _ ...string) *model.CommandResponse {
+	if !p.isSystemAdmin(header.UserId) {
+		return p.responsef(header, "Only system admins can run this command.")
+	}
+
+	data, count, err := p.exportRooms()
+	if err != nil {
+		p.errorf("error in executeAdminExport: %v", err)
+		return p.responsef(header, "Error loading the rooms of users, please check the server logs")
+	}
+
+	if err = p.sendFile(header.UserId, roomExportFileName, fmt.Sprintf("The rooms set by %d users.", count), data); err != nil {
+		p.errorf("error in executeAdminExport: %v", err)
+		return p.responsef(header, "Error sending the export, please check the server logs")
+	}
+	return p.responsef(header, "Exported the rooms of %d users. The file was sent to you by direct message.", count)
+}
+
+func executeConnect(p *Plugin, _ *plugin.Context, header *model.CommandArgs, _ ...string) *model.CommandResponse {
+	if !p.getConfiguration().IsOAuthConfigured() {
+		return p.responsef(header, "Connecting Webex accounts has not been configured. Please contact your system administrator.")
+	}
+
+	return p.responsef(header, "[Click here to connect your Webex account.](%s%s)", p.GetPluginURL(), routeOAuthConnect)
+}
+
+func executeDisconnect(p *Plugin, _ *plugin.Context, header *model.CommandArgs, _ ...string) *model.CommandResponse {
+	userInfo, err := p.store.LoadUserInfo(header.UserId)
+	if err == ErrUserNotFound || (err == nil && userInfo.EncryptedToken == "") {
+		return p.responsef(header, "Your Webex account is not connected.")
+	}
+	if err != nil {
+		p.errorf("error in executeDisconnect: %v", err)
+		return p.responsef(header, "Error loading user info, please contact your system administrator")
+	}
+
+	if token, tokenErr := p.getUserToken(header.UserId); tokenErr == nil {
+		p.unregisterWebhooks(header.UserId, token)
+	}
+
+	if err = p.storeUserToken(header.UserId, nil); err != nil {
+		p.errorf("error in executeDisconnect: %v", err)
+		return p.responsef(header, "Error storing user info, please contact your system administrator")
+	}
+
+	return p.responsef(header, "Your Webex account has been disconnected.")
+}
+
+func executeStart(p *Plugin, _ *plugin.Context, header *model.CommandArgs, args ...string) *model.CommandResponse {
+	force, _ := parseForceFlag(args)
 	details := meetingDetails{
 		startedByUserID:     header.UserId,
 		meetingRoomOfUserID: header.UserId,
 		channelID:           header.ChannelId,
 		meetingStatus:       webex.StatusStarted,
+		preventDuplicate:    !force,
 	}
-	if _, _, err := p.startMeeting(details); err != nil {
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	if _, _, err := p.startChannelMeeting(ctx, details); err != nil {
+		var runningErr *meetingRunningError
+		if errors.As(err, &runningErr) {
+			p.notifyMeetingRunning(header.UserId, details, runningErr, nil)
+			return &model.CommandResponse{}
+		}
+		return p.responsef(header, "%s", err.Error())
+	}
+	return &model.CommandResponse{}
+}
+
+func executeStartNew(p *Plugin, _ *plugin.Context, header *model.CommandArgs, args ...string) *model.CommandResponse {
+	force, args := parseForceFlag(args)
+	details := meetingDetails{
+		startedByUserID:     header.UserId,
+		meetingRoomOfUserID: header.UserId,
+		channelID:           header.ChannelId,
+		meetingStatus:       webex.StatusStarted,
+		preventDuplicate:    !force,
+	}
+	request := webex.CreateMeetingRequest{
+		Title: topicOrDefault(strings.Join(args, " ")),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	if _, _, err := p.startNewMeeting(ctx, details, request); err != nil {
+		var runningErr *meetingRunningError
+		if errors.As(err, &runningErr) {
+			p.notifyMeetingRunning(header.UserId, details, runningErr, &request)
+			return &model.CommandResponse{}
+		}
+		return p.responsef(header, "%s", err.Error())
+	}
+	return &model.CommandResponse{}
+}
+
+// parseForceFlag reports whether args include `--force`, and returns the other args.
+// roomCheck is the outcome of looking up a room before setting it: its Personal Room, or else a warning explaining why
+// it wasn't found.
+type roomCheck struct {
+	pmr     *webex.PMR
+	warning string
+}
+
+// checkRoom looks up the Personal Room of roomID on the Webex site of channelID. Unless force is set, a room that isn't
+// found is refused, with the refusal suggesting to set it anyway with setCommand.
+func (p *Plugin) checkRoom(channelID, roomID string, force bool, setCommand string) (check *roomCheck, refusal string) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	siteHost := p.siteHostForChannel(channelID)
+	pmr, err := p.getPersonalMeetingRoom(ctx, siteHost, roomID)
+	if err == nil {
+		return &roomCheck{pmr: pmr}, ""
+	}
+
+	warning := describeWebexLookupError(siteHost, err)
+	if warning == "" {
+		warning = fmt.Sprintf("No Personal Room was found at `%s` for the room: `%s`.%s", siteHost, roomID, explainLookupError(err))
+	}
+	if !force {
+		return nil, fmt.Sprintf("%s\nPlease check the room ID, or use `%s %s --force` to set it anyway.", warning, setCommand, roomID)
+	}
+	return &roomCheck{warning: warning}, ""
+}
+
+// describe renders the response to setting the checked room: set, the setting, followed by a link to the Personal
+// Room labeled label, or by the reason it wasn't validated.
+func (c *roomCheck) describe(set, label string) string {
+	if c.pmr == nil {
+		return fmt.Sprintf("%s, without validation.\n%s", set, c.warning)
+	}
+	title := c.pmr.Title
+	if title == "" {
+		title = c.pmr.PMRUrl
+	}
+	return fmt.Sprintf("%s\n%s: [%s](%s)", set, label, title, c.pmr.PMRUrl)
+}
+
+func parseForceFlag(args []string) (bool, []string) {
+	force := false
+	var rest []string
+	for _, arg := range args {
+		if arg == "--force" {
+			force = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return force, rest
+}
+
+func executeList(p *Plugin, _ *plugin.Context, header *model.CommandArgs, args ...string) *model.CommandResponse {
+	if len(args) > 1 {
+		return p.responsef(header, "Please use `/webex list`, `/webex list %s` or `/webex list %s`", listRangeToday, listRangeWeek)
+	}
+	rangeName := listRangeToday
+	if len(args) == 1 {
+		rangeName = strings.ToLower(args[0])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	meetings, location, err := p.listUpcomingMeetings(ctx, header.UserId, rangeName)
+	if err != nil {
+		return p.responsef(header, "%s", err.Error())
+	}
+
+	when := "today"
+	if rangeName == listRangeWeek {
+		when = "in the next 7 days"
+	}
+	if len(meetings) == 0 {
+		return p.responsef(header, "You have no upcoming meetings %s.", when)
+	}
+
+	return p.responsef(header, "###### Your Webex meetings %s\n%s", when, p.formatMeetingsTable(meetings, location))
+}
+
+func executeShare(p *Plugin, _ *plugin.Context, header *model.CommandArgs, args ...string) *model.CommandResponse {
+	if len(args) != 1 {
+		return p.responsef(header, "Please specify the meeting to share, as listed by `/webex list`.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	if _, _, err := p.shareWebexMeeting(ctx, header.UserId, header.ChannelId, args[0]); err != nil {
+		return p.responsef(header, "%s", err.Error())
+	}
+	return &model.CommandResponse{}
+}
+
+func executeNotes(p *Plugin, _ *plugin.Context, header *model.CommandArgs, args ...string) *model.CommandResponse {
+	meeting, err := p.getThreadMeeting(header.ChannelId, header.RootId)
+	if err == ErrMeetingNotFound {
+		return p.responsef(header, "No meeting has been started in this channel.")
+	}
+	if err != nil {
+		p.errorf("error in executeNotes: %v", err)
+		return p.responsef(header, "Error loading the meetings of this channel, please contact your system administrator")
+	}
+
+	text := strings.TrimSpace(strings.Join(args, " "))
+	if text == "" {
+		return p.responsef(header, "Please enter the note to add to the thread of [%s](%s).", meeting.Title, p.getPermalink(meeting.PostID))
+	}
+
+	if err = p.postInMeetingThread(header.UserId, meeting, "**Note:** "+text); err != nil {
+		p.errorf("error in executeNotes: %v", err)
+		return p.responsef(header, "Error adding the note, please contact your system administrator")
+	}
+	return &model.CommandResponse{}
+}
+
+func executeActions(p *Plugin, _ *plugin.Context, header *model.CommandArgs, args ...string) *model.CommandResponse {
+	meeting, err := p.getThreadMeeting(header.ChannelId, header.RootId)
+	if err == ErrMeetingNotFound {
+		return p.responsef(header, "No meeting has been started in this channel.")
+	}
+	if err != nil {
+		p.errorf("error in executeActions: %v", err)
+		return p.responsef(header, "Error loading the meetings of this channel, please contact your system administrator")
+	}
+
+	text := strings.TrimSpace(strings.Join(args, " "))
+	if text == "" {
+		if len(meeting.ActionItems) == 0 {
+			return p.responsef(header, "[%s](%s) has no action items yet. Add one with `/webex actions <item>`.", meeting.Title, p.getPermalink(meeting.PostID))
+		}
+		return p.responsef(header, "###### Action items of [%s](%s)\n%s", meeting.Title, p.getPermalink(meeting.PostID), p.formatActionItems(meeting))
+	}
+
+	if err = p.addActionItem(header.UserId, meeting, text); err != nil {
+		p.errorf("error in executeActions: %v", err)
+		return p.responsef(header, "Error adding the action item, please contact your system administrator")
+	}
+	return &model.CommandResponse{}
+}
+
+func executeEnd(p *Plugin, _ *plugin.Context, header *model.CommandArgs, _ ...string) *model.CommandResponse {
+	meeting, err := p.getCurrentMeeting(header.UserId, header.ChannelId)
+	if err == ErrMeetingNotFound {
+		return p.responsef(header, "You are not hosting any meeting.")
+	}
+	if err != nil {
+		p.errorf("error in executeEnd: %v", err)
+		return p.responsef(header, "Error loading your meetings, please contact your system administrator")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	if err = p.endMeeting(ctx, header.UserId, meeting); err != nil {
 		return p.responsef(header, "%s", err.Error())
 	}
+
+	return p.responsef(header, "Meeting `%s` has ended after %s.", meeting.Title, formatDuration(meeting.Duration()))
+}
+
+func executeSchedule(p *Plugin, _ *plugin.Context, header *model.CommandArgs, args ...string) *model.CommandResponse {
+	if len(args) < 2 {
+		return p.responsef(header, "Please specify when the meeting starts and its duration, for example: `/webex schedule tomorrow 9:30am 15m Standup @alice ~team-standup`")
+	}
+
+	location := p.getUserLocation(header.UserId)
+	start, n, err := parseMeetingTime(args, time.Now().In(location))
+	if err != nil {
+		return p.responsef(header, "%s", err.Error())
+	}
+	if start.Before(time.Now().Add(-time.Minute)) {
+		return p.responsef(header, "The meeting cannot start in the past: `%s`", start.Format(scheduleTimeFormat))
+	}
+	if len(args) <= n {
+		return p.responsef(header, "Please specify the duration of the meeting, for example `30m` or `1h30m`")
+	}
+	duration, err := parseMeetingDuration(args[n])
+	if err != nil {
+		return p.responsef(header, "%s", err.Error())
+	}
+
+	channelID := header.ChannelId
+	var titleWords []string
+	var inviteeUserIDs []string
+	var invitees []webex.Invitee
+	for _, arg := range args[n+1:] {
+		switch {
+		case strings.HasPrefix(arg, "@") && len(arg) > 1:
+			user, appErr := p.API.GetUserByUsername(arg[1:])
+			if appErr != nil {
+				return p.responsef(header, "Could not find the user `%s`. Please make sure you typed the name correctly and try again.", arg)
+			}
+			email, _, emailErr := p.getEmailAndUserName(user.Id)
+			if emailErr != nil {
+				return p.responsef(header, "%s", emailErr.Error())
+			}
+			inviteeUserIDs = append(inviteeUserIDs, user.Id)
+			invitees = append(invitees, webex.Invitee{Email: email})
+		case strings.HasPrefix(arg, "~") && len(arg) > 1:
+			channel, appErr := p.API.GetChannelByName(header.TeamId, arg[1:], false)
+			if appErr != nil {
+				return p.responsef(header, "Could not find the channel `%s`.", arg)
+			}
+			if _, appErr = p.API.GetChannelMember(channel.Id, header.UserId); appErr != nil {
+				return p.responsef(header, "You must be a member of `%s` to schedule a meeting in it.", arg)
+			}
+			channelID = channel.Id
+		default:
+			titleWords = append(titleWords, arg)
+		}
+	}
+
+	details := meetingDetails{
+		startedByUserID: header.UserId,
+		channelID:       channelID,
+		meetingStatus:   webex.StatusScheduled,
+		start:           start,
+		location:        location,
+		inviteeUserIDs:  inviteeUserIDs,
+	}
+	request := webex.CreateMeetingRequest{
+		Title:    topicOrDefault(strings.Join(titleWords, " ")),
+		Start:    start,
+		Duration: duration,
+		Invitees: invitees,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	if _, _, err = p.startNewMeeting(ctx, details, request); err != nil {
+		return p.responsef(header, "%s", err.Error())
+	}
+
+	if channelID != header.ChannelId {
+		return p.responsef(header, "Meeting `%s` scheduled for %s.", request.Title, start.Format(scheduleTimeFormat))
+	}
 	return &model.CommandResponse{}
 }
 
@@ -208,6 +852,10 @@ func executeStartWithArg(p *Plugin, _ *plugin.Context, header *model.CommandArgs
 		meetingStatus:   webex.StatusInvited,
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	siteHost := p.siteHostForChannel(header.ChannelId)
 	arg := args[0]
 	if strings.HasPrefix(arg, "@") {
 		// we were given a user
@@ -216,16 +864,16 @@ func executeStartWithArg(p *Plugin, _ *plugin.Context, header *model.CommandArgs
 			return p.responsef(header, "Could not find the user `%s`. Please make sure you typed the name correctly and try again.", arg)
 		}
 		details.meetingRoomOfUserID = user.Id
-		if _, _, err := p.startMeeting(details); err != nil {
-			return p.responsef(header, "Unable to create a meeting at `%s` for user: `%s`. They may not have their roomID set correctly, or their Mattermost email is not the same as their Webex email.", p.getConfiguration().SiteHost, arg)
+		if _, _, err := p.startMeeting(ctx, details); err != nil {
+			return p.responsef(header, "Unable to create a meeting at `%s` for user: `%s`. They may not have their roomID set correctly, or their Mattermost email is not the same as their Webex email.", siteHost, arg)
 		}
 		return &model.CommandResponse{}
 	}
 
 	// we were given a roomID
-	roomURL, err := p.getURLFromRoomID(arg)
+	roomURL, err := p.getURLFromRoomID(ctx, siteHost, arg)
 	if err != nil {
-		return p.responsef(header, "No Personal Room link found at `%s` for the room: `%s`", p.getConfiguration().SiteHost, arg)
+		return p.responsef(header, "No Personal Room link found at `%s` for the room: `%s`", siteHost, arg)
 	}
 
 	details.roomURL = roomURL
@@ -0,0 +1,175 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unknownRoomClient finds no Personal Room other than the ones of MockClient's users.
+type unknownRoomClient struct {
+	webex.MockClient
+}
+
+func (c unknownRoomClient) GetPersonalMeetingRoom(_ context.Context, roomID string) (*webex.PMR, error) {
+	return nil, &webex.LookupError{Failures: []webex.LookupFailure{
+		{Identifier: webex.IdentifierRoomID, Value: roomID, Err: webex.ErrUserNotFound},
+	}}
+}
+
+func TestExecuteRoom(t *testing.T) {
+	newPlugin := func(client webex.Client) (*Plugin, *userStore, *[]string) {
+		api := &plugintest.API{}
+		api.On("GetUser", "theuserid").Return(&model.User{Email: "alice@example.com", Username: "alice"}, nil)
+		responses := recordEphemeralPosts(api, "theuserid")
+
+		store := newUserStore(map[string]UserInfo{"theuserid": {Email: "alice@example.com"}})
+		return newTestPlugin(api, &configuration{SiteHost: "site.webex.com"}, store, client), store, responses
+	}
+	header := &model.CommandArgs{UserId: "theuserid", ChannelId: "thechannelid"}
+
+	t.Run("shows the validated room", func(t *testing.T) {
+		p, store, responses := newPlugin(webex.MockClient{SiteHost: "site.webex.com"})
+
+		executeRoom(p, nil, header, "alice.room")
+		assert.Equal(t, "alice.room", store.stored["theuserid"].RoomID)
+		require.Len(t, *responses, 1)
+		assert.Contains(t, (*responses)[0], "[alice.room's Personal Room](https://site.webex.com/meet/alice.room)")
+	})
+
+	t.Run("refuses an unknown room", func(t *testing.T) {
+		p, store, responses := newPlugin(unknownRoomClient{})
+
+		executeRoom(p, nil, header, "typo.room")
+		assert.Empty(t, store.stored)
+		require.Len(t, *responses, 1)
+		assert.Contains(t, (*responses)[0], "No Personal Room was found at `site.webex.com` for the room: `typo.room`")
+		assert.Contains(t, (*responses)[0], "`/webex room typo.room --force`")
+	})
+
+	t.Run("forces an unknown room", func(t *testing.T) {
+		p, store, responses := newPlugin(unknownRoomClient{})
+
+		executeRoom(p, nil, header, "--force", "typo.room")
+		assert.Equal(t, "typo.room", store.stored["theuserid"].RoomID)
+		require.Len(t, *responses, 1)
+		assert.Contains(t, (*responses)[0], "Room is set to: `typo.room`, without validation.")
+	})
+}
+
+// channelStore keeps the settings of a single channel.
+type channelStore struct {
+	mockStore
+
+	channelInfo ChannelInfo
+}
+
+func (store *channelStore) StoreChannelInfo(_ string, info ChannelInfo) error {
+	store.channelInfo = info
+	return nil
+}
+
+func (store *channelStore) LoadChannelInfo(_ string) (ChannelInfo, error) {
+	return store.channelInfo, nil
+}
+
+func TestExecuteChannelRoom(t *testing.T) {
+	newPlugin := func(channelAdmin bool) (*Plugin, *channelStore, *[]string) {
+		api := &plugintest.API{}
+		api.On("GetChannelMember", "thechannelid", "theuserid").Return(&model.ChannelMember{SchemeAdmin: channelAdmin}, nil)
+		api.On("GetChannel", "thechannelid").Return(&model.Channel{Id: "thechannelid", Type: model.ChannelTypeOpen}, nil)
+		api.On("HasPermissionTo", "theuserid", model.PermissionManageSystem).Return(false)
+		responses := recordEphemeralPosts(api, "theuserid")
+
+		store := &channelStore{}
+		return newTestPlugin(api, &configuration{SiteHost: "site.webex.com"}, store, webex.MockClient{SiteHost: "site.webex.com"}), store, responses
+	}
+	header := &model.CommandArgs{UserId: "theuserid", ChannelId: "thechannelid"}
+
+	t.Run("sets the validated room", func(t *testing.T) {
+		p, store, responses := newPlugin(true)
+
+		executeChannelRoom(p, nil, header, "standup.room")
+		assert.Equal(t, "standup.room", store.channelInfo.RoomID)
+		require.Len(t, *responses, 1)
+		assert.Contains(t, (*responses)[0], "[standup.room's Personal Room](https://site.webex.com/meet/standup.room)")
+
+		executeChannelRoomReset(p, nil, header)
+		assert.Empty(t, store.channelInfo.RoomID)
+	})
+
+	t.Run("refuses an unknown room unless forced", func(t *testing.T) {
+		p, store, responses := newPlugin(true)
+		p.setWebexClients(map[string]webex.Client{"site.webex.com": unknownRoomClient{}})
+
+		executeChannelRoom(p, nil, header, "typo.room")
+		assert.Empty(t, store.channelInfo.RoomID)
+		require.Len(t, *responses, 1)
+		assert.Contains(t, (*responses)[0], "`/webex channel room typo.room --force`")
+
+		executeChannelRoom(p, nil, header, "typo.room", "--force")
+		assert.Equal(t, "typo.room", store.channelInfo.RoomID)
+		require.Len(t, *responses, 2)
+		assert.Contains(t, (*responses)[1], "This channel's meeting room is set to: `typo.room`, without validation.")
+	})
+
+	t.Run("only channel admins can change it", func(t *testing.T) {
+		p, store, responses := newPlugin(false)
+		store.channelInfo.RoomID = "standup.room"
+
+		executeChannelRoom(p, nil, header, "other.room")
+		executeChannelRoomReset(p, nil, header)
+		assert.Equal(t, "standup.room", store.channelInfo.RoomID)
+		require.Len(t, *responses, 2)
+		assert.Equal(t, "Only channel admins can change this channel's settings.", (*responses)[0])
+
+		executeChannelRoom(p, nil, header)
+		assert.Equal(t, "This channel's meeting room: `standup.room`", (*responses)[2])
+	})
+}
+
+func TestExecuteStartInChannelRoom(t *testing.T) {
+	var joinPost *model.Post
+	api := &plugintest.API{}
+	api.On("CreatePost", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+		joinPost = args.Get(0).(*model.Post)
+	}).Return(&model.Post{Id: "thepostid"}, nil)
+	api.On("SendEphemeralPost", "theuserid", mock.AnythingOfType("*model.Post")).Return(&model.Post{Id: "thestartpostid"})
+	api.On("KVSetWithOptions", "mutex_channel_meetings_thechannelid", mock.Anything, mock.Anything).Return(true, nil)
+
+	p := newTestPlugin(api, &configuration{SiteHost: "site.webex.com"}, &channelStore{channelInfo: ChannelInfo{RoomID: "standup.room"}}, webex.MockClient{SiteHost: "site.webex.com"})
+
+	executeStart(p, nil, &model.CommandArgs{UserId: "theuserid", ChannelId: "thechannelid"})
+	require.NotNil(t, joinPost)
+	assert.Equal(t, "https://site.webex.com/meet/standup.room", joinPost.Props["meeting_link"])
+}
+
+func TestExecuteInfo(t *testing.T) {
+	config := newOAuthConfiguration("site.webex.com")
+	encryptedToken := newEncryptedToken(t, config, &webex.Token{AccessToken: "theexpiredtoken", RefreshToken: "therefreshtoken", Expiry: time.Now().Add(-time.Hour)})
+
+	api := &plugintest.API{}
+	responses := recordEphemeralPosts(api, "theuserid")
+
+	s := newUserStore(map[string]UserInfo{
+		"theuserid": {MattermostUserID: "theuserid", Email: "alice@example.com", RoomID: "alice.room", EncryptedToken: encryptedToken},
+	})
+	p := newTestPlugin(api, config, s, nil)
+
+	executeInfo(p, nil, &model.CommandArgs{UserId: "theuserid", ChannelId: "thechannelid"})
+	require.Len(t, *responses, 1)
+	assert.Contains(t, (*responses)[0], "Your personal meeting room: `alice.room`")
+	assert.Contains(t, (*responses)[0], "Your Webex account: connected")
+	assert.Empty(t, s.stored, "the expired token is not refreshed")
+}
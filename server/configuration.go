@@ -5,12 +5,15 @@ package main
 
 import (
 	"encoding/json"
+	"net/url"
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-plugin-webex/server/webex"
 
+	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/pkg/errors"
 )
 
@@ -28,11 +31,57 @@ import (
 type configuration struct {
 	SiteHost string `json:"sitehost"`
 
+	// SiteName is the name of the site at SiteHost, required when it can't be derived from SiteHost.
+	SiteName string `json:"sitename"`
+
 	URLConversion bool `json:"url_conversion"`
 
-	// siteName is the SiteHost up to .webex.com
+	// URLRewriteDomains are the comma-separated domains of the meeting links converted by URLConversion. Site names
+	// are also derived from the hostnames under them.
+	URLRewriteDomains string `json:"urlrewritedomains"`
+
+	// TeamSites maps teams to the Webex site of their meetings, one `<team id> = <site host>` per line. Teams that
+	// aren't listed use SiteHost.
+	TeamSites string `json:"teamsites"`
+
+	// IdentityMappingRules rewrite the Mattermost username and email of users before they are looked up on Webex, one
+	// rule per line.
+	IdentityMappingRules string `json:"identitymappingrules"`
+
+	// RequestTimeoutSeconds bounds each request made to the Webex site.
+	RequestTimeoutSeconds int `json:"requesttimeoutseconds"`
+
+	// PersonalRoomCacheMinutes is how long Personal Room lookups are cached. 0 uses the default, negative disables it.
+	PersonalRoomCacheMinutes int `json:"personalroomcacheminutes"`
+
+	// OAuthClientID and OAuthClientSecret identify the Webex integration used to act on behalf of users.
+	OAuthClientID     string `json:"oauthclientid"`
+	OAuthClientSecret string `json:"oauthclientsecret"`
+
+	// EncryptionKey is used to encrypt the users' Webex tokens at rest.
+	EncryptionKey string `json:"encryptionkey"`
+
+	// WebhookSecret is used to verify the signature of the events Webex sends to the plugin.
+	WebhookSecret string `json:"webhooksecret"`
+
+	// siteName is the SiteHost up to .webex.com, or SiteName when set
 	// Eg., for testsite.my.webex.com, siteName would be: testsite.my
 	siteName string
+
+	// teamSiteHosts is TeamSites parsed, by team ID.
+	teamSiteHosts map[string]string
+
+	// siteNames are the site names set explicitly, by site host.
+	siteNames map[string]string
+
+	// urlRewriteDomains is URLRewriteDomains parsed.
+	urlRewriteDomains []string
+
+	// identityRules is IdentityMappingRules parsed.
+	identityRules []identityRule
+
+	// processErr is the problem found by process in the settings, which leave the plugin unconfigured.
+	processErr error
 }
 
 // Clone shallow copies the configuration. Your implementation may require a deep copy if
@@ -45,7 +94,12 @@ func (c *configuration) Clone() *configuration {
 // IsValid checks if all needed fields are set.
 func (c *configuration) IsValid() bool {
 	// If we can't get the site name from the siteHost, then the site host has not been correctly set.
-	return parseSiteNameFromSiteHost(c.SiteHost) != ""
+	return c.siteName != ""
+}
+
+// IsOAuthConfigured checks if users can connect their Webex accounts.
+func (c *configuration) IsOAuthConfigured() bool {
+	return c.OAuthClientID != "" && c.OAuthClientSecret != "" && c.EncryptionKey != ""
 }
 
 // getConfiguration retrieves the active configuration under lock, making it safe to use
@@ -108,15 +162,158 @@ func (p *Plugin) OnConfigurationChange() error {
 		_ = p.API.SavePluginConfig(asMap)
 	}
 
-	configuration.siteName = parseSiteNameFromSiteHost(host)
+	// The System Console rejects invalid settings, but they may have been saved elsewhere. They are still stored, so
+	// that /webex admin diagnose can report the problem, but the user commands stay disabled.
+	if err := configuration.process(); err != nil {
+		p.errorf("invalid plugin configuration: %v", err)
+		configuration.siteName = ""
+		configuration.processErr = err
+	}
 
 	p.setConfiguration(configuration)
 
-	p.webexClient = webex.NewClient(configuration.SiteHost, configuration.siteName)
+	p.setWebexClients(p.newWebexClients(configuration))
+
+	// The reminder and recording jobs are started by OnActivate once the plugin is ready.
+	if p.botUserID != "" {
+		p.ensureReminderJob()
+		p.ensureRecordingJob()
+	}
 
 	return nil
 }
 
+// ConfigurationWillBeSaved rejects invalid plugin settings, so the System Console shows the admin what is wrong.
+func (p *Plugin) ConfigurationWillBeSaved(newCfg *model.Config) (*model.Config, error) {
+	settings, ok := newCfg.PluginSettings.Plugins[manifest.Id]
+	if !ok {
+		return nil, nil
+	}
+
+	var configuration = new(configuration)
+	asJSON, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(asJSON, configuration); err != nil {
+		return nil, errors.Wrap(err, "failed to read the Webex plugin settings")
+	}
+
+	if err = configuration.process(); err != nil {
+		return nil, errors.Wrap(err, "invalid Webex plugin settings")
+	}
+	return nil, nil
+}
+
+// process computes the fields derived from the settings, or returns an error explaining what is wrong with them.
+// An empty SiteHost is allowed, as the plugin is just not configured yet.
+func (c *configuration) process() error {
+	c.SiteHost = parseHostFromURL(c.SiteHost)
+	c.urlRewriteDomains = parseDomains(c.URLRewriteDomains)
+
+	teamSiteHosts, siteNames, err := parseTeamSites(c.TeamSites)
+	if err != nil {
+		return errors.Wrap(err, "Team Webex Sites")
+	}
+	c.teamSiteHosts = teamSiteHosts
+	c.siteNames = siteNames
+
+	identityRules, err := parseIdentityRules(c.IdentityMappingRules)
+	if err != nil {
+		return errors.Wrap(err, "Identity Mapping Rules")
+	}
+	c.identityRules = identityRules
+
+	if siteName := strings.TrimSpace(c.SiteName); siteName != "" && c.SiteHost != "" {
+		c.siteNames[c.SiteHost] = siteName
+	}
+	c.siteName = c.siteNameForHost(c.SiteHost)
+
+	if c.SiteHost != "" && c.siteName == "" {
+		return errors.Errorf("the site name of `%s` can't be derived from its hostname, please set the Webex Site Name", c.SiteHost)
+	}
+	for _, siteHost := range c.siteHosts() {
+		if c.siteNameForHost(siteHost) == "" {
+			return errors.Errorf("Team Webex Sites: the site name of `%s` can't be derived from its hostname, please add it after the hostname: `<team id> = %s, <site name>`", siteHost, siteHost)
+		}
+	}
+	return nil
+}
+
+// siteNameForHost returns the site name of siteHost: set explicitly, or derived from a hostname under webex.com or
+// one of the URL rewrite domains. It returns "" when neither applies.
+func (c *configuration) siteNameForHost(siteHost string) string {
+	if siteName, ok := c.siteNames[siteHost]; ok {
+		return siteName
+	}
+	if siteName := parseSiteNameFromSiteHost(siteHost); siteName != "" {
+		return siteName
+	}
+	for _, domain := range c.urlRewriteDomains {
+		if siteName, ok := strings.CutSuffix(siteHost, "."+domain); ok && siteName != "" {
+			return siteName
+		}
+	}
+	return ""
+}
+
+// rewriteMeetingURL replaces /meet/ by /action/ in meetingURL, when URL conversion is enabled and the host of
+// meetingURL is one of the URL rewrite domains or under one of them.
+func (c *configuration) rewriteMeetingURL(meetingURL, action string) string {
+	if !c.URLConversion {
+		return meetingURL
+	}
+
+	u, err := url.Parse(meetingURL)
+	if err != nil {
+		return meetingURL
+	}
+	path, ok := strings.CutPrefix(u.Path, "/meet/")
+	if !ok {
+		return meetingURL
+	}
+
+	domains := c.urlRewriteDomains
+	if len(domains) == 0 {
+		domains = []string{defaultURLRewriteDomain}
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			u.Path = "/" + action + "/" + path
+			if rawPath, ok := strings.CutPrefix(u.RawPath, "/meet/"); ok {
+				u.RawPath = "/" + action + "/" + rawPath
+			}
+			return u.String()
+		}
+	}
+	return meetingURL
+}
+
+// webexClientOptions returns the options of the Webex XML API client, leaving the defaults for what isn't configured.
+func (c *configuration) webexClientOptions() webex.ClientOptions {
+	return webex.ClientOptions{
+		RequestTimeout: time.Duration(c.RequestTimeoutSeconds) * time.Second,
+	}
+}
+
+const defaultURLRewriteDomain = "webex.com"
+
+// parseDomains parses a comma-separated list of domains, defaulting to webex.com.
+func parseDomains(domains string) []string {
+	var parsed []string
+	for _, domain := range strings.Split(domains, ",") {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain != "" {
+			parsed = append(parsed, domain)
+		}
+	}
+	if len(parsed) == 0 {
+		return []string{defaultURLRewriteDomain}
+	}
+	return parsed
+}
+
 func parseHostFromURL(url string) string {
 	r := regexp.MustCompile("^https?://(.*?)(/|$)")
 	matches := r.FindStringSubmatch(url)
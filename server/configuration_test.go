@@ -0,0 +1,133 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigurationProcess(t *testing.T) {
+	teamID := model.NewId()
+
+	for name, tc := range map[string]struct {
+		configuration    configuration
+		expectedSiteName string
+		expectedErr      string
+	}{
+		"not configured": {
+			configuration: configuration{},
+		},
+		"webex.com site": {
+			configuration:    configuration{SiteHost: "https://acme.my.webex.com/"},
+			expectedSiteName: "acme.my",
+		},
+		"site under a rewrite domain": {
+			configuration:    configuration{SiteHost: "acme.webexgov.us", URLRewriteDomains: "webex.com, webexgov.us"},
+			expectedSiteName: "acme",
+		},
+		"explicit site name": {
+			configuration:    configuration{SiteHost: "meet.acme.example.com", SiteName: " acme "},
+			expectedSiteName: "acme",
+		},
+		"unknown site name": {
+			configuration: configuration{SiteHost: "meet.acme.example.com"},
+			expectedErr:   "please set the Webex Site Name",
+		},
+		"unknown team site name": {
+			configuration: configuration{SiteHost: "acme.webex.com", TeamSites: teamID + " = meet.acme.example.com"},
+			expectedErr:   "please add it after the hostname",
+		},
+		"invalid team sites": {
+			configuration: configuration{SiteHost: "acme.webex.com", TeamSites: "sales = sales.webex.com"},
+			expectedErr:   "not a valid team ID",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := tc.configuration
+			err := c.process()
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedSiteName, c.siteName)
+			assert.Equal(t, tc.expectedSiteName != "", c.IsValid())
+		})
+	}
+}
+
+func TestConfigurationWillBeSaved(t *testing.T) {
+	p := Plugin{}
+
+	newConfig := func(settings map[string]any) *model.Config {
+		config := &model.Config{}
+		config.PluginSettings.Plugins = map[string]map[string]any{manifest.Id: settings}
+		return config
+	}
+
+	_, err := p.ConfigurationWillBeSaved(newConfig(map[string]any{"sitehost": "acme.webex.com"}))
+	assert.NoError(t, err)
+
+	_, err = p.ConfigurationWillBeSaved(newConfig(map[string]any{"sitehost": "meet.acme.example.com"}))
+	assert.Error(t, err)
+
+	_, err = p.ConfigurationWillBeSaved(&model.Config{})
+	assert.NoError(t, err)
+}
+
+func TestOnConfigurationChangeWithInvalidSettings(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("LoadPluginConfiguration", mock.AnythingOfType("*main.configuration")).Run(func(args mock.Arguments) {
+		config := args.Get(0).(*configuration)
+		config.SiteHost = "acme.webex.com"
+		config.TeamSites = "not a team = other.webex.com"
+	}).Return(nil)
+	api.On("LogError", mock.Anything).Return()
+
+	p := &Plugin{}
+	p.SetAPI(api)
+	require.NoError(t, p.OnConfigurationChange())
+
+	config := p.getConfiguration()
+	assert.Equal(t, "acme.webex.com", config.SiteHost, "the settings are stored")
+	assert.False(t, config.IsValid(), "the user commands are disabled")
+	assert.Contains(t, p.diagnose(context.Background(), "theuserid").Problems[0], "Team Webex Sites")
+}
+
+func TestRewriteMeetingURL(t *testing.T) {
+	c := configuration{URLConversion: true}
+	require.NoError(t, c.process())
+	assert.Equal(t, "https://acme.webex.com/join/alice", c.rewriteMeetingURL("https://acme.webex.com/meet/alice", "join"))
+	assert.Equal(t, "https://acme.webexgov.us/meet/alice", c.rewriteMeetingURL("https://acme.webexgov.us/meet/alice", "join"))
+
+	c = configuration{URLConversion: true, URLRewriteDomains: "webex.com, webexgov.us"}
+	require.NoError(t, c.process())
+	assert.Equal(t, "https://acme.webexgov.us/start/alice", c.rewriteMeetingURL("https://acme.webexgov.us/meet/alice", "start"))
+
+	c.URLConversion = false
+	assert.Equal(t, "https://acme.webexgov.us/meet/alice", c.rewriteMeetingURL("https://acme.webexgov.us/meet/alice", "start"))
+
+	c = configuration{URLConversion: true}
+	require.NoError(t, c.process())
+	for meetingURL, expected := range map[string]string{
+		"https://webex.com/meet/alice":                 "https://webex.com/join/alice",
+		"https://ACME.Webex.com/meet/alice?x=1":        "https://ACME.Webex.com/join/alice?x=1",
+		"https://acme.webex.com:443/meet/al%2Fice":     "https://acme.webex.com:443/join/al%2Fice",
+		"https://evil-webex.com/meet/alice":            "https://evil-webex.com/meet/alice",
+		"https://acme.webex.com.evil.com/meet/alice":   "https://acme.webex.com.evil.com/meet/alice",
+		"https://evil.com/acme.webex.com/meet/alice":   "https://evil.com/acme.webex.com/meet/alice",
+		"https://evil.com/?next=acme.webex.com/meet/a": "https://evil.com/?next=acme.webex.com/meet/a",
+		"https://acme.webex.com/other/meet/alice":      "https://acme.webex.com/other/meet/alice",
+	} {
+		assert.Equal(t, expected, c.rewriteMeetingURL(meetingURL, "join"), meetingURL)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// lookupHost resolves the site hosts. Replaced in tests.
+var lookupHost = net.DefaultResolver.LookupHost
+
+// diagnosis is the outcome of checking the configuration of the plugin against Webex.
+type diagnosis struct {
+	// SampleEmail is the email looked up on each site, the one of the admin running the diagnosis.
+	SampleEmail string          `json:"sample_email"`
+	Sites       []siteDiagnosis `json:"sites"`
+	OAuth       *oauthDiagnosis `json:"oauth,omitempty"`
+	Problems    []string        `json:"problems,omitempty"`
+}
+
+type siteDiagnosis struct {
+	SiteHost     string   `json:"site_host"`
+	SiteName     string   `json:"site_name"`
+	URL          string   `json:"url"`
+	Addresses    []string `json:"addresses,omitempty"`
+	ResolveError string   `json:"resolve_error,omitempty"`
+	Result       string   `json:"result,omitempty"`
+	Reason       string   `json:"reason,omitempty"`
+	ExceptionID  string   `json:"exception_id,omitempty"`
+	PMRUrl       string   `json:"pmr_url,omitempty"`
+	LatencyMS    int64    `json:"latency_ms"`
+	Error        string   `json:"error,omitempty"`
+
+	// OK is true when the site answered the sample request, even if it found no Personal Room for the sample user.
+	OK bool `json:"ok"`
+}
+
+type oauthDiagnosis struct {
+	Connected   bool   `json:"connected"`
+	DisplayName string `json:"display_name,omitempty"`
+	LatencyMS   int64  `json:"latency_ms,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// diagnose checks every configured site, and the OAuth token of mattermostUserID when OAuth is configured.
+func (p *Plugin) diagnose(ctx context.Context, mattermostUserID string) *diagnosis {
+	config := p.getConfiguration()
+	result := &diagnosis{}
+
+	if config.processErr != nil {
+		result.Problems = append(result.Problems, fmt.Sprintf("The plugin settings are invalid: %v.", config.processErr))
+		return result
+	}
+	if !config.IsValid() {
+		result.Problems = append(result.Problems, "The Webex Site Hostname is not set, or its site name can't be determined.")
+		return result
+	}
+
+	email, _, err := p.getEmailAndUserName(mattermostUserID)
+	if err != nil {
+		result.Problems = append(result.Problems, err.Error())
+		return result
+	}
+	result.SampleEmail = email
+
+	for _, siteHost := range config.siteHosts() {
+		result.Sites = append(result.Sites, p.diagnoseSite(ctx, siteHost, email))
+	}
+
+	if config.IsOAuthConfigured() {
+		result.OAuth = p.diagnoseOAuth(ctx, mattermostUserID)
+		if config.WebhookSecret == "" {
+			result.Problems = append(result.Problems, "The Webhook Secret has not been generated, so meeting posts won't be updated by Webex.")
+		}
+	}
+
+	return result
+}
+
+func (p *Plugin) diagnoseSite(ctx context.Context, siteHost, email string) siteDiagnosis {
+	result := siteDiagnosis{SiteHost: siteHost, SiteName: p.getConfiguration().siteNameForHost(siteHost)}
+
+	addresses, err := lookupHost(ctx, siteHost)
+	if err != nil {
+		result.ResolveError = err.Error()
+		return result
+	}
+	result.Addresses = addresses
+
+	client, err := p.getWebexClient(siteHost)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	check, err := client.CheckSite(ctx, email)
+	if check != nil {
+		result.URL = check.URL
+		result.LatencyMS = check.Latency.Milliseconds()
+		result.Result = check.Response.Result
+		result.Reason = check.Response.Reason
+		result.ExceptionID = check.Response.ExceptionID
+		result.PMRUrl = check.PMRUrl
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	failure := check.Failure()
+	result.OK = failure == nil || errors.Is(failure, webex.ErrUserNotFound)
+	return result
+}
+
+func (p *Plugin) diagnoseOAuth(ctx context.Context, mattermostUserID string) *oauthDiagnosis {
+	result := &oauthDiagnosis{}
+
+	token, err := p.getUserToken(mattermostUserID)
+	if err == ErrNotConnected {
+		return result
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Connected = true
+
+	started := time.Now()
+	person, err := p.webexRESTClient.GetMe(ctx, token.AccessToken)
+	result.LatencyMS = time.Since(started).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.DisplayName = person.DisplayName
+	return result
+}
+
+// formatDiagnosis renders d as markdown.
+func formatDiagnosis(d *diagnosis) string {
+	var b strings.Builder
+	b.WriteString("#### Webex plugin diagnosis\n")
+	for _, problem := range d.Problems {
+		fmt.Fprintf(&b, "* **Problem:** %s\n", problem)
+	}
+
+	if len(d.Sites) > 0 {
+		fmt.Fprintf(&b, "Looked up `%s` on each site:\n\n", d.SampleEmail)
+		b.WriteString("| Site | Site name | Status | Response | Latency |\n")
+		b.WriteString("| :--- | :-------- | :----- | :------- | ------: |\n")
+		for _, site := range d.Sites {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+				site.SiteHost, escapeTableCell(site.SiteName), site.status(), escapeTableCell(site.response()), site.latency())
+		}
+	}
+
+	if d.OAuth != nil {
+		b.WriteString("\n")
+		switch {
+		case d.OAuth.Error != "":
+			fmt.Fprintf(&b, "* **OAuth:** your Webex token doesn't work: %s\n", d.OAuth.Error)
+		case !d.OAuth.Connected:
+			b.WriteString("* **OAuth:** configured. Connect your own account with `/webex connect` to check it.\n")
+		default:
+			fmt.Fprintf(&b, "* **OAuth:** your token works, connected as %s (%d ms).\n", d.OAuth.DisplayName, d.OAuth.LatencyMS)
+		}
+	}
+	return b.String()
+}
+
+func (s siteDiagnosis) status() string {
+	switch {
+	case s.ResolveError != "":
+		return "Hostname not found"
+	case s.Error != "":
+		return "Unreachable"
+	case !s.OK:
+		return "Refused"
+	}
+	return "OK"
+}
+
+func (s siteDiagnosis) response() string {
+	switch {
+	case s.ResolveError != "":
+		return s.ResolveError
+	case s.Error != "":
+		return s.Error
+	case s.PMRUrl != "":
+		return s.Result + ", Personal Room: " + s.PMRUrl
+	case s.Reason != "":
+		return fmt.Sprintf("%s: %s (exception %s)", s.Result, s.Reason, s.ExceptionID)
+	}
+	return s.Result
+}
+
+func (s siteDiagnosis) latency() string {
+	if s.ResolveError != "" {
+		return ""
+	}
+	return fmt.Sprintf("%d ms", s.LatencyMS)
+}
+
+func (p *Plugin) isSystemAdmin(userID string) bool {
+	return p.API.HasPermissionTo(userID, model.PermissionManageSystem)
+}
+
+func (p *Plugin) handleDiagnose(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodGet {
+		return http.StatusMethodNotAllowed, errors.New("method " + r.Method + " is not allowed, must be GET")
+	}
+
+	userID := r.Header.Get("Mattermost-User-Id")
+	if userID == "" {
+		return http.StatusUnauthorized, errors.New("not authorized")
+	}
+	if !p.isSystemAdmin(userID) {
+		return http.StatusForbidden, errors.New("forbidden")
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), commandTimeout)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(p.diagnose(ctx, userID)); err != nil {
+		p.API.LogWarn("failed to write response", "error", err.Error())
+	}
+	return http.StatusOK, nil
+}
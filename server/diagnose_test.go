@@ -0,0 +1,85 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleDiagnose(t *testing.T) {
+	originalLookupHost := lookupHost
+	defer func() { lookupHost = originalLookupHost }()
+	lookupHost = func(_ context.Context, host string) ([]string, error) {
+		if host == "missing.webex.com" {
+			return nil, errors.New("no such host")
+		}
+		return []string{"192.0.2.1"}, nil
+	}
+
+	newPlugin := func(admin bool) *Plugin {
+		api := &plugintest.API{}
+		api.On("HasPermissionTo", "theuserid", model.PermissionManageSystem).Return(admin)
+		api.On("GetUser", "theuserid").Return(&model.User{Email: "alice@example.com", Username: "alice"}, nil)
+
+		config := &configuration{
+			SiteHost:  "hostname.webex.com",
+			TeamSites: model.NewId() + " = missing.webex.com",
+		}
+		require.NoError(t, config.process())
+		p := newTestPlugin(api, config, nil, nil)
+		p.setWebexClients(map[string]webex.Client{
+			"hostname.webex.com": webex.MockClient{SiteHost: "hostname.webex.com"},
+			"missing.webex.com":  webex.MockClient{SiteHost: "missing.webex.com"},
+		})
+		return p
+	}
+
+	t.Run("reports every site", func(t *testing.T) {
+		p := newPlugin(true)
+		r := httptest.NewRequest(http.MethodGet, routeAPIadminDiagnose, nil)
+		r.Header.Set("Mattermost-User-Id", "theuserid")
+		w := httptest.NewRecorder()
+
+		status, err := handleHTTPRequest(p, w, r)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+
+		var d diagnosis
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&d))
+		assert.Equal(t, "alice@example.com", d.SampleEmail)
+		require.Len(t, d.Sites, 2)
+		assert.True(t, d.Sites[0].OK)
+		assert.Equal(t, webex.ResultSuccess, d.Sites[0].Result)
+		assert.Equal(t, "https://hostname.webex.com/meet/alice", d.Sites[0].PMRUrl)
+		assert.False(t, d.Sites[1].OK)
+		assert.Equal(t, "no such host", d.Sites[1].ResolveError)
+		assert.Nil(t, d.OAuth)
+
+		text := formatDiagnosis(&d)
+		assert.Contains(t, text, "| hostname.webex.com | hostname | OK |")
+		assert.Contains(t, text, "| missing.webex.com | missing | Hostname not found |")
+	})
+
+	t.Run("forbidden to other users", func(t *testing.T) {
+		p := newPlugin(false)
+		r := httptest.NewRequest(http.MethodGet, routeAPIadminDiagnose, nil)
+		r.Header.Set("Mattermost-User-Id", "theuserid")
+
+		status, err := handleHTTPRequest(p, httptest.NewRecorder(), r)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+}
@@ -4,6 +4,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-plugin-webex/server/webex"
 
@@ -18,7 +20,15 @@ import (
 )
 
 const (
-	routeAPImeetings = "/api/v1/meetings"
+	routeAPImeetings           = "/api/v1/meetings"
+	routeAPIactiveMeetings     = "/api/v1/meetings/active"
+	routeAPIendMeeting         = "/api/v1/meetings/end"
+	routeAPIstartMeetingAnyway = "/api/v1/meetings/start-anyway"
+	routeAPIrecordingPassword  = "/api/v1/meetings/recording-password"
+	routeOAuthConnect          = "/oauth2/connect"
+	routeOAuthComplete         = "/oauth2/complete"
+	routeWebhook               = "/api/v1/webhooks/webex"
+	routeAPIadminDiagnose      = "/api/v1/admin/diagnose"
 )
 
 func (p *Plugin) ServeHTTP(_ *plugin.Context, w http.ResponseWriter, r *http.Request) {
@@ -42,9 +52,26 @@ func (p *Plugin) ServeHTTP(_ *plugin.Context, w http.ResponseWriter, r *http.Req
 		"RequestURI", r.RequestURI, "Method", r.Method, "query", r.URL.Query().Encode())
 }
 
-func handleHTTPRequest(p *Plugin, w io.Writer, r *http.Request) (int, error) {
-	if strings.EqualFold(r.URL.Path, routeAPImeetings) {
+func handleHTTPRequest(p *Plugin, w http.ResponseWriter, r *http.Request) (int, error) {
+	switch {
+	case strings.EqualFold(r.URL.Path, routeAPImeetings):
 		return p.handleStartMeeting(w, r)
+	case strings.EqualFold(r.URL.Path, routeAPIactiveMeetings):
+		return p.handleGetActiveMeetings(w, r)
+	case strings.EqualFold(r.URL.Path, routeAPIendMeeting):
+		return p.handleEndMeeting(w, r)
+	case strings.EqualFold(r.URL.Path, routeAPIstartMeetingAnyway):
+		return p.handleStartMeetingAnyway(w, r)
+	case strings.EqualFold(r.URL.Path, routeAPIrecordingPassword):
+		return p.handleRecordingPassword(w, r)
+	case strings.EqualFold(r.URL.Path, routeWebhook):
+		return p.handleWebhook(w, r)
+	case strings.EqualFold(r.URL.Path, routeAPIadminDiagnose):
+		return p.handleDiagnose(w, r)
+	case strings.EqualFold(r.URL.Path, routeOAuthConnect):
+		return p.handleOAuthConnect(w, r)
+	case strings.EqualFold(r.URL.Path, routeOAuthComplete):
+		return p.handleOAuthComplete(w, r)
 	}
 	return http.StatusNotFound, errors.New("not found")
 }
@@ -52,6 +79,15 @@ func handleHTTPRequest(p *Plugin, w io.Writer, r *http.Request) (int, error) {
 type startMeetingRequest struct {
 	ChannelID string `json:"channel_id"`
 	MeetingID int    `json:"meeting_id"`
+
+	// Personal defaults to true. When false, a unique meeting is created through the Webex REST API.
+	Personal *bool  `json:"personal"`
+	Topic    string `json:"topic"`
+	Password string `json:"password"`
+	Duration int    `json:"duration"` // in minutes
+
+	// Force starts the meeting even when another one is running in the channel.
+	Force bool `json:"force"`
 }
 
 func (p *Plugin) handleStartMeeting(w io.Writer, r *http.Request) (int, error) {
@@ -87,9 +123,30 @@ func (p *Plugin) handleStartMeeting(w io.Writer, r *http.Request) (int, error) {
 		meetingRoomOfUserID: userID,
 		channelID:           req.ChannelID,
 		meetingStatus:       webex.StatusStarted,
+		preventDuplicate:    !req.Force,
 	}
 
-	posts, status, err := p.startMeeting(details)
+	ctx, cancel := context.WithTimeout(r.Context(), commandTimeout)
+	defer cancel()
+
+	var posts *meetingPosts
+	var status int
+	var err error
+	var request *webex.CreateMeetingRequest
+	if req.Personal != nil && !*req.Personal {
+		request = &webex.CreateMeetingRequest{
+			Title:    topicOrDefault(req.Topic),
+			Password: req.Password,
+			Duration: time.Duration(req.Duration) * time.Minute,
+		}
+		posts, status, err = p.startNewMeeting(ctx, details, *request)
+	} else {
+		posts, status, err = p.startChannelMeeting(ctx, details)
+	}
+	var runningErr *meetingRunningError
+	if errors.As(err, &runningErr) {
+		p.notifyMeetingRunning(userID, details, runningErr, request)
+	}
 	if err != nil {
 		return status, err
 	}
@@ -100,3 +157,87 @@ func (p *Plugin) handleStartMeeting(w io.Writer, r *http.Request) (int, error) {
 
 	return status, nil
 }
+
+func (p *Plugin) handleGetActiveMeetings(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodGet {
+		return http.StatusMethodNotAllowed,
+			errors.New("method " + r.Method + " is not allowed, must be GET")
+	}
+
+	userID := r.Header.Get("Mattermost-User-Id")
+	if userID == "" {
+		return http.StatusUnauthorized, errors.New("not authorized")
+	}
+
+	channelID := r.URL.Query().Get("channel_id")
+	if channelID == "" {
+		return http.StatusBadRequest, errors.New("channel id required")
+	}
+
+	if _, appErr := p.API.GetChannelMember(channelID, userID); appErr != nil {
+		return http.StatusForbidden, errors.New("forbidden")
+	}
+
+	meetings, err := p.getActiveMeetings(channelID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(meetings); err != nil {
+		p.API.LogWarn("failed to write response", "error", err.Error())
+	}
+
+	return http.StatusOK, nil
+}
+
+type endMeetingRequest struct {
+	PostID string `json:"post_id"`
+}
+
+func (p *Plugin) handleEndMeeting(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed,
+			errors.New("method " + r.Method + " is not allowed, must be POST")
+	}
+
+	userID := r.Header.Get("Mattermost-User-Id")
+	if userID == "" {
+		return http.StatusUnauthorized, errors.New("not authorized")
+	}
+
+	var req endMeetingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("err: %v", err)
+	}
+
+	if req.PostID == "" {
+		return http.StatusBadRequest, errors.New("post id required")
+	}
+
+	meeting, err := p.store.LoadMeeting(req.PostID)
+	if err == ErrMeetingNotFound {
+		return http.StatusNotFound, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	switch err = p.endMeeting(r.Context(), userID, meeting); err {
+	case nil:
+		// The webapp expects a JSON response.
+		w.Header().Set("Content-Type", "application/json")
+		if err = json.NewEncoder(w).Encode(map[string]string{"status": "OK"}); err != nil {
+			p.API.LogWarn("failed to write response", "error", err.Error())
+		}
+		return http.StatusOK, nil
+	case ErrNotMeetingHost:
+		return http.StatusForbidden, err
+	case ErrMeetingAlreadyEnded:
+		return http.StatusBadRequest, err
+	case ErrNotConnected:
+		return http.StatusUnauthorized, err
+	default:
+		return http.StatusInternalServerError, err
+	}
+}
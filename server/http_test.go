@@ -9,8 +9,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/mattermost/mattermost-plugin-webex/server/webex"
 
@@ -18,6 +20,7 @@ import (
 	"github.com/mattermost/mattermost/server/public/plugin"
 	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
 	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -58,7 +61,7 @@ func TestPlugin(t *testing.T) {
 		strings.NewReader("{\"channellll_id\": \"thechannelid\"}"))
 	invalidMeetingRequestNoChannel.Header.Add("Mattermost-User-Id", "theuserid")
 
-	validUser := UserInfo{"myemail@test.com", "myroom"}
+	validUser := UserInfo{Email: "myemail@test.com", RoomID: "myroom"}
 
 	for _, tc := range []struct {
 		Name               string
@@ -175,6 +178,11 @@ func TestPlugin(t *testing.T) {
 			api.On("GetServerVersion").Return("5.10.0")
 
 			user, _ := json.Marshal(tc.User)
+			sweepJobMetadata, _ := json.Marshal(cluster.JobMetadata{LastFinished: time.Now()})
+			api.On("KVGet", "cron_"+userSweepJobKey).Return(sweepJobMetadata, (*model.AppError)(nil))
+			api.On("KVSetWithOptions", "mutex_cron_"+userSweepJobKey, mock.Anything, mock.Anything).Return(true, nil)
+			api.On("KVSetWithOptions", "mutex_channel_meetings_thechannelid", mock.Anything, mock.Anything).Return(true, nil)
+			api.On("KVGet", keySchemaVersion).Return([]byte(strconv.Itoa(schemaVersion)), (*model.AppError)(nil))
 			api.On("KVGet", mock.AnythingOfTypeArgument("string")).Return(user, (*model.AppError)(nil))
 			api.On("KVSetWithOptions", "mutex_mmi_bot_ensure", mock.AnythingOfType("[]uint8"), model.PluginKVSetOptions{Atomic: true, OldValue: []uint8(nil), ExpireInSeconds: 15}).Return(true, nil)
 			api.On("KVSetWithOptions", "mutex_mmi_bot_ensure", []byte(nil), model.PluginKVSetOptions{ExpireInSeconds: 0}).Return(true, nil)
@@ -201,9 +209,10 @@ func TestPlugin(t *testing.T) {
 
 			err = p.OnActivate()
 			require.Nil(t, err)
+			defer func() { _ = p.OnDeactivate() }()
 
-			p.store = mockStore{tc.User}
-			p.webexClient = webex.MockClient{SiteHost: tc.SiteHost}
+			p.store = mockStore{userInfo: tc.User}
+			p.setWebexClients(map[string]webex.Client{tc.SiteHost: webex.MockClient{SiteHost: tc.SiteHost}})
 
 			w := httptest.NewRecorder()
 
@@ -239,3 +248,117 @@ func TestPlugin(t *testing.T) {
 		})
 	}
 }
+
+func TestEndMeeting(t *testing.T) {
+	activeMeeting := &Meeting{
+		PostID:      "thepostid",
+		ChannelID:   "thechannelid",
+		HostUserID:  "theuserid",
+		Status:      webex.StatusStarted,
+		StartPostID: "thestartpostid",
+		CreatedAt:   time.Now().Add(-10 * time.Minute),
+		StartedAt:   time.Now().Add(-10 * time.Minute),
+	}
+	endedMeeting := *activeMeeting
+	endedMeeting.PostID = "theendedpostid"
+	endedMeeting.Status = webex.StatusEnded
+	endedMeeting.EndedAt = time.Now()
+
+	for _, tc := range []struct {
+		Name               string
+		UserID             string
+		Body               string
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "Unauthorized request",
+			Body:               `{"post_id": "thepostid"}`,
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:               "No post id",
+			UserID:             "theuserid",
+			Body:               `{}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Unknown meeting",
+			UserID:             "theuserid",
+			Body:               `{"post_id": "unknownpostid"}`,
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:               "Not the host",
+			UserID:             "otheruserid",
+			Body:               `{"post_id": "thepostid"}`,
+			ExpectedStatusCode: http.StatusForbidden,
+		},
+		{
+			Name:               "Already ended",
+			UserID:             "theuserid",
+			Body:               `{"post_id": "theendedpostid"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Host ends the meeting",
+			UserID:             "theuserid",
+			Body:               `{"post_id": "thepostid"}`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			api := &plugintest.API{}
+			api.On("LogDebug", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+				mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			api.On("LogError", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+				mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			api.On("KVSetWithOptions", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(true, nil)
+			api.On("GetPost", "thepostid").Return(&model.Post{Id: "thepostid", Type: "custom_webex"}, nil)
+			api.On("UpdatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{}, nil)
+			api.On("DeleteEphemeralPost", "theuserid", "thestartpostid").Return()
+			api.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{}, nil)
+
+			p := Plugin{}
+			p.setConfiguration(&configuration{
+				SiteHost: "hostname.webex.com",
+				siteName: "hostname",
+			})
+			p.SetAPI(api)
+
+			active := *activeMeeting
+			ended := endedMeeting
+			p.store = mockStore{meetings: map[string]*Meeting{
+				active.PostID: &active,
+				ended.PostID:  &ended,
+			}}
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/meetings/end", strings.NewReader(tc.Body))
+			if tc.UserID != "" {
+				r.Header.Add("Mattermost-User-Id", tc.UserID)
+			}
+			w := httptest.NewRecorder()
+
+			p.ServeHTTP(&plugin.Context{}, w, r)
+			assert.Equal(t, tc.ExpectedStatusCode, w.Result().StatusCode)
+
+			if tc.ExpectedStatusCode != http.StatusOK {
+				api.AssertNotCalled(t, "UpdatePost", mock.Anything)
+				return
+			}
+			assert.JSONEq(t, `{"status":"OK"}`, w.Body.String(), "the webapp parses the response")
+
+			stored, err := p.store.LoadMeeting("thepostid")
+			require.NoError(t, err)
+			assert.Equal(t, webex.StatusEnded, stored.Status)
+			assert.False(t, stored.EndedAt.IsZero())
+
+			api.AssertCalled(t, "UpdatePost", mock.MatchedBy(func(post *model.Post) bool {
+				return post.GetProp("meeting_status") == webex.StatusEnded && post.GetProp("meeting_duration") != nil
+			}))
+			api.AssertCalled(t, "DeleteEphemeralPost", "theuserid", "thestartpostid")
+			api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+				return post.RootId == "thepostid" && strings.HasPrefix(post.Message, "#### Meeting summary")
+			}))
+		})
+	}
+}
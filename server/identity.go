@@ -0,0 +1,199 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	identityFieldRoom     = "room"
+	identityFieldUsername = "username"
+	identityFieldEmail    = "email"
+
+	identityRuleDomain    = "domain"
+	identityRuleRegex     = "regex"
+	identityRuleAttribute = "attribute"
+
+	identityAttributeAuthData = "authdata"
+	identityAttributePrefix   = "props."
+)
+
+// webexIdentity is what a Mattermost user is looked up by on Webex.
+type webexIdentity struct {
+	RoomID   string
+	Username string
+	Email    string
+}
+
+// identityRule rewrites the Webex identity of a Mattermost user. It is one of:
+//   - `domain <mattermost domain> = <webex domain>` replaces the domain of the email.
+//   - `regex <email|username> <pattern> = <replacement>` replaces the matches of pattern in the email or username.
+//     The replacement can refer to the groups of pattern, e.g. `${1}`.
+//   - `attribute <authdata|props.<key>> = <room|username|email>` uses the AuthData of the user, set by LDAP or SAML,
+//     or one of their Props, when not empty.
+type identityRule struct {
+	kind string
+
+	// field is the part of the identity the rule changes.
+	field string
+
+	// from and to are the domains of a domain rule, or the attribute of an attribute rule in from.
+	from string
+	to   string
+
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// parseIdentityRules parses the IdentityMappingRules setting, one rule per line. Blank lines and lines starting with #
+// are ignored.
+func parseIdentityRules(rules string) ([]identityRule, error) {
+	var parsed []identityRule
+	for i, line := range strings.Split(rules, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule, err := parseIdentityRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		parsed = append(parsed, rule)
+	}
+	return parsed, nil
+}
+
+func parseIdentityRule(line string) (identityRule, error) {
+	kind, rest, _ := strings.Cut(line, " ")
+	kind = strings.ToLower(kind)
+
+	// The replacement of a regex is less likely to contain " = " than its pattern.
+	separator := strings.LastIndex(rest, " = ")
+	if separator < 0 {
+		return identityRule{}, fmt.Errorf("expected `%s ... = ...`", kind)
+	}
+	left := strings.TrimSpace(rest[:separator])
+	right := strings.TrimSpace(rest[separator+len(" = "):])
+
+	switch kind {
+	case identityRuleDomain:
+		from := strings.Trim(strings.ToLower(left), "@")
+		to := strings.Trim(strings.ToLower(right), "@")
+		if from == "" || to == "" || strings.ContainsAny(from+to, " @") {
+			return identityRule{}, fmt.Errorf("expected `domain <mattermost domain> = <webex domain>`")
+		}
+		return identityRule{kind: kind, field: identityFieldEmail, from: from, to: to}, nil
+
+	case identityRuleRegex:
+		field, pattern, _ := strings.Cut(left, " ")
+		field = strings.ToLower(field)
+		pattern = strings.TrimSpace(pattern)
+		if (field != identityFieldEmail && field != identityFieldUsername) || pattern == "" {
+			return identityRule{}, fmt.Errorf("expected `regex <email|username> <pattern> = <replacement>`")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return identityRule{}, fmt.Errorf("invalid pattern `%s`: %w", pattern, err)
+		}
+		return identityRule{kind: kind, field: field, pattern: re, replacement: right}, nil
+
+	case identityRuleAttribute:
+		attribute := left
+		if strings.EqualFold(attribute, identityAttributeAuthData) {
+			attribute = identityAttributeAuthData
+		}
+		if attribute != identityAttributeAuthData && (!strings.HasPrefix(attribute, identityAttributePrefix) || attribute == identityAttributePrefix) {
+			return identityRule{}, fmt.Errorf("unknown attribute `%s`, expected `%s` or `%s<key>`", attribute, identityAttributeAuthData, identityAttributePrefix)
+		}
+		field := strings.ToLower(right)
+		if field != identityFieldRoom && field != identityFieldUsername && field != identityFieldEmail {
+			return identityRule{}, fmt.Errorf("expected `attribute %s = <room|username|email>`", attribute)
+		}
+		return identityRule{kind: kind, field: field, from: attribute}, nil
+	}
+	return identityRule{}, fmt.Errorf("unknown rule `%s`, expected `domain`, `regex` or `attribute`", kind)
+}
+
+// apply rewrites identity for user.
+func (r identityRule) apply(user *model.User, identity *webexIdentity) {
+	value := identity.get(r.field)
+
+	switch r.kind {
+	case identityRuleDomain:
+		local, domain, ok := strings.Cut(value, "@")
+		if ok && strings.EqualFold(domain, r.from) {
+			value = local + "@" + r.to
+		}
+	case identityRuleRegex:
+		value = r.pattern.ReplaceAllString(value, r.replacement)
+	case identityRuleAttribute:
+		if attribute := userAttribute(user, r.from); attribute != "" {
+			value = attribute
+		}
+	}
+
+	identity.set(r.field, value)
+}
+
+func userAttribute(user *model.User, attribute string) string {
+	if attribute == identityAttributeAuthData {
+		if user.AuthData == nil {
+			return ""
+		}
+		return strings.TrimSpace(*user.AuthData)
+	}
+	return strings.TrimSpace(user.Props[strings.TrimPrefix(attribute, identityAttributePrefix)])
+}
+
+func (i *webexIdentity) get(field string) string {
+	switch field {
+	case identityFieldRoom:
+		return i.RoomID
+	case identityFieldUsername:
+		return i.Username
+	}
+	return i.Email
+}
+
+func (i *webexIdentity) set(field, value string) {
+	switch field {
+	case identityFieldRoom:
+		i.RoomID = value
+	case identityFieldUsername:
+		i.Username = value
+	default:
+		i.Email = value
+	}
+}
+
+// webexIdentity returns what user is looked up by on Webex when they haven't set a room: their Mattermost username and
+// email, rewritten by the identity mapping rules.
+func (c *configuration) webexIdentity(user *model.User) webexIdentity {
+	identity := webexIdentity{Username: user.Username, Email: user.Email}
+	for _, rule := range c.identityRules {
+		rule.apply(user, &identity)
+	}
+	return identity
+}
+
+// formatWebexIdentity lists the identifiers of identity, as markdown.
+func formatWebexIdentity(identity webexIdentity) string {
+	var identifiers []string
+	if identity.RoomID != "" {
+		identifiers = append(identifiers, fmt.Sprintf("room `%s`", identity.RoomID))
+	}
+	if identity.Username != "" {
+		identifiers = append(identifiers, fmt.Sprintf("username `%s`", identity.Username))
+	}
+	if identity.Email != "" {
+		identifiers = append(identifiers, fmt.Sprintf("email `%s`", identity.Email))
+	}
+	return strings.Join(identifiers, ", ")
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebexIdentity(t *testing.T) {
+	authData := "alice.ldap"
+	user := &model.User{
+		Username: "alice",
+		Email:    "Alice.Smith@corp.com",
+		AuthData: &authData,
+		Props:    model.StringMap{"webex_room": "asmith"},
+	}
+
+	for name, tc := range map[string]struct {
+		rules    string
+		expected webexIdentity
+	}{
+		"no rules": {
+			expected: webexIdentity{Username: "alice", Email: "Alice.Smith@corp.com"},
+		},
+		"domain rewrite": {
+			rules:    "# Webex uses its own domain\ndomain @CORP.com = corp.webex.com\ndomain other.com = other.webex.com",
+			expected: webexIdentity{Username: "alice", Email: "Alice.Smith@corp.webex.com"},
+		},
+		"regex": {
+			rules:    `regex email ^([^.]+)\.([^@]+)@.*$ = ${2}.${1}@webex.corp.com` + "\nregex username ^ = mm-",
+			expected: webexIdentity{Username: "mm-alice", Email: "Smith.Alice@webex.corp.com"},
+		},
+		"attributes": {
+			rules:    "attribute authdata = username\nattribute props.webex_room = room\nattribute props.missing = email",
+			expected: webexIdentity{RoomID: "asmith", Username: "alice.ldap", Email: "Alice.Smith@corp.com"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			config := &configuration{IdentityMappingRules: tc.rules}
+			require.NoError(t, config.process())
+			assert.Equal(t, tc.expected, config.webexIdentity(user))
+		})
+	}
+}
+
+func TestParseIdentityRules(t *testing.T) {
+	for name, rules := range map[string]string{
+		"unknown rule":       "rewrite corp.com = corp.webex.com",
+		"missing separator":  "domain corp.com corp.webex.com",
+		"invalid domain":     "domain alice@corp.com = corp.webex.com",
+		"invalid field":      "regex room ^a = b",
+		"invalid pattern":    "regex email ( = b",
+		"unknown attribute":  "attribute position = username",
+		"unknown target":     "attribute authdata = nickname",
+		"empty props key":    "attribute props. = email",
+		"error on next line": "domain corp.com = corp.webex.com\nregex email",
+	} {
+		_, err := parseIdentityRules(rules)
+		assert.Error(t, err, name)
+	}
+
+	_, err := parseIdentityRules("domain corp.com = corp.webex.com\n\nregex email\n")
+	assert.ErrorContains(t, err, "line 3")
+}
+
+// emailClient answers lookups from the rooms of emails.
+type emailClient struct {
+	webex.Client
+
+	rooms  map[string]string
+	emails []string
+}
+
+func (c *emailClient) GetPersonalMeetingRoomURL(_ context.Context, _, _, email string) (string, error) {
+	c.emails = append(c.emails, email)
+	if pmrURL, ok := c.rooms[email]; ok {
+		return pmrURL, nil
+	}
+	return "", &webex.LookupError{Failures: []webex.LookupFailure{
+		{Identifier: webex.IdentifierEmail, Value: email, Err: webex.ErrUserNotFound},
+	}}
+}
+
+func TestGetRoomURLFromMMIdWithIdentityRules(t *testing.T) {
+	newPlugin := func(client webex.Client) *Plugin {
+		api := &plugintest.API{}
+		api.On("GetUser", "theuserid").Return(&model.User{Email: "alice@corp.com", Username: "alice"}, nil)
+
+		config := &configuration{SiteHost: "site.webex.com", IdentityMappingRules: "domain corp.com = corp.webex.com"}
+		require.NoError(t, config.process())
+		return newTestPlugin(api, config, mockStore{}, client)
+	}
+
+	t.Run("uses the mapped identity", func(t *testing.T) {
+		client := &emailClient{rooms: map[string]string{"alice@corp.webex.com": "https://site.webex.com/meet/alice"}}
+		p := newPlugin(client)
+
+		roomURL, err := p.getRoomURLFromMMId(context.Background(), "site.webex.com", "theuserid")
+		require.NoError(t, err)
+		assert.Equal(t, "https://site.webex.com/meet/alice", roomURL)
+		assert.Equal(t, []string{"alice@corp.webex.com"}, client.emails)
+	})
+
+	t.Run("falls back to the Mattermost identity", func(t *testing.T) {
+		client := &emailClient{rooms: map[string]string{"alice@corp.com": "https://site.webex.com/meet/alice"}}
+		p := newPlugin(client)
+
+		roomURL, err := p.getRoomURLFromMMId(context.Background(), "site.webex.com", "theuserid")
+		require.NoError(t, err)
+		assert.Equal(t, "https://site.webex.com/meet/alice", roomURL)
+		assert.Equal(t, []string{"alice@corp.webex.com", "alice@corp.com"}, client.emails)
+	})
+
+	t.Run("explains both lookups", func(t *testing.T) {
+		p := newPlugin(&emailClient{})
+
+		_, err := p.getRoomURLFromMMId(context.Background(), "site.webex.com", "theuserid")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "The email `alice@corp.webex.com`: user not found")
+		assert.Contains(t, err.Error(), "The email `alice@corp.com`: user not found")
+	})
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+)
+
+const (
+	listRangeToday = "today"
+	listRangeWeek  = "week"
+
+	listTimeFormat = "Mon Jan 2, 3:04 PM MST"
+)
+
+// listMeetingsRange returns the time range covered by `/webex list rangeName`, relative to now and in now's location.
+func listMeetingsRange(rangeName string, now time.Time) (time.Time, time.Time, error) {
+	startOfDay := atTimeOfDay(now, 0, 0)
+	switch rangeName {
+	case "", listRangeToday:
+		return startOfDay, startOfDay.AddDate(0, 0, 1), nil
+	case listRangeWeek:
+		return startOfDay, startOfDay.AddDate(0, 0, 7), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("unknown range `%s`, use `%s` or `%s`", rangeName, listRangeToday, listRangeWeek)
+}
+
+// listUpcomingMeetings returns the meetings of mattermostUserID in rangeName that are upcoming or in progress,
+// along with the user's location.
+func (p *Plugin) listUpcomingMeetings(ctx context.Context, mattermostUserID, rangeName string) ([]webex.Meeting, *time.Location, error) {
+	location := p.getUserLocation(mattermostUserID)
+	now := time.Now().In(location)
+	from, to, err := listMeetingsRange(rangeName, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token, err := p.getUserToken(mattermostUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meetings, err := p.webexRESTClient.ListMeetings(ctx, token.AccessToken, from, to)
+	if err != nil {
+		p.errorf("error listing the Webex meetings of mattermostUserID: %s, error: %v", mattermostUserID, err)
+		return nil, nil, fmt.Errorf("failed to list your meetings in Webex: %v", err)
+	}
+
+	var upcoming []webex.Meeting
+	for _, meeting := range meetings {
+		switch meeting.State {
+		case webex.MeetingStateEnded, webex.MeetingStateMissed, webex.MeetingStateExpired:
+			continue
+		}
+		if meeting.State != webex.MeetingStateInProgress && meeting.End.Before(now) {
+			continue
+		}
+		upcoming = append(upcoming, meeting)
+	}
+	return upcoming, location, nil
+}
+
+// formatMeetingsTable renders meetings as a markdown table, with the command sharing each of them.
+func (p *Plugin) formatMeetingsTable(meetings []webex.Meeting, location *time.Location) string {
+	var b strings.Builder
+	b.WriteString("| Title | Time | Host | Join | Share in this channel |\n")
+	b.WriteString("| :---- | :--- | :--- | :--- | :-------------------- |\n")
+	for _, meeting := range meetings {
+		when := fmt.Sprintf("%s - %s", meeting.Start.In(location).Format(listTimeFormat), meeting.End.In(location).Format("3:04 PM"))
+		if meeting.State == webex.MeetingStateInProgress {
+			when = "In progress, until " + meeting.End.In(location).Format("3:04 PM MST")
+		}
+		host := meeting.HostDisplayName
+		if host == "" {
+			host = meeting.HostEmail
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | [Join](%s) | `/webex share %s` |\n",
+			escapeTableCell(meeting.Title), when, escapeTableCell(host), p.makeJoinURL(meeting.WebLink), meeting.ID)
+	}
+	return b.String()
+}
+
+func escapeTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
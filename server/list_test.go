@@ -0,0 +1,114 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListMeetingsRange(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	now := time.Date(2026, 10, 17, 15, 30, 0, 0, location)
+	midnight := time.Date(2026, 10, 17, 0, 0, 0, 0, location)
+
+	for name, tc := range map[string]struct {
+		rangeName string
+		to        time.Time
+	}{
+		"default": {"", midnight.AddDate(0, 0, 1)},
+		"today":   {listRangeToday, midnight.AddDate(0, 0, 1)},
+		"week":    {listRangeWeek, midnight.AddDate(0, 0, 7)},
+	} {
+		from, to, err := listMeetingsRange(tc.rangeName, now)
+		require.NoError(t, err, name)
+		assert.Equal(t, midnight, from, name)
+		assert.Equal(t, tc.to, to, name)
+	}
+
+	_, _, err = listMeetingsRange("month", now)
+	assert.EqualError(t, err, "unknown range `month`, use `today` or `week`")
+}
+
+func TestFormatMeetingsTable(t *testing.T) {
+	p := &Plugin{}
+	p.setConfiguration(&configuration{})
+
+	start := time.Date(2026, 10, 17, 14, 0, 0, 0, time.UTC)
+	table := p.formatMeetingsTable([]webex.Meeting{
+		{
+			ID:              "m1",
+			Title:           "Design | review",
+			State:           webex.MeetingStateScheduled,
+			Start:           start,
+			End:             start.Add(time.Hour),
+			WebLink:         "https://site.webex.com/m1",
+			HostDisplayName: "Alice",
+		},
+		{
+			ID:        "m2",
+			Title:     "Standup",
+			State:     webex.MeetingStateInProgress,
+			Start:     start.Add(-time.Hour),
+			End:       start.Add(-30 * time.Minute),
+			WebLink:   "https://site.webex.com/m2",
+			HostEmail: "bob@example.com",
+		},
+	}, time.UTC)
+
+	assert.Equal(t, "| Title | Time | Host | Join | Share in this channel |\n"+
+		"| :---- | :--- | :--- | :--- | :-------------------- |\n"+
+		"| Design \\| review | Sat Oct 17, 2:00 PM UTC - 3:00 PM | Alice | [Join](https://site.webex.com/m1) | `/webex share m1` |\n"+
+		"| Standup | In progress, until 1:30 PM UTC | bob@example.com | [Join](https://site.webex.com/m2) | `/webex share m2` |\n", table)
+}
+
+// newConnectedPlugin returns a plugin whose users are connected to Webex with the token "thetoken", and whose Webex
+// REST API is served by handler.
+func newConnectedPlugin(t *testing.T, handler http.HandlerFunc) (*Plugin, *plugintest.API) {
+	config := newOAuthConfiguration("site.webex.com")
+	encryptedToken := newEncryptedToken(t, config, &webex.Token{AccessToken: "thetoken", Expiry: time.Now().Add(time.Hour)})
+
+	api := &plugintest.API{}
+	p := newTestPlugin(api, config, mockStore{userInfo: UserInfo{Email: "theuser@example.com", EncryptedToken: encryptedToken}}, nil)
+	p.botUserID = "thebotid"
+	serveWebexAPI(t, p, handler)
+	return p, api
+}
+
+func TestListUpcomingMeetings(t *testing.T) {
+	now := time.Now().UTC()
+	meetings := []webex.Meeting{
+		{ID: "ended", State: webex.MeetingStateEnded, Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)},
+		{ID: "missed", State: webex.MeetingStateReady, Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)},
+		{ID: "running", State: webex.MeetingStateInProgress, Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)},
+		{ID: "upcoming", State: webex.MeetingStateReady, Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)},
+	}
+	p, api := newConnectedPlugin(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer thetoken", r.Header.Get("Authorization"))
+		assert.Equal(t, "/meetings", r.URL.Path)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"items": meetings})
+	})
+	api.On("GetUser", "theuserid").Return(&model.User{Id: "theuserid"}, nil)
+
+	upcoming, location, err := p.listUpcomingMeetings(context.Background(), "theuserid", listRangeToday)
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, location)
+	require.Len(t, upcoming, 2)
+	assert.Equal(t, "running", upcoming[0].ID)
+	assert.Equal(t, "upcoming", upcoming[1].ID)
+
+	_, _, err = p.listUpcomingMeetings(context.Background(), "theuserid", "month")
+	assert.Error(t, err)
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-plugin-webex/server/webex"
 
@@ -16,8 +19,131 @@ type meetingDetails struct {
 	channelID           string
 	meetingStatus       string
 	roomURL             string
+
+	// The following are only set for meetings created through the Webex REST API.
+	topic          string
+	password       string
+	joinURL        string
+	startURL       string
+	webexMeetingID string
+	meetingNumber  string
+
+	// start, end and location are only set for meetings created through the Webex REST API.
+	start    time.Time
+	end      time.Time
+	location *time.Location
+
+	// inviteeUserIDs are the Mattermost users invited to the meeting.
+	inviteeUserIDs []string
+
+	// preventDuplicate refuses to start the meeting while another one is running in the channel.
+	preventDuplicate bool
+
+	// webexHostEmail is the host of a meeting shared from Webex, who may not be the user sharing it. Other meetings
+	// are hosted by startedByUserID.
+	webexHostEmail string
 }
 
+// Meeting is the record of a meeting shared by the plugin, keyed by its custom_webex post.
+type Meeting struct {
+	PostID         string   `json:"post_id"`
+	ChannelID      string   `json:"channel_id"`
+	HostUserID     string   `json:"host_user_id"`
+	RoomURL        string   `json:"room_url"`
+	JoinURL        string   `json:"join_url"`
+	Status         string   `json:"status"`
+	Title          string   `json:"title"`
+	WebexMeetingID string   `json:"webex_meeting_id,omitempty"`
+	InviteeUserIDs []string `json:"invitee_user_ids,omitempty"`
+
+	// WebexInstanceID is the Webex meeting instance of a Personal Room meeting, once reported by a webhook event.
+	WebexInstanceID string `json:"webex_instance_id,omitempty"`
+
+	// StartPostID is the ephemeral post holding the host's start link.
+	StartPostID string `json:"start_post_id,omitempty"`
+
+	// Start and End are the scheduled times of meetings created through the Webex REST API.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	CreatedAt time.Time `json:"created_at"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+
+	// Attendees are everyone who joined the meeting, as reported by Webex.
+	// ParticipantCount is the number of attendees currently in the meeting, those that are Present.
+	Attendees        []Attendee `json:"attendees,omitempty"`
+	ParticipantCount int        `json:"participant_count"`
+
+	// ActionItems are collected in the meeting's thread with /webex actions, and listed in its summary.
+	ActionItems []ActionItem `json:"action_items,omitempty"`
+
+	// Recordings are the recordings of the meeting shared in its thread, oldest first.
+	Recordings []MeetingRecording `json:"recordings,omitempty"`
+
+	// TranscriptIDs are the Webex transcripts of the meeting that were handled: attached to its thread, or skipped as
+	// empty.
+	TranscriptIDs []string `json:"transcript_ids,omitempty"`
+}
+
+type Attendee struct {
+	ID          string `json:"id"`
+	Email       string `json:"email"`
+	DisplayName string `json:"display_name"`
+
+	// Present reports whether the attendee is currently in the meeting.
+	Present bool `json:"present,omitempty"`
+}
+
+type ActionItem struct {
+	Text      string    `json:"text"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// findAttendee returns the attendee of the meeting whose Webex participant ID is id, or nil.
+func (m *Meeting) findAttendee(id string) *Attendee {
+	for i := range m.Attendees {
+		if m.Attendees[i].ID == id {
+			return &m.Attendees[i]
+		}
+	}
+	return nil
+}
+
+// clearParticipants marks every attendee as having left the meeting.
+func (m *Meeting) clearParticipants() {
+	for i := range m.Attendees {
+		m.Attendees[i].Present = false
+	}
+	m.ParticipantCount = 0
+}
+
+// isUnmatchedPersonalRoomMeeting reports whether the meeting is a Personal Room meeting that Webex hasn't reported
+// yet, and may still report.
+func (m *Meeting) isUnmatchedPersonalRoomMeeting() bool {
+	return m.WebexMeetingID == "" && m.WebexInstanceID == "" && m.RoomURL != "" && m.Status != webex.StatusEnded
+}
+
+// IsActive reports whether the meeting is scheduled or in progress at now. Webex may never report the end of a
+// meeting, such as a meeting in a Personal Room, or one nobody joined. Without participants, a Personal Room meeting
+// is no longer active runningMeetingWindow after it was shared, and a scheduled meeting runningMeetingWindow after
+// its scheduled end.
+func (m *Meeting) IsActive(now time.Time) bool {
+	if m.Status == webex.StatusEnded || !m.EndedAt.IsZero() {
+		return false
+	}
+	if m.ParticipantCount > 0 {
+		return true
+	}
+	if m.End.IsZero() {
+		return now.Sub(m.CreatedAt) < runningMeetingWindow
+	}
+	return now.Sub(m.End) < runningMeetingWindow
+}
+
+const defaultMeetingTopic = "Webex Meeting"
+
 type meetingPosts struct {
 	createdJoinPost  *model.Post
 	createdStartPost *model.Post
@@ -25,8 +151,8 @@ type meetingPosts struct {
 
 // startMeeting starts a meeting using details.meetingRoomOfUserId's room
 // returns the joinPost, startPost, http status code and a descriptive error
-func (p *Plugin) startMeeting(details meetingDetails) (*meetingPosts, int, error) {
-	roomURL, err := p.getRoomURLFromMMId(details.meetingRoomOfUserID)
+func (p *Plugin) startMeeting(ctx context.Context, details meetingDetails) (*meetingPosts, int, error) {
+	roomURL, err := p.getRoomURLFromMMId(ctx, p.siteHostForChannel(details.channelID), details.meetingRoomOfUserID)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
@@ -35,23 +161,182 @@ func (p *Plugin) startMeeting(details meetingDetails) (*meetingPosts, int, error
 	return p.startMeetingFromRoomURL(details)
 }
 
+// startNewMeeting creates a unique meeting hosted by details.startedByUserID through the Webex REST API,
+// and shares it the same way as a Personal Meeting Room.
+func (p *Plugin) startNewMeeting(ctx context.Context, details meetingDetails, request webex.CreateMeetingRequest) (*meetingPosts, int, error) {
+	// Lock and check before creating the meeting in Webex, where the loser of concurrent starts would leave it behind.
+	// The lock is held while Webex creates the meeting, which gives up when ctx is done.
+	if details.preventDuplicate {
+		unlock, err := p.lockChannelMeetings(ctx, details.channelID)
+		if err != nil {
+			return nil, http.StatusConflict, err
+		}
+		defer unlock()
+
+		if err = p.checkNoRunningMeeting(details); err != nil {
+			return nil, http.StatusConflict, err
+		}
+	}
+
+	meeting, links, err := p.createWebexMeeting(ctx, details.startedByUserID, request)
+	if err == ErrNotConnected {
+		return nil, http.StatusUnauthorized, err
+	}
+	if err != nil {
+		p.errorf("error creating a Webex meeting for mattermostUserID: %s, error: %v", details.startedByUserID, err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to create the meeting in Webex: %v", err)
+	}
+
+	details.roomURL = meeting.WebLink
+	details.joinURL = links.JoinLink
+	details.startURL = links.StartLink
+	details.topic = meeting.Title
+	details.password = meeting.Password
+	details.webexMeetingID = meeting.ID
+	details.meetingNumber = meeting.MeetingNumber
+	details.start = meeting.Start
+	details.end = meeting.End
+	return p.postMeeting(details)
+}
+
+// shareWebexMeeting shares the existing Webex meeting webexMeetingID, visible to mattermostUserID, in channelID.
+func (p *Plugin) shareWebexMeeting(ctx context.Context, mattermostUserID, channelID, webexMeetingID string) (*meetingPosts, int, error) {
+	token, err := p.getUserToken(mattermostUserID)
+	if err == ErrNotConnected {
+		return nil, http.StatusUnauthorized, err
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	meeting, err := p.webexRESTClient.GetMeeting(ctx, token.AccessToken, webexMeetingID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("could not find the meeting `%s` in Webex: %v", webexMeetingID, err)
+	}
+
+	email, _, err := p.getEmailAndUserName(mattermostUserID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	isHost := strings.EqualFold(meeting.HostEmail, email)
+
+	details := meetingDetails{
+		startedByUserID: mattermostUserID,
+		webexHostEmail:  meeting.HostEmail,
+		channelID:       channelID,
+		meetingStatus:   webex.StatusInvited,
+		roomURL:         meeting.WebLink,
+		topic:           meeting.Title,
+		password:        meeting.Password,
+		webexMeetingID:  meeting.ID,
+		meetingNumber:   meeting.MeetingNumber,
+		start:           meeting.Start,
+		end:             meeting.End,
+		location:        p.getUserLocation(mattermostUserID),
+	}
+	switch {
+	case meeting.State != webex.MeetingStateInProgress && meeting.Start.After(time.Now()):
+		details.meetingStatus = webex.StatusScheduled
+	case isHost:
+		details.meetingStatus = webex.StatusStarted
+	}
+
+	if isHost {
+		links, linksErr := p.webexRESTClient.GetJoinLinks(ctx, token.AccessToken, meeting.ID)
+		if linksErr == nil {
+			details.joinURL = links.JoinLink
+			details.startURL = links.StartLink
+		}
+	}
+
+	return p.startMeetingFromRoomURL(details)
+}
+
+// createWebexMeeting creates a meeting on behalf of mattermostUserID and fetches its join and start links.
+func (p *Plugin) createWebexMeeting(ctx context.Context, mattermostUserID string, request webex.CreateMeetingRequest) (*webex.Meeting, *webex.JoinLinks, error) {
+	token, err := p.getUserToken(mattermostUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meeting, err := p.webexRESTClient.CreateMeeting(ctx, token.AccessToken, request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	links, err := p.webexRESTClient.GetJoinLinks(ctx, token.AccessToken, meeting.ID)
+	if err != nil {
+		// The meeting's web link works for both the host and the invitees, only less directly.
+		p.API.LogWarn("unable to get the join links for a Webex meeting", "meeting_id", meeting.ID, "error", err.Error())
+		links = &webex.JoinLinks{JoinLink: meeting.WebLink, StartLink: meeting.WebLink}
+	}
+
+	return meeting, links, nil
+}
+
 // startMeetingFromroomURL starts a meeting using details.roomURL, ignoring details.meetingRoomOfUserId
 func (p *Plugin) startMeetingFromRoomURL(details meetingDetails) (*meetingPosts, int, error) {
-	webexJoinURL := p.makeJoinURL(details.roomURL)
-	webexStartURL := p.makeStartURL(details.roomURL)
+	if details.preventDuplicate {
+		// Hold the lock until the meeting is stored, so concurrent starts on other nodes see it.
+		unlock, err := p.lockChannelMeetings(context.Background(), details.channelID)
+		if err != nil {
+			return nil, http.StatusConflict, err
+		}
+		defer unlock()
+
+		if err = p.checkNoRunningMeeting(details); err != nil {
+			return nil, http.StatusConflict, err
+		}
+	}
+
+	return p.postMeeting(details)
+}
+
+// postMeeting posts and stores the meeting of details. The caller holds the lock of the channel's meetings when
+// details.preventDuplicate is set.
+func (p *Plugin) postMeeting(details meetingDetails) (*meetingPosts, int, error) {
+	webexJoinURL := details.joinURL
+	if webexJoinURL == "" {
+		webexJoinURL = p.makeJoinURL(details.roomURL)
+	}
+	webexStartURL := details.startURL
+	if webexStartURL == "" {
+		webexStartURL = p.makeStartURL(details.roomURL)
+	}
+
+	topic := topicOrDefault(details.topic)
+
+	message := fmt.Sprintf("Meeting started at %s.", webexJoinURL)
+	if details.meetingStatus == webex.StatusScheduled {
+		location := details.location
+		if location == nil {
+			location = time.UTC
+		}
+		message = fmt.Sprintf("Meeting scheduled for %s at %s.", details.start.In(location).Format(scheduleTimeFormat), webexJoinURL)
+	}
+	if details.password != "" {
+		message += fmt.Sprintf(" Meeting password: `%s`", details.password)
+	}
 
 	joinPost := &model.Post{
 		UserId:    details.startedByUserID,
 		ChannelId: details.channelID,
-		Message:   fmt.Sprintf("Meeting started at %s.", webexJoinURL),
+		Message:   message,
 		Type:      "custom_webex",
 		Props: map[string]interface{}{
 			"meeting_link":     webexJoinURL,
 			"meeting_status":   details.meetingStatus,
-			"meeting_topic":    "Webex Meeting",
+			"meeting_topic":    topic,
 			"starting_user_id": details.startedByUserID,
 		},
 	}
+	if details.webexMeetingID != "" {
+		joinPost.AddProp("meeting_id", details.meetingNumber)
+		joinPost.AddProp("webex_meeting_id", details.webexMeetingID)
+	}
+	if !details.start.IsZero() {
+		joinPost.AddProp("meeting_start", details.start.UnixMilli())
+	}
 
 	createdJoinPost, appErr := p.API.CreatePost(joinPost)
 	if appErr != nil {
@@ -69,27 +354,217 @@ func (p *Plugin) startMeetingFromRoomURL(details meetingDetails) (*meetingPosts,
 		createdStartPost = p.API.SendEphemeralPost(details.startedByUserID, startPost)
 	}
 
+	now := time.Now()
+	meeting := &Meeting{
+		PostID:         createdJoinPost.Id,
+		ChannelID:      details.channelID,
+		HostUserID:     p.getMeetingHostUserID(details),
+		RoomURL:        details.roomURL,
+		JoinURL:        webexJoinURL,
+		Status:         details.meetingStatus,
+		Title:          topic,
+		WebexMeetingID: details.webexMeetingID,
+		InviteeUserIDs: details.inviteeUserIDs,
+		Start:          details.start,
+		End:            details.end,
+		CreatedAt:      now,
+	}
+	if details.meetingStatus == webex.StatusStarted {
+		meeting.StartedAt = now
+	}
+	if createdStartPost != nil {
+		meeting.StartPostID = createdStartPost.Id
+	}
+	if err := p.store.StoreMeeting(meeting); err != nil {
+		// The meeting has already been shared, so only log the error.
+		p.errorf("error storing the meeting for post: %s, error: %v", meeting.PostID, err)
+	} else if err = p.scheduleReminders(meeting); err != nil {
+		p.errorf("error scheduling the reminders for post: %s, error: %v", meeting.PostID, err)
+	}
+
 	return &meetingPosts{createdJoinPost, createdStartPost}, http.StatusOK, nil
 }
 
-func (p *Plugin) makeJoinURL(meetingURL string) string {
-	if p.getConfiguration().URLConversion {
-		meetingURL = strings.Replace(meetingURL, "webex.com/meet/", "webex.com/join/", 1)
+// getMeetingHostUserID returns the Mattermost user hosting the meeting of details, or "" when the host of a meeting
+// shared from Webex has no Mattermost account.
+func (p *Plugin) getMeetingHostUserID(details meetingDetails) string {
+	if details.webexHostEmail == "" {
+		return details.startedByUserID
 	}
+	user, appErr := p.API.GetUserByEmail(details.webexHostEmail)
+	if appErr != nil {
+		return ""
+	}
+	return user.Id
+}
 
-	return meetingURL
+// Duration returns how long the meeting lasted, or has lasted so far.
+func (m *Meeting) Duration() time.Duration {
+	start := m.StartedAt
+	if start.IsZero() {
+		start = m.CreatedAt
+	}
+	end := m.EndedAt
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
 }
 
-func (p *Plugin) makeStartURL(meetingURL string) string {
-	if p.getConfiguration().URLConversion {
-		meetingURL = strings.Replace(meetingURL, "webex.com/meet/", "webex.com/start/", 1)
+// updateMeetingPost reflects the status, duration and participants of meeting in its custom_webex post.
+func (p *Plugin) updateMeetingPost(meeting *Meeting) error {
+	post, appErr := p.API.GetPost(meeting.PostID)
+	if appErr != nil {
+		return appErr
+	}
+
+	post.AddProp("meeting_status", meeting.Status)
+	post.AddProp("meeting_participant_count", meeting.ParticipantCount)
+	post.AddProp("meeting_attendee_count", len(meeting.Attendees))
+	if meeting.Status == webex.StatusEnded {
+		post.AddProp("meeting_duration", int64(meeting.Duration().Seconds()))
+	}
+	if n := len(meeting.Recordings); n > 0 {
+		recording := meeting.Recordings[n-1]
+		post.AddProp("recording_url", recording.URL)
+		post.AddProp("recording_duration", recording.DurationSeconds)
+	}
+	// Posts updated by earlier versions of the plugin showed the password of the recording to anyone in the channel.
+	post.DelProp("recording_password")
+
+	if _, appErr = p.API.UpdatePost(post); appErr != nil {
+		return appErr
+	}
+
+	if meeting.Status == webex.StatusEnded && meeting.StartPostID != "" {
+		p.API.DeleteEphemeralPost(meeting.HostUserID, meeting.StartPostID)
+	}
+	return nil
+}
+
+var (
+	ErrNotMeetingHost      = errors.New("only the host can end the meeting")
+	ErrMeetingAlreadyEnded = errors.New("the meeting has already ended")
+)
+
+// endMeeting ends meeting on behalf of mattermostUserID, who must be its host.
+// Meetings created through the Webex REST API are ended in Webex as well.
+func (p *Plugin) endMeeting(ctx context.Context, mattermostUserID string, meeting *Meeting) error {
+	if meeting.HostUserID != mattermostUserID {
+		return ErrNotMeetingHost
+	}
+	if !meeting.IsActive(time.Now()) {
+		return ErrMeetingAlreadyEnded
+	}
+
+	if meeting.WebexMeetingID != "" {
+		token, err := p.getUserToken(mattermostUserID)
+		if err != nil {
+			return err
+		}
+		if err = p.webexRESTClient.EndMeeting(ctx, token.AccessToken, meeting.WebexMeetingID); err != nil {
+			p.errorf("error ending the Webex meeting: %s, error: %v", meeting.WebexMeetingID, err)
+			return fmt.Errorf("failed to end the meeting in Webex: %v", err)
+		}
+	}
+
+	err := p.updateMeeting(meeting.PostID, func(meeting *Meeting) bool {
+		if meeting.Status == webex.StatusEnded {
+			return false
+		}
+		meeting.Status = webex.StatusEnded
+		meeting.EndedAt = time.Now()
+		meeting.clearParticipants()
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = p.store.CancelReminders(meeting.PostID); err != nil {
+		p.errorf("error cancelling the reminders for post: %s, error: %v", meeting.PostID, err)
+	}
+	return nil
+}
+
+// getCurrentMeeting returns the latest active meeting hosted by mattermostUserID, preferring channelID.
+func (p *Plugin) getCurrentMeeting(mattermostUserID, channelID string) (*Meeting, error) {
+	meetings, err := p.store.LoadMeetingsByUser(mattermostUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var current *Meeting
+	for i := len(meetings) - 1; i >= 0; i-- {
+		if !meetings[i].IsActive(now) {
+			continue
+		}
+		if meetings[i].ChannelID == channelID {
+			return meetings[i], nil
+		}
+		if current == nil {
+			current = meetings[i]
+		}
+	}
+
+	if current == nil {
+		return nil, ErrMeetingNotFound
+	}
+	return current, nil
+}
+
+// getActiveMeetings returns the meetings shared in channelID that are scheduled or in progress.
+func (p *Plugin) getActiveMeetings(channelID string) ([]*Meeting, error) {
+	meetings, err := p.store.LoadMeetingsByChannel(channelID)
+	if err != nil {
+		return nil, err
 	}
 
-	return meetingURL
+	now := time.Now()
+	active := []*Meeting{}
+	for _, meeting := range meetings {
+		if meeting.IsActive(now) {
+			active = append(active, meeting)
+		}
+	}
+	return active, nil
 }
 
-func (p *Plugin) getURLFromRoomID(roomID string) (string, error) {
-	roomURL, cerr := p.webexClient.GetPersonalMeetingRoomURL(roomID, "", "")
+// formatDuration formats d rounded up to the minute, e.g. "1h 5m" or "12m".
+func formatDuration(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", minutes/60, minutes%60)
+}
+
+func topicOrDefault(topic string) string {
+	if topic = strings.TrimSpace(topic); topic == "" {
+		return defaultMeetingTopic
+	}
+	return topic
+}
+
+func (p *Plugin) makeJoinURL(meetingURL string) string {
+	return p.getConfiguration().rewriteMeetingURL(meetingURL, "join")
+}
+
+func (p *Plugin) makeStartURL(meetingURL string) string {
+	return p.getConfiguration().rewriteMeetingURL(meetingURL, "start")
+}
+
+func (p *Plugin) getURLFromRoomID(ctx context.Context, siteHost, roomID string) (string, error) {
+	client, err := p.getWebexClient(siteHost)
+	if err != nil {
+		return "", err
+	}
+
+	roomURL, cerr := client.GetPersonalMeetingRoomURL(ctx, roomID, "", "")
 	if cerr != nil {
 		return "", cerr
 	}
@@ -97,8 +572,23 @@ func (p *Plugin) getURLFromRoomID(roomID string) (string, error) {
 	return roomURL, nil
 }
 
-func (p *Plugin) getURLFromNameOrEmail(userName, email string) (string, error) {
-	roomURL, err := p.webexClient.GetPersonalMeetingRoomURL("", userName, email)
+// getPersonalMeetingRoom returns the Personal Room of roomID on siteHost.
+func (p *Plugin) getPersonalMeetingRoom(ctx context.Context, siteHost, roomID string) (*webex.PMR, error) {
+	client, err := p.getWebexClient(siteHost)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetPersonalMeetingRoom(ctx, roomID)
+}
+
+func (p *Plugin) getURLFromIdentity(ctx context.Context, siteHost string, identity webexIdentity) (string, error) {
+	client, err := p.getWebexClient(siteHost)
+	if err != nil {
+		return "", err
+	}
+
+	roomURL, err := client.GetPersonalMeetingRoomURL(ctx, identity.RoomID, identity.Username, identity.Email)
 	if err != nil {
 		return "", err
 	}
@@ -106,26 +596,77 @@ func (p *Plugin) getURLFromNameOrEmail(userName, email string) (string, error) {
 	return roomURL, nil
 }
 
-// getroomURLFromMMId will find the correct url for mattermostUserId, or return a message explaining why it couldn't.
-func (p *Plugin) getRoomURLFromMMId(mattermostUserID string) (string, error) {
-	var roomURL string
+// getroomURLFromMMId will find the correct url for mattermostUserId on siteHost, or return a message explaining why it
+// couldn't.
+func (p *Plugin) getRoomURLFromMMId(ctx context.Context, siteHost, mattermostUserID string) (string, error) {
 	if roomID, err := p.getRoom(mattermostUserID); err == nil && roomID != "" {
 		// Look for their url using roomId
-		roomURL, err = p.getURLFromRoomID(roomID)
-		if err != nil {
-			return "", fmt.Errorf("no Personal Room link found at `%s` for the room: `%s`", p.getConfiguration().SiteHost, roomID)
+		roomURL, err := p.getURLFromRoomID(ctx, siteHost, roomID)
+		if message := describeWebexLookupError(siteHost, err); message != "" {
+			return "", errors.New(message)
 		}
-	} else {
-		// Look for their url using userName or email
-		email, userName, err := p.getEmailAndUserName(mattermostUserID)
 		if err != nil {
-			return "", fmt.Errorf("error getting email and Username: %v", err)
+			return "", fmt.Errorf("no Personal Room link found at `%s` for the room: `%s`.%s", siteHost, roomID, explainLookupError(err))
 		}
-		roomURL, err = p.getURLFromNameOrEmail(userName, email)
-		if err != nil {
-			return "", fmt.Errorf("no Personal Room link found at `%s` for your Username: `%s`, or your email: `%s`. Try setting a room manually with `/webex room <room id>`", p.getConfiguration().SiteHost, userName, email)
+		return roomURL, nil
+	}
+
+	user, err := p.getUser(mattermostUserID)
+	if err != nil {
+		return "", fmt.Errorf("error getting email and Username: %v", err)
+	}
+
+	// Look for their url using the identity mapping rules, then using their userName or email
+	identity := webexIdentity{Username: user.Username, Email: user.Email}
+	var mappedErr error
+	if mapped := p.getConfiguration().webexIdentity(user); mapped != identity {
+		roomURL, err := p.getURLFromIdentity(ctx, siteHost, mapped)
+		if err == nil {
+			return roomURL, nil
+		}
+		if message := describeWebexLookupError(siteHost, err); message != "" {
+			return "", errors.New(message)
+		}
+		mappedErr = err
+	}
+
+	roomURL, err := p.getURLFromIdentity(ctx, siteHost, identity)
+	if message := describeWebexLookupError(siteHost, err); message != "" {
+		return "", errors.New(message)
+	}
+	if err != nil {
+		explanation := explainLookupError(err)
+		if mappedErr != nil {
+			explanation = explainLookupError(mappedErr) + explanation
 		}
+		return "", fmt.Errorf("no Personal Room link found at `%s` for your Username: `%s`, or your email: `%s`. Try setting a room manually with `/webex room <room id>`.%s", siteHost, user.Username, user.Email, explanation)
 	}
 
 	return roomURL, nil
 }
+
+// explainLookupError lists why each identifier failed to resolve to a Personal Room, as markdown to append to a
+// message.
+func explainLookupError(err error) string {
+	var lookupErr *webex.LookupError
+	if !errors.As(err, &lookupErr) {
+		return "\n* " + err.Error()
+	}
+
+	var b strings.Builder
+	for _, failure := range lookupErr.Failures {
+		fmt.Fprintf(&b, "\n* The %s `%s`: %s", failure.Identifier, failure.Value, failure.Err.Error())
+	}
+	return b.String()
+}
+
+// describeWebexLookupError explains a Personal Room lookup failure that is not about the user, or returns "".
+func describeWebexLookupError(siteHost string, err error) string {
+	switch {
+	case errors.Is(err, webex.ErrSiteNotFound):
+		return fmt.Sprintf("Webex doesn't know the site `%s`. Please ask your system administrator to check the Webex Site Hostname in the plugin settings.", siteHost)
+	case errors.Is(err, webex.ErrAuthRequired):
+		return fmt.Sprintf("The site `%s` doesn't allow looking up Personal Rooms without signing in. Please ask your system administrator to check the Webex site settings.", siteHost)
+	}
+	return ""
+}
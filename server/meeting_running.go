@@ -0,0 +1,188 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+// runningMeetingWindow is how long after it was shared a meeting is considered running when Webex reports no
+// participants in it, as Webex doesn't report when the meetings of Personal Rooms end.
+const runningMeetingWindow = time.Hour
+
+// channelMeetingsLockTimeout bounds the wait for another start of a meeting in the same channel, which holds the lock
+// of the channel's meetings while Webex creates its meeting.
+const channelMeetingsLockTimeout = 10 * time.Second
+
+// meetingRunningError is returned when starting a meeting in a channel where another one is running.
+type meetingRunningError struct {
+	Meeting *Meeting
+}
+
+func (e *meetingRunningError) Error() string {
+	return "a meeting is already running in this channel"
+}
+
+// isRunning reports whether the meeting is in progress at now, as far as the plugin can tell.
+func (m *Meeting) isRunning(now time.Time) bool {
+	if m.Status != webex.StatusStarted || !m.EndedAt.IsZero() {
+		return false
+	}
+	return m.ParticipantCount > 0 || now.Sub(m.StartedAt) < runningMeetingWindow
+}
+
+// getRunningMeeting returns the latest meeting running in channelID, or nil.
+func (p *Plugin) getRunningMeeting(channelID string, now time.Time) (*Meeting, error) {
+	meetings, err := p.store.LoadMeetingsByChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(meetings) - 1; i >= 0; i-- {
+		if meetings[i].isRunning(now) {
+			return meetings[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// checkNoRunningMeeting returns a *meetingRunningError when details.preventDuplicate is set and a meeting is already
+// running in details.channelID. Failing to load the meetings of the channel doesn't prevent starting one.
+func (p *Plugin) checkNoRunningMeeting(details meetingDetails) error {
+	if !details.preventDuplicate {
+		return nil
+	}
+
+	meeting, err := p.getRunningMeeting(details.channelID, time.Now())
+	if err != nil {
+		p.errorf("error loading the running meetings of channel: %s, error: %v", details.channelID, err)
+		return nil
+	}
+	if meeting != nil {
+		return &meetingRunningError{Meeting: meeting}
+	}
+	return nil
+}
+
+// lockChannelMeetings prevents other nodes of the cluster from starting a meeting in channelID until unlock is called.
+// It gives up after channelMeetingsLockTimeout, or when ctx is done, while another start holds the lock.
+func (p *Plugin) lockChannelMeetings(ctx context.Context, channelID string) (unlock func(), err error) {
+	mutex, err := cluster.NewMutex(p.API, "channel_meetings_"+channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, channelMeetingsLockTimeout)
+	defer cancel()
+	if err = mutex.LockWithContext(ctx); err != nil {
+		return nil, errors.New("another meeting is being started in this channel, please try again")
+	}
+	return mutex.Unlock, nil
+}
+
+// notifyMeetingRunning tells mattermostUserID about the meeting already running in the channel of details, with a
+// button starting theirs anyway. Without a request, the meeting would have been in a Personal Room.
+func (p *Plugin) notifyMeetingRunning(mattermostUserID string, details meetingDetails, runningErr *meetingRunningError, request *webex.CreateMeetingRequest) {
+	meeting := runningErr.Meeting
+
+	host := "someone"
+	if user, err := p.getUser(meeting.HostUserID); err == nil {
+		host = "@" + user.Username
+	}
+	command := "/webex start --force"
+	if request != nil {
+		command = "/webex start new --force " + request.Title
+	}
+
+	post := &model.Post{
+		UserId:    p.botUserID,
+		ChannelId: details.channelID,
+		Message: fmt.Sprintf("A meeting is already running in this channel: [%s](%s), started by %s %s ago. Join it, or start a new meeting anyway with `%s`.",
+			meeting.Title, p.getPermalink(meeting.PostID), host, formatDuration(meeting.Duration()), strings.TrimSpace(command)),
+	}
+
+	actionContext := map[string]interface{}{"personal": request == nil}
+	if request != nil {
+		actionContext["topic"] = request.Title
+	}
+	model.ParseSlackAttachment(post, []*model.SlackAttachment{{
+		Actions: []*model.PostAction{{
+			Name: "Start a new meeting anyway",
+			Type: model.PostActionTypeButton,
+			Integration: &model.PostActionIntegration{
+				URL:     p.GetPluginURLPath() + routeAPIstartMeetingAnyway,
+				Context: actionContext,
+			},
+		}},
+	}})
+
+	_ = p.API.SendEphemeralPost(mattermostUserID, post)
+}
+
+func (p *Plugin) getPermalink(postID string) string {
+	return strings.TrimRight(p.GetSiteURL(), "/") + "/_redirect/pl/" + postID
+}
+
+// handleStartMeetingAnyway starts the meeting refused by notifyMeetingRunning, once its user clicked the button.
+func (p *Plugin) handleStartMeetingAnyway(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed,
+			errors.New("method " + r.Method + " is not allowed, must be POST")
+	}
+
+	userID := r.Header.Get("Mattermost-User-Id")
+	if userID == "" {
+		return http.StatusUnauthorized, errors.New("not authorized")
+	}
+
+	var req model.PostActionIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("err: %v", err)
+	}
+	if req.ChannelId == "" {
+		return http.StatusBadRequest, errors.New("channel id required")
+	}
+	if _, appErr := p.API.GetChannelMember(req.ChannelId, userID); appErr != nil {
+		return http.StatusForbidden, errors.New("forbidden")
+	}
+
+	details := meetingDetails{
+		startedByUserID:     userID,
+		meetingRoomOfUserID: userID,
+		channelID:           req.ChannelId,
+		meetingStatus:       webex.StatusStarted,
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), commandTimeout)
+	defer cancel()
+
+	var err error
+	if personal, _ := req.Context["personal"].(bool); personal {
+		_, _, err = p.startChannelMeeting(ctx, details)
+	} else {
+		topic, _ := req.Context["topic"].(string)
+		_, _, err = p.startNewMeeting(ctx, details, webex.CreateMeetingRequest{Title: topicOrDefault(topic)})
+	}
+
+	response := &model.PostActionIntegrationResponse{}
+	if err != nil {
+		response.EphemeralText = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(response); err != nil {
+		p.API.LogWarn("failed to write response", "error", err.Error())
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeetingIsRunning(t *testing.T) {
+	now := time.Now()
+	for name, tc := range map[string]struct {
+		meeting  Meeting
+		expected bool
+	}{
+		"just started":           {Meeting{Status: webex.StatusStarted, StartedAt: now.Add(-time.Minute)}, true},
+		"started long ago":       {Meeting{Status: webex.StatusStarted, StartedAt: now.Add(-2 * time.Hour)}, false},
+		"long with participants": {Meeting{Status: webex.StatusStarted, StartedAt: now.Add(-2 * time.Hour), ParticipantCount: 2}, true},
+		"ended":                  {Meeting{Status: webex.StatusEnded, StartedAt: now.Add(-time.Minute)}, false},
+		"scheduled":              {Meeting{Status: webex.StatusScheduled}, false},
+	} {
+		assert.Equal(t, tc.expected, tc.meeting.isRunning(now), name)
+	}
+}
+
+func TestMeetingIsActive(t *testing.T) {
+	now := time.Now()
+	for name, tc := range map[string]struct {
+		meeting  Meeting
+		expected bool
+	}{
+		"just shared in a Personal Room":     {Meeting{Status: webex.StatusStarted, CreatedAt: now.Add(-time.Minute)}, true},
+		"shared long ago in a Personal Room": {Meeting{Status: webex.StatusStarted, CreatedAt: now.Add(-2 * time.Hour)}, false},
+		"long with participants":             {Meeting{Status: webex.StatusStarted, CreatedAt: now.Add(-2 * time.Hour), ParticipantCount: 1}, true},
+		"scheduled later":                    {Meeting{Status: webex.StatusScheduled, CreatedAt: now.Add(-48 * time.Hour), End: now.Add(24 * time.Hour)}, true},
+		"scheduled and never ended":          {Meeting{Status: webex.StatusScheduled, CreatedAt: now.Add(-48 * time.Hour), End: now.Add(-2 * time.Hour)}, false},
+		"ended":                              {Meeting{Status: webex.StatusEnded, CreatedAt: now.Add(-time.Minute)}, false},
+	} {
+		assert.Equal(t, tc.expected, tc.meeting.IsActive(now), name)
+	}
+}
+
+// runningStore has a meeting running in every channel.
+type runningStore struct {
+	mockStore
+}
+
+func (store runningStore) LoadMeetingsByChannel(channelID string) ([]*Meeting, error) {
+	return []*Meeting{{
+		PostID:     "runningpostid",
+		ChannelID:  channelID,
+		HostUserID: "hostid",
+		Status:     webex.StatusStarted,
+		Title:      "Standup",
+		StartedAt:  time.Now().Add(-4*time.Minute - 30*time.Second),
+	}}, nil
+}
+
+func newRunningMeetingPlugin() (*Plugin, *plugintest.API) {
+	siteURL := "https://mattermost.example.com"
+	api := &plugintest.API{}
+	api.On("GetConfig").Return(&model.Config{ServiceSettings: model.ServiceSettings{SiteURL: &siteURL}})
+	api.On("GetUser", "hostid").Return(&model.User{Id: "hostid", Username: "host"}, nil)
+	api.On("KVSetWithOptions", "mutex_channel_meetings_thechannelid", mock.Anything, mock.Anything).Return(true, nil)
+
+	store := runningStore{mockStore{userInfo: UserInfo{Email: "theuser@example.com", RoomID: "theuser.room"}}}
+	return newTestPlugin(api, &configuration{SiteHost: "site.webex.com"}, store, webex.MockClient{SiteHost: "site.webex.com"}), api
+}
+
+func TestExecuteStartWithRunningMeeting(t *testing.T) {
+	header := &model.CommandArgs{UserId: "theuserid", ChannelId: "thechannelid"}
+
+	t.Run("links to the running meeting", func(t *testing.T) {
+		p, api := newRunningMeetingPlugin()
+		var notice *model.Post
+		api.On("SendEphemeralPost", "theuserid", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+			notice = args.Get(1).(*model.Post)
+		}).Return(nil)
+
+		executeStart(p, nil, header)
+		require.NotNil(t, notice)
+		assert.Contains(t, notice.Message, "[Standup](https://mattermost.example.com/_redirect/pl/runningpostid), started by @host 5m ago")
+		assert.Contains(t, notice.Message, "`/webex start --force`")
+
+		attachments := notice.Attachments()
+		require.Len(t, attachments, 1)
+		require.Len(t, attachments[0].Actions, 1)
+		assert.Equal(t, "/plugins/"+manifest.Id+routeAPIstartMeetingAnyway, attachments[0].Actions[0].Integration.URL)
+		assert.Equal(t, true, attachments[0].Actions[0].Integration.Context["personal"])
+		api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("starts anyway with --force", func(t *testing.T) {
+		p, api := newRunningMeetingPlugin()
+		api.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{Id: "thepostid"}, nil)
+		api.On("SendEphemeralPost", "theuserid", mock.AnythingOfType("*model.Post")).Return(&model.Post{Id: "thestartpostid"})
+
+		executeStart(p, nil, header, "--force")
+		api.AssertCalled(t, "CreatePost", mock.AnythingOfType("*model.Post"))
+	})
+}
+
+func TestHandleStartMeetingAnyway(t *testing.T) {
+	p, api := newRunningMeetingPlugin()
+	api.On("GetChannelMember", "thechannelid", "theuserid").Return(&model.ChannelMember{}, nil)
+	api.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{Id: "thepostid"}, nil)
+	api.On("SendEphemeralPost", "theuserid", mock.AnythingOfType("*model.Post")).Return(&model.Post{Id: "thestartpostid"})
+
+	body, err := json.Marshal(model.PostActionIntegrationRequest{
+		UserId:    "theuserid",
+		ChannelId: "thechannelid",
+		Context:   map[string]interface{}{"personal": true},
+	})
+	require.NoError(t, err)
+	r := httptest.NewRequest(http.MethodPost, routeAPIstartMeetingAnyway, strings.NewReader(string(body)))
+	r.Header.Set("Mattermost-User-Id", "theuserid")
+	w := httptest.NewRecorder()
+
+	status, err := p.handleStartMeetingAnyway(w, r)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+
+	var response model.PostActionIntegrationResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Empty(t, response.EphemeralText)
+	api.AssertCalled(t, "CreatePost", mock.AnythingOfType("*model.Post"))
+}
+
+func TestStartNewMeetingWithRunningMeeting(t *testing.T) {
+	p, api := newRunningMeetingPlugin()
+	serveWebexAPI(t, p, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected Webex request: %s %s", r.Method, r.URL.Path)
+	})
+
+	details := meetingDetails{startedByUserID: "theuserid", channelID: "thechannelid", preventDuplicate: true}
+	_, status, err := p.startNewMeeting(context.Background(), details, webex.CreateMeetingRequest{Title: "Standup"})
+	assert.Equal(t, http.StatusConflict, status)
+	var runningErr *meetingRunningError
+	assert.ErrorAs(t, err, &runningErr)
+	api.AssertCalled(t, "KVSetWithOptions", "mutex_channel_meetings_thechannelid", mock.Anything, mock.Anything)
+}
+
+func TestStartNewMeetingWhileAnotherIsStarting(t *testing.T) {
+	// Another node holds the lock of the channel's meetings.
+	api := &plugintest.API{}
+	api.On("KVSetWithOptions", "mutex_channel_meetings_thechannelid", mock.Anything, mock.Anything).Return(false, nil)
+
+	p := newTestPlugin(api, nil, nil, nil)
+	serveWebexAPI(t, p, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected Webex request: %s %s", r.Method, r.URL.Path)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	details := meetingDetails{startedByUserID: "theuserid", channelID: "thechannelid", preventDuplicate: true}
+	_, status, err := p.startNewMeeting(ctx, details, webex.CreateMeetingRequest{Title: "Standup"})
+	assert.Equal(t, http.StatusConflict, status)
+	assert.EqualError(t, err, "another meeting is being started in this channel, please try again")
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareWebexMeeting(t *testing.T) {
+	for name, tc := range map[string]struct {
+		hostEmail  string
+		hostUserID string
+		status     string
+	}{
+		"shared by its host":           {"theuser@example.com", "theuserid", webex.StatusStarted},
+		"shared by an invitee":         {"alice@example.com", "aliceid", webex.StatusInvited},
+		"hosted outside of Mattermost": {"carol@example.org", "", webex.StatusInvited},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p, api := newConnectedPlugin(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/meetings/m1":
+					_ = json.NewEncoder(w).Encode(webex.Meeting{
+						ID:        "m1",
+						Title:     "Design review",
+						State:     webex.MeetingStateInProgress,
+						Start:     time.Now().Add(-time.Minute),
+						End:       time.Now().Add(time.Hour),
+						WebLink:   "https://site.webex.com/m1",
+						HostEmail: tc.hostEmail,
+					})
+				case "/meetings/join":
+					_ = json.NewEncoder(w).Encode(webex.JoinLinks{JoinLink: "https://site.webex.com/m1/join", StartLink: "https://site.webex.com/m1/start"})
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+			store := p.store.(mockStore)
+			store.meetings = map[string]*Meeting{}
+			p.store = store
+
+			api.On("GetUser", "theuserid").Return(&model.User{Id: "theuserid", Email: "theuser@example.com"}, nil)
+			api.On("GetUserByEmail", "theuser@example.com").Return(&model.User{Id: "theuserid"}, nil)
+			api.On("GetUserByEmail", "alice@example.com").Return(&model.User{Id: "aliceid"}, nil)
+			api.On("GetUserByEmail", "carol@example.org").Return(nil, &model.AppError{Message: "not found", StatusCode: http.StatusNotFound})
+			api.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{Id: "thepostid"}, nil)
+			api.On("SendEphemeralPost", "theuserid", mock.AnythingOfType("*model.Post")).Return(&model.Post{Id: "thestartpostid"})
+
+			_, _, err := p.shareWebexMeeting(context.Background(), "theuserid", "thechannelid", "m1")
+			require.NoError(t, err)
+
+			// Only the host of the Webex meeting may end it from Mattermost, whoever shared it.
+			meeting := store.meetings["thepostid"]
+			require.NotNil(t, meeting)
+			assert.Equal(t, tc.hostUserID, meeting.HostUserID)
+			assert.Equal(t, tc.status, meeting.Status)
+			if tc.hostUserID != "theuserid" {
+				assert.Equal(t, ErrNotMeetingHost, p.endMeeting(context.Background(), "theuserid", meeting))
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// getThreadMeeting returns the meeting whose thread the notes and action items of a command in channelID go to: the
+// meeting of rootID when the command was run in its thread, or else the latest meeting of the channel that started.
+func (p *Plugin) getThreadMeeting(channelID, rootID string) (*Meeting, error) {
+	if rootID != "" {
+		meeting, err := p.store.LoadMeeting(rootID)
+		if err == nil && meeting.ChannelID == channelID {
+			return meeting, nil
+		}
+		if err != nil && err != ErrMeetingNotFound {
+			return nil, err
+		}
+	}
+
+	meetings, err := p.store.LoadMeetingsByChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(meetings) - 1; i >= 0; i-- {
+		if meetings[i].Status != webex.StatusScheduled {
+			return meetings[i], nil
+		}
+	}
+	return nil, ErrMeetingNotFound
+}
+
+// postInMeetingThread replies to the post of meeting on behalf of mattermostUserID.
+func (p *Plugin) postInMeetingThread(mattermostUserID string, meeting *Meeting, message string) error {
+	post := &model.Post{
+		UserId:    mattermostUserID,
+		ChannelId: meeting.ChannelID,
+		RootId:    meeting.PostID,
+		Message:   message,
+	}
+	if _, appErr := p.API.CreatePost(post); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// addActionItem collects an action item of meeting, and posts it in its thread.
+func (p *Plugin) addActionItem(mattermostUserID string, meeting *Meeting, text string) error {
+	item := ActionItem{Text: text, UserID: mattermostUserID, CreatedAt: time.Now()}
+	err := p.updateMeeting(meeting.PostID, func(meeting *Meeting) bool {
+		meeting.ActionItems = append(meeting.ActionItems, item)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return p.postInMeetingThread(mattermostUserID, meeting, "**Action item:**\n"+formatActionItem(item, ""))
+}
+
+// formatActionItem renders item as a checklist entry, crediting its author when username is set.
+func formatActionItem(item ActionItem, username string) string {
+	text := strings.ReplaceAll(item.Text, "\n", " ")
+	if username == "" {
+		return "- [ ] " + text
+	}
+	return fmt.Sprintf("- [ ] %s (@%s)", text, username)
+}
+
+// formatActionItems renders the action items of meeting as a checklist.
+func (p *Plugin) formatActionItems(meeting *Meeting) string {
+	usernames := map[string]string{}
+	var lines []string
+	for _, item := range meeting.ActionItems {
+		username, ok := usernames[item.UserID]
+		if !ok {
+			if user, err := p.getUser(item.UserID); err == nil {
+				username = user.Username
+			}
+			usernames[item.UserID] = username
+		}
+		lines = append(lines, formatActionItem(item, username))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatAttendees lists the attendees reported by Webex, mentioning those with a Mattermost account.
+func (p *Plugin) formatAttendees(meeting *Meeting) string {
+	if len(meeting.Attendees) == 0 {
+		return "not reported by Webex"
+	}
+
+	var names []string
+	for _, attendee := range meeting.Attendees {
+		if attendee.Email != "" {
+			if user, appErr := p.API.GetUserByEmail(attendee.Email); appErr == nil {
+				names = append(names, "@"+user.Username)
+				continue
+			}
+		}
+		name := attendee.DisplayName
+		if name == "" {
+			name = attendee.Email
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// formatMeetingSummary renders the summary of an ended meeting.
+func (p *Plugin) formatMeetingSummary(meeting *Meeting) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#### Meeting summary: %s\n", meeting.Title)
+	fmt.Fprintf(&b, "**Duration:** %s\n", formatDuration(meeting.Duration()))
+	fmt.Fprintf(&b, "**Attendees:** %s\n", p.formatAttendees(meeting))
+	if len(meeting.ActionItems) == 0 {
+		b.WriteString("**Action items:** none were collected with `/webex actions`.")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "**Action items:**\n%s", p.formatActionItems(meeting))
+	return b.String()
+}
+
+// postMeetingSummary replies to the post of an ended meeting with its summary.
+func (p *Plugin) postMeetingSummary(meeting *Meeting) {
+	if err := p.postInMeetingThread(p.botUserID, meeting, p.formatMeetingSummary(meeting)); err != nil {
+		p.errorf("error posting the summary of the meeting for post: %s, error: %v", meeting.PostID, err)
+	}
+}
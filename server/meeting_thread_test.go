@@ -0,0 +1,129 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// threadStore lists its meetings as the meetings of every channel, in order.
+type threadStore struct {
+	mockStore
+
+	order []string
+}
+
+func (store threadStore) LoadMeetingsByChannel(_ string) ([]*Meeting, error) {
+	var meetings []*Meeting
+	for _, postID := range store.order {
+		meeting, _ := store.LoadMeeting(postID)
+		meetings = append(meetings, meeting)
+	}
+	return meetings, nil
+}
+
+func newThreadPlugin() (*Plugin, *plugintest.API, threadStore) {
+	siteURL := "https://mattermost.example.com"
+	api := &plugintest.API{}
+	api.On("GetConfig").Return(&model.Config{ServiceSettings: model.ServiceSettings{SiteURL: &siteURL}})
+	api.On("GetUser", "theuserid").Return(&model.User{Id: "theuserid", Username: "alice"}, nil)
+	api.On("KVSetWithOptions", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(true, nil)
+	api.On("GetPost", mock.AnythingOfType("string")).Return(&model.Post{Type: "custom_webex"}, nil)
+	api.On("UpdatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{}, nil)
+
+	store := threadStore{
+		mockStore: mockStore{meetings: map[string]*Meeting{
+			"firstpostid":     {PostID: "firstpostid", ChannelID: "thechannelid", Status: webex.StatusEnded, Title: "First"},
+			"secondpostid":    {PostID: "secondpostid", ChannelID: "thechannelid", Status: webex.StatusStarted, Title: "Second"},
+			"scheduledpostid": {PostID: "scheduledpostid", ChannelID: "thechannelid", Status: webex.StatusScheduled, Title: "Later"},
+		}},
+		order: []string{"firstpostid", "secondpostid", "scheduledpostid"},
+	}
+
+	return newTestPlugin(api, nil, store, nil), api, store
+}
+
+func TestGetThreadMeeting(t *testing.T) {
+	p, _, _ := newThreadPlugin()
+
+	meeting, err := p.getThreadMeeting("thechannelid", "")
+	require.NoError(t, err)
+	assert.Equal(t, "secondpostid", meeting.PostID, "the latest meeting that started")
+
+	meeting, err = p.getThreadMeeting("thechannelid", "firstpostid")
+	require.NoError(t, err)
+	assert.Equal(t, "firstpostid", meeting.PostID, "the meeting of the thread")
+
+	meeting, err = p.getThreadMeeting("thechannelid", "someotherpostid")
+	require.NoError(t, err)
+	assert.Equal(t, "secondpostid", meeting.PostID)
+}
+
+func TestExecuteActions(t *testing.T) {
+	p, api, store := newThreadPlugin()
+	var replies []*model.Post
+	api.On("CreatePost", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+		replies = append(replies, args.Get(0).(*model.Post))
+	}).Return(&model.Post{}, nil)
+	responses := recordEphemeralPosts(api, "theuserid")
+	header := &model.CommandArgs{UserId: "theuserid", ChannelId: "thechannelid"}
+
+	executeActions(p, nil, header, "Send", "the", "slides")
+	require.Len(t, replies, 1)
+	assert.Equal(t, "secondpostid", replies[0].RootId)
+	assert.Equal(t, "theuserid", replies[0].UserId)
+	assert.Equal(t, "**Action item:**\n- [ ] Send the slides", replies[0].Message)
+
+	meeting, err := store.LoadMeeting("secondpostid")
+	require.NoError(t, err)
+	require.Len(t, meeting.ActionItems, 1)
+	assert.Equal(t, "Send the slides", meeting.ActionItems[0].Text)
+
+	executeActions(p, nil, header)
+	require.Len(t, *responses, 1)
+	assert.Contains(t, (*responses)[0], "- [ ] Send the slides (@alice)")
+
+	executeNotes(p, nil, &model.CommandArgs{UserId: "theuserid", ChannelId: "thechannelid", RootId: "firstpostid"}, "Budget", "approved")
+	require.Len(t, replies, 2)
+	assert.Equal(t, "firstpostid", replies[1].RootId)
+	assert.Equal(t, "**Note:** Budget approved", replies[1].Message)
+}
+
+func TestFormatMeetingSummary(t *testing.T) {
+	p, api, _ := newThreadPlugin()
+	api.On("GetUserByEmail", "alice@example.com").Return(&model.User{Username: "alice"}, nil)
+	api.On("GetUserByEmail", "guest@example.com").Return(nil, &model.AppError{Message: "not found"})
+
+	started := time.Now().Add(-time.Hour)
+	meeting := &Meeting{
+		Title:     "Planning",
+		StartedAt: started,
+		EndedAt:   started.Add(65 * time.Minute),
+		Attendees: []Attendee{
+			{Email: "alice@example.com", DisplayName: "Alice"},
+			{Email: "guest@example.com", DisplayName: "Guest"},
+		},
+		ActionItems: []ActionItem{{Text: "Book the room", UserID: "theuserid"}},
+	}
+
+	assert.Equal(t, "#### Meeting summary: Planning\n"+
+		"**Duration:** 1h 5m\n"+
+		"**Attendees:** @alice, Guest\n"+
+		"**Action items:**\n- [ ] Book the room (@alice)", p.formatMeetingSummary(meeting))
+
+	meeting.Attendees = nil
+	meeting.ActionItems = nil
+	summary := p.formatMeetingSummary(meeting)
+	assert.Contains(t, summary, "**Attendees:** not reported by Webex")
+	assert.Contains(t, summary, "**Action items:** none were collected")
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const oauthCompleteHTML = `<!DOCTYPE html>
+<html>
+	<head>
+		<script>
+			window.close();
+		</script>
+	</head>
+	<body>
+		<p>Completed connecting to Webex. Please close this window.</p>
+	</body>
+</html>
+`
+
+var webexOAuthScopes = []string{
+	"spark:kms",
+	"meeting:schedules_read",
+	"meeting:schedules_write",
+	"meeting:participants_read",
+	"meeting:recordings_read",
+	"meeting:transcripts_read",
+}
+
+var ErrNotConnected = errors.New("your Webex account is not connected, please run `/webex connect` first")
+
+func (p *Plugin) getOAuthConfig() *webex.OAuthConfig {
+	config := p.getConfiguration()
+	return &webex.OAuthConfig{
+		ClientID:     config.OAuthClientID,
+		ClientSecret: config.OAuthClientSecret,
+		RedirectURL:  p.GetPluginURL() + routeOAuthComplete,
+		Scopes:       webexOAuthScopes,
+	}
+}
+
+func (p *Plugin) handleOAuthConnect(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodGet {
+		return http.StatusMethodNotAllowed,
+			errors.New("method " + r.Method + " is not allowed, must be GET")
+	}
+
+	userID := r.Header.Get("Mattermost-User-Id")
+	if userID == "" {
+		return http.StatusUnauthorized, errors.New("not authorized")
+	}
+
+	if !p.getConfiguration().IsOAuthConfigured() {
+		return http.StatusBadRequest, errors.New("the Webex plugin has not been configured to connect accounts. Please speak with your Mattermost administrator")
+	}
+
+	state := fmt.Sprintf("%s_%s", model.NewId(), userID)
+	if err := p.store.StoreOAuthState(userID, state); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	w.Header().Set("Location", p.getOAuthConfig().AuthCodeURL(state))
+	return http.StatusFound, nil
+}
+
+func (p *Plugin) handleOAuthComplete(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodGet {
+		return http.StatusMethodNotAllowed,
+			errors.New("method " + r.Method + " is not allowed, must be GET")
+	}
+
+	userID := r.Header.Get("Mattermost-User-Id")
+	if userID == "" {
+		return http.StatusUnauthorized, errors.New("not authorized")
+	}
+
+	if !p.getConfiguration().IsOAuthConfigured() {
+		return http.StatusBadRequest, errors.New("the Webex plugin has not been configured to connect accounts. Please speak with your Mattermost administrator")
+	}
+
+	query := r.URL.Query()
+	if errMsg := query.Get("error"); errMsg != "" {
+		return http.StatusBadRequest, fmt.Errorf("webex authorization failed: %s", errMsg)
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		return http.StatusBadRequest, errors.New("missing authorization code")
+	}
+
+	if err := p.store.VerifyOAuthState(userID, query.Get("state")); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	token, err := p.getOAuthConfig().Exchange(p.httpClient, code)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to exchange the authorization code: %v", err)
+	}
+
+	if err = p.storeUserToken(userID, token); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if err = p.registerWebhooks(userID, token); err != nil {
+		// Meetings can still be created, their posts only won't be updated live.
+		p.errorf("unable to register the Webex webhooks for mattermostUserID: %s, error: %v", userID, err)
+	}
+
+	p.dm(userID, "Your Webex account has been connected. Use `/webex disconnect` to disconnect it.")
+
+	w.Header().Set("Content-Type", "text/html")
+	if _, err = w.Write([]byte(oauthCompleteHTML)); err != nil {
+		p.API.LogWarn("failed to write response", "error", err.Error())
+	}
+
+	return http.StatusOK, nil
+}
+
+// getUserToken returns a valid Webex token for mattermostUserID, refreshing and storing it when it has expired.
+func (p *Plugin) getUserToken(mattermostUserID string) (*webex.Token, error) {
+	config := p.getConfiguration()
+	if !config.IsOAuthConfigured() {
+		return nil, ErrNotConnected
+	}
+
+	userInfo, err := p.store.LoadUserInfo(mattermostUserID)
+	if err == ErrUserNotFound {
+		return nil, ErrNotConnected
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.decryptUserToken(mattermostUserID, userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Valid() {
+		return token, nil
+	}
+	if !token.CanRefresh() {
+		return nil, ErrNotConnected
+	}
+
+	token, err = p.getOAuthConfig().Refresh(p.httpClient, token.RefreshToken)
+	if err != nil {
+		p.errorf("unable to refresh the Webex token for mattermostUserID: %s, error: %v", mattermostUserID, err)
+		return nil, ErrNotConnected
+	}
+
+	if err = p.storeUserToken(mattermostUserID, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// decryptUserToken returns the Webex token stored in userInfo as it is, without refreshing it.
+func (p *Plugin) decryptUserToken(mattermostUserID string, userInfo UserInfo) (*webex.Token, error) {
+	config := p.getConfiguration()
+	if !config.IsOAuthConfigured() || userInfo.EncryptedToken == "" {
+		return nil, ErrNotConnected
+	}
+
+	plain, err := decrypt(encryptionKey(config.EncryptionKey), userInfo.EncryptedToken)
+	if err != nil {
+		p.errorf("unable to decrypt the Webex token for mattermostUserID: %s, error: %v", mattermostUserID, err)
+		return nil, ErrNotConnected
+	}
+
+	token := &webex.Token{}
+	if err = json.Unmarshal([]byte(plain), token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// storeUserToken encrypts token and stores it in mattermostUserID's UserInfo. A nil token disconnects the user.
+func (p *Plugin) storeUserToken(mattermostUserID string, token *webex.Token) error {
+	encryptedToken := ""
+	if token != nil {
+		data, err := json.Marshal(token)
+		if err != nil {
+			return err
+		}
+		encryptedToken, err = encrypt(encryptionKey(p.getConfiguration().EncryptionKey), string(data))
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.store.UpdateUserInfo(mattermostUserID, func(info *UserInfo) error {
+		info.EncryptedToken = encryptedToken
+		if token != nil {
+			info.TranscriptsUnauthorized = false
+		}
+		return nil
+	})
+}
+
+func (p *Plugin) dm(mattermostUserID, message string) {
+	channel, appErr := p.API.GetDirectChannel(mattermostUserID, p.botUserID)
+	if appErr != nil {
+		p.errorf("unable to get the direct channel for mattermostUserID: %s, error: %v", mattermostUserID, appErr)
+		return
+	}
+
+	post := &model.Post{
+		UserId:    p.botUserID,
+		ChannelId: channel.Id,
+		Message:   message,
+	}
+	if _, appErr = p.API.CreatePost(post); appErr != nil {
+		p.errorf("unable to send a direct message to mattermostUserID: %s, error: %v", mattermostUserID, appErr)
+	}
+}
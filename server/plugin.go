@@ -5,16 +5,19 @@ package main
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/mattermost/mattermost-plugin-webex/server/webex"
 
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
 	"github.com/pkg/errors"
 )
 
@@ -42,8 +45,29 @@ type Plugin struct {
 	// KV store
 	store Store
 
-	// the http client
-	webexClient webex.Client
+	// webexClients holds the XML API client of each Webex site, by site host. Consult getWebexClient for usage.
+	webexClients     map[string]webex.Client
+	webexClientsLock sync.RWMutex
+
+	// pmrCache keeps the recent Personal Meeting Room lookups of webexClients in memory, in front of the KV store.
+	pmrCache pmrCache
+
+	// webexRESTClient is used for the Webex REST APIs, on behalf of connected users
+	webexRESTClient webex.RESTClient
+
+	// httpClient is used for requests made on behalf of users, such as OAuth token exchanges.
+	httpClient *http.Client
+
+	// reminderJob sends the reminders of scheduled meetings. Consult ensureReminderJob for usage.
+	reminderJob     *cluster.Job
+	reminderJobLock sync.Mutex
+
+	// recordingJob shares the recordings of ended meetings. Consult ensureRecordingJob for usage.
+	recordingJob     *cluster.Job
+	recordingJobLock sync.Mutex
+
+	// userSweepJob removes the settings of departed users. Consult startUserSweepJob for usage.
+	userSweepJob *cluster.Job
 }
 
 // OnActivate checks if the configurations is valid and ensures the bot account exists
@@ -75,8 +99,12 @@ func (p *Plugin) OnActivate() error {
 	}
 
 	p.store = NewStore(p)
+	go p.migrateStore(p.store)
+
+	p.httpClient = &http.Client{Timeout: 30 * time.Second}
+	p.webexRESTClient = webex.NewRESTClient(webex.DefaultAPIURL, p.httpClient)
 
-	p.webexClient = webex.NewClient(config.SiteHost, config.siteName)
+	p.setWebexClients(p.newWebexClients(config))
 
 	command, err := p.getCommand()
 	if err != nil {
@@ -88,6 +116,25 @@ func (p *Plugin) OnActivate() error {
 		return errors.WithMessage(err, "OnActivate: failed to register command")
 	}
 
+	p.ensureReminderJob()
+	p.ensureRecordingJob()
+	p.startUserSweepJob()
+
+	return nil
+}
+
+// migrateStore upgrades store, logging any failure. The next activation tries again.
+func (p *Plugin) migrateStore(store Store) {
+	if err := store.Migrate(); err != nil {
+		p.errorf("failed to migrate the store: %v", err)
+	}
+}
+
+// OnDeactivate stops the background jobs.
+func (p *Plugin) OnDeactivate() error {
+	p.stopReminderJob()
+	p.stopRecordingJob()
+	p.stopUserSweepJob()
 	return nil
 }
 
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPlugin returns a plugin on api, config and store, whose Webex site is served by client unless it is nil.
+func newTestPlugin(api *plugintest.API, config *configuration, store Store, client webex.Client) *Plugin {
+	p := &Plugin{}
+	p.SetAPI(api)
+	p.setConfiguration(config)
+	p.store = store
+	if client != nil {
+		p.setWebexClients(map[string]webex.Client{config.SiteHost: client})
+	}
+	return p
+}
+
+// newOAuthConfiguration returns the configuration of siteHost, whose users can connect their Webex accounts.
+func newOAuthConfiguration(siteHost string) *configuration {
+	return &configuration{SiteHost: siteHost, OAuthClientID: "clientid", OAuthClientSecret: "clientsecret", EncryptionKey: "theencryptionkey"}
+}
+
+// newEncryptedToken returns token as stored in the settings of a user connected under config.
+func newEncryptedToken(t *testing.T, config *configuration, token *webex.Token) string {
+	data, err := json.Marshal(token)
+	require.NoError(t, err)
+	encryptedToken, err := encrypt(encryptionKey(config.EncryptionKey), string(data))
+	require.NoError(t, err)
+	return encryptedToken
+}
+
+// serveWebexAPI serves the Webex REST API of p with handler until the test ends.
+func serveWebexAPI(t *testing.T, p *Plugin, handler http.HandlerFunc) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	p.webexRESTClient = webex.NewRESTClient(server.URL, server.Client())
+}
+
+// recordEphemeralPosts records the messages of the ephemeral posts sent to userID, such as the responses to commands.
+func recordEphemeralPosts(api *plugintest.API, userID string) *[]string {
+	var messages []string
+	api.On("SendEphemeralPost", userID, mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+		messages = append(messages, args.Get(1).(*model.Post).Message)
+	}).Return(nil)
+	return &messages
+}
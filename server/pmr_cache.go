@@ -0,0 +1,206 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+)
+
+const (
+	defaultPMRCacheTTL = time.Hour
+
+	// pmrNotFoundTTL bounds how long a lookup that found no Personal Room is cached, so a user fixing their Webex
+	// account doesn't wait long.
+	pmrNotFoundTTL = 2 * time.Minute
+
+	// pmrMemoryTTL bounds how long a lookup is cached in memory. Invalidations only reach the KV store and the
+	// memory of the node they happen on, so the other nodes of a cluster pick them up after at most this long.
+	pmrMemoryTTL = time.Minute
+)
+
+// PMRCacheEntry is the cached result of a Personal Meeting Room lookup.
+type PMRCacheEntry struct {
+	// URL is empty when no Personal Room was found, in which case NotFoundReason explains why.
+	URL            string    `json:"url,omitempty"`
+	NotFoundReason string    `json:"not_found_reason,omitempty"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// cachedNotFoundError replays a cached lookup that found no Personal Room.
+type cachedNotFoundError struct {
+	reason string
+}
+
+func (e cachedNotFoundError) Error() string {
+	return e.reason
+}
+
+func (e cachedNotFoundError) Unwrap() error {
+	return webex.ErrUserNotFound
+}
+
+// pmrCache is the in-memory layer of the Personal Meeting Room cache, in front of the KV store.
+type pmrCache struct {
+	lock    sync.Mutex
+	entries map[string]PMRCacheEntry
+}
+
+func (c *pmrCache) get(key string, now time.Time) (PMRCacheEntry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.ExpiresAt) {
+		return PMRCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *pmrCache) set(key string, entry PMRCacheEntry, now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[string]PMRCacheEntry{}
+	}
+	for k, e := range c.entries {
+		if !now.Before(e.ExpiresAt) {
+			delete(c.entries, k)
+		}
+	}
+
+	if expiresAt := now.Add(pmrMemoryTTL); expiresAt.Before(entry.ExpiresAt) {
+		entry.ExpiresAt = expiresAt
+	}
+	c.entries[key] = entry
+}
+
+func (c *pmrCache) delete(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.entries, key)
+}
+
+// pmrCacheKey identifies a lookup of GetPersonalMeetingRoomURL on siteHost.
+func pmrCacheKey(siteHost, roomID, username, email string) string {
+	return strings.Join([]string{siteHost, roomID, username, email}, "|")
+}
+
+// cachingClient caches the Personal Meeting Room lookups of a webex.Client in the plugin's memory and KV store.
+type cachingClient struct {
+	webex.Client
+
+	plugin   *Plugin
+	siteHost string
+	ttl      time.Duration
+}
+
+func (p *Plugin) newCachingClient(client webex.Client, siteHost string, ttl time.Duration) webex.Client {
+	if ttl < 0 {
+		return client
+	}
+	if ttl == 0 {
+		ttl = defaultPMRCacheTTL
+	}
+	return &cachingClient{
+		Client:   client,
+		plugin:   p,
+		siteHost: siteHost,
+		ttl:      ttl,
+	}
+}
+
+func (c *cachingClient) GetPersonalMeetingRoomURL(ctx context.Context, roomID, username, email string) (string, error) {
+	key := pmrCacheKey(c.siteHost, roomID, username, email)
+	now := time.Now()
+
+	entry, ok := c.plugin.pmrCache.get(key, now)
+	if !ok {
+		entry, ok = c.loadEntry(key, now)
+	}
+	if ok {
+		if entry.URL == "" {
+			return "", cachedNotFoundError{reason: entry.NotFoundReason}
+		}
+		return entry.URL, nil
+	}
+
+	pmrURL, err := c.Client.GetPersonalMeetingRoomURL(ctx, roomID, username, email)
+	switch {
+	case err == nil:
+		c.storeEntry(key, PMRCacheEntry{URL: pmrURL}, c.ttl, now)
+	case webex.IsUserNotFound(err):
+		c.storeEntry(key, PMRCacheEntry{NotFoundReason: err.Error()}, min(c.ttl, pmrNotFoundTTL), now)
+	}
+	return pmrURL, err
+}
+
+func (c *cachingClient) loadEntry(key string, now time.Time) (PMRCacheEntry, bool) {
+	entry, err := c.plugin.store.LoadPMRCacheEntry(key)
+	if err != nil {
+		if err != ErrPMRNotCached {
+			c.plugin.errorf("error loading the cached personal meeting room: %v", err)
+		}
+		return PMRCacheEntry{}, false
+	}
+	if !now.Before(entry.ExpiresAt) {
+		return PMRCacheEntry{}, false
+	}
+
+	c.plugin.pmrCache.set(key, entry, now)
+	return entry, true
+}
+
+func (c *cachingClient) storeEntry(key string, entry PMRCacheEntry, ttl time.Duration, now time.Time) {
+	entry.ExpiresAt = now.Add(ttl)
+	c.plugin.pmrCache.set(key, entry, now)
+	if err := c.plugin.store.StorePMRCacheEntry(key, entry, ttl); err != nil {
+		c.plugin.errorf("error caching the personal meeting room: %v", err)
+	}
+}
+
+// invalidatePMRCache forgets the cached lookups of the Personal Room of mattermostUserID, with and without the identity
+// mapping rules, as well as the lookups of roomIDs, on every site, so a changed room setting takes effect immediately.
+func (p *Plugin) invalidatePMRCache(mattermostUserID string, roomIDs ...string) {
+	config := p.getConfiguration()
+
+	var identities []webexIdentity
+	if user, err := p.getUser(mattermostUserID); err == nil {
+		identity := webexIdentity{Username: user.Username, Email: user.Email}
+		identities = append(identities, identity)
+		if mapped := config.webexIdentity(user); mapped != identity {
+			identities = append(identities, mapped)
+		}
+	}
+
+	p.deletePMRCacheEntries(identities, roomIDs)
+}
+
+// deletePMRCacheEntries forgets the cached lookups of identities and roomIDs on every site.
+func (p *Plugin) deletePMRCacheEntries(identities []webexIdentity, roomIDs []string) {
+	var keys []string
+	for _, siteHost := range p.getConfiguration().siteHosts() {
+		for _, roomID := range roomIDs {
+			if roomID != "" {
+				keys = append(keys, pmrCacheKey(siteHost, roomID, "", ""))
+			}
+		}
+		for _, identity := range identities {
+			keys = append(keys, pmrCacheKey(siteHost, identity.RoomID, identity.Username, identity.Email))
+		}
+	}
+
+	for _, key := range keys {
+		p.pmrCache.delete(key)
+		if err := p.store.DeletePMRCacheEntry(key); err != nil {
+			p.errorf("error invalidating the personal meeting room cache entry: %s, error: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingClient answers lookups from rooms, counting the calls it receives.
+type countingClient struct {
+	webex.Client
+
+	rooms map[string]string
+	err   error
+	calls int
+}
+
+func (c *countingClient) GetPersonalMeetingRoomURL(_ context.Context, roomID, username, _ string) (string, error) {
+	c.calls++
+	if c.err != nil {
+		return "", c.err
+	}
+	if pmrURL, ok := c.rooms[roomID+username]; ok {
+		return pmrURL, nil
+	}
+	return "", webex.ErrUserNotFound
+}
+
+func newCachingTestPlugin(client webex.Client) (*Plugin, webex.Client) {
+	api := &plugintest.API{}
+	api.On("GetUser", "theuserid").Return(&model.User{Email: "alice@example.com", Username: "alice"}, nil)
+
+	p := newTestPlugin(api, &configuration{SiteHost: "site.webex.com"}, mockStore{pmrCache: map[string]PMRCacheEntry{}}, nil)
+	return p, p.newCachingClient(client, "site.webex.com", time.Hour)
+}
+
+func TestCachingClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("caches found rooms", func(t *testing.T) {
+		client := &countingClient{rooms: map[string]string{"alice": "https://site.webex.com/meet/alice"}}
+		p, cached := newCachingTestPlugin(client)
+
+		for i := 0; i < 3; i++ {
+			pmrURL, err := cached.GetPersonalMeetingRoomURL(ctx, "", "alice", "alice@example.com")
+			require.NoError(t, err)
+			assert.Equal(t, "https://site.webex.com/meet/alice", pmrURL)
+		}
+		assert.Equal(t, 1, client.calls)
+
+		// A fresh node only has the KV store.
+		p.pmrCache = pmrCache{}
+		_, err := cached.GetPersonalMeetingRoomURL(ctx, "", "alice", "alice@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, 1, client.calls)
+	})
+
+	t.Run("caches rooms not found", func(t *testing.T) {
+		client := &countingClient{}
+		_, cached := newCachingTestPlugin(client)
+
+		for i := 0; i < 2; i++ {
+			_, err := cached.GetPersonalMeetingRoomURL(ctx, "bob", "", "")
+			assert.True(t, errors.Is(err, webex.ErrUserNotFound))
+		}
+		assert.Equal(t, 1, client.calls)
+	})
+
+	t.Run("doesn't cache other errors", func(t *testing.T) {
+		client := &countingClient{err: webex.ErrSiteNotFound}
+		_, cached := newCachingTestPlugin(client)
+
+		for i := 0; i < 2; i++ {
+			_, err := cached.GetPersonalMeetingRoomURL(ctx, "alice", "", "")
+			assert.True(t, errors.Is(err, webex.ErrSiteNotFound))
+		}
+		assert.Equal(t, 2, client.calls)
+	})
+
+	t.Run("invalidates the rooms of a user", func(t *testing.T) {
+		client := &countingClient{rooms: map[string]string{
+			"alice":  "https://site.webex.com/meet/alice",
+			"alice2": "https://site.webex.com/meet/alice2",
+		}}
+		p, cached := newCachingTestPlugin(client)
+
+		_, err := cached.GetPersonalMeetingRoomURL(ctx, "alice2", "", "")
+		require.NoError(t, err)
+		_, err = cached.GetPersonalMeetingRoomURL(ctx, "", "alice", "alice@example.com")
+		require.NoError(t, err)
+		require.Equal(t, 2, client.calls)
+
+		p.invalidatePMRCache("theuserid", "alice2")
+
+		_, err = cached.GetPersonalMeetingRoomURL(ctx, "alice2", "", "")
+		require.NoError(t, err)
+		_, err = cached.GetPersonalMeetingRoomURL(ctx, "", "alice", "alice@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, 4, client.calls)
+	})
+}
+
+func TestPMRCacheExpiry(t *testing.T) {
+	now := time.Now()
+	var cache pmrCache
+
+	cache.set("key", PMRCacheEntry{URL: "url", ExpiresAt: now.Add(time.Hour)}, now)
+
+	_, ok := cache.get("key", now.Add(pmrMemoryTTL-time.Second))
+	assert.True(t, ok)
+	_, ok = cache.get("key", now.Add(pmrMemoryTTL))
+	assert.False(t, ok, "the memory layer keeps entries for at most pmrMemoryTTL")
+}
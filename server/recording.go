@@ -0,0 +1,333 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	recordingJobKey      = "recording_job"
+	recordingJobInterval = time.Minute
+
+	// Webex takes from a few minutes to several hours to process a recording and its transcript, so their lookups
+	// back off from firstRecordingLookupDelay up to maxRecordingLookupDelay, and give up after
+	// maxRecordingLookupAttempts.
+	firstRecordingLookupDelay  = 5 * time.Minute
+	maxRecordingLookupDelay    = 2 * time.Hour
+	maxRecordingLookupAttempts = 8
+
+	// maxUnrecordedLookupAttempts is the number of lookups after which a meeting that Webex lists no recording for is
+	// considered not recorded. The recordings webhook brings the lookups back for a recording listed later.
+	maxUnrecordedLookupAttempts = 3
+)
+
+// lookupOutcome is what a lookup found of the recordings, or of the transcripts, of a meeting.
+type lookupOutcome int
+
+const (
+	// lookupFailed means the lookup didn't complete, and is worth retrying.
+	lookupFailed lookupOutcome = iota
+	// lookupNothing means Webex listed nothing available.
+	lookupNothing
+	// lookupDone means everything Webex listed as available is shared.
+	lookupDone
+)
+
+// RecordingLookup is a pending lookup of the recordings and transcripts of an ended meeting. Attempt counts the
+// lookups made so far.
+type RecordingLookup struct {
+	PostID  string    `json:"post_id"`
+	Attempt int       `json:"attempt"`
+	At      time.Time `json:"at"`
+}
+
+// MeetingRecording is a recording of a meeting, as shared in its thread.
+type MeetingRecording struct {
+	ID              string `json:"id"`
+	URL             string `json:"url"`
+	Password        string `json:"password,omitempty"`
+	DurationSeconds int    `json:"duration_seconds"`
+}
+
+// recordingLookupDelay returns how long to wait before the given lookup attempt (starting at 0).
+func recordingLookupDelay(attempt int) time.Duration {
+	d := firstRecordingLookupDelay
+	for i := 0; i < attempt && d < maxRecordingLookupDelay; i++ {
+		d *= 2
+	}
+	return min(d, maxRecordingLookupDelay)
+}
+
+func (p *Plugin) scheduleRecordingLookup(postID string, attempt int, at time.Time) error {
+	return p.store.ScheduleRecordingLookup(RecordingLookup{PostID: postID, Attempt: attempt, At: at})
+}
+
+// startRecordingLookups schedules the first lookup of the recordings and transcripts of an ended meeting. Only
+// meetings created through the Webex REST API can be looked up.
+func (p *Plugin) startRecordingLookups(meeting *Meeting) {
+	if meeting.WebexMeetingID == "" {
+		return
+	}
+	if err := p.scheduleRecordingLookup(meeting.PostID, 0, time.Now().Add(recordingLookupDelay(0))); err != nil {
+		p.errorf("error scheduling the recording lookup for post: %s, error: %v", meeting.PostID, err)
+	}
+}
+
+// ensureRecordingJob starts the job looking up recordings, unless it is already running. Recordings are looked up on
+// behalf of their meeting's host, so the job only runs once users can connect their accounts.
+func (p *Plugin) ensureRecordingJob() {
+	p.recordingJobLock.Lock()
+	defer p.recordingJobLock.Unlock()
+
+	if p.recordingJob != nil || !p.getConfiguration().IsOAuthConfigured() {
+		return
+	}
+
+	job, err := cluster.Schedule(p.API, recordingJobKey, cluster.MakeWaitForInterval(recordingJobInterval), p.lookupDueRecordings)
+	if err != nil {
+		p.errorf("unable to schedule the recording job: %v", err)
+		return
+	}
+	p.recordingJob = job
+}
+
+func (p *Plugin) stopRecordingJob() {
+	p.recordingJobLock.Lock()
+	defer p.recordingJobLock.Unlock()
+
+	if p.recordingJob == nil {
+		return
+	}
+	if err := p.recordingJob.Close(); err != nil {
+		p.errorf("unable to close the recording job: %v", err)
+	}
+	p.recordingJob = nil
+}
+
+// lookupDueRecordings is run on a single node of the cluster at a time.
+func (p *Plugin) lookupDueRecordings() {
+	lookups, err := p.store.PopDueRecordingLookups(time.Now())
+	if err != nil {
+		p.errorf("unable to load the due recording lookups: %v", err)
+		return
+	}
+
+	for _, lookup := range lookups {
+		p.lookupRecordings(lookup)
+	}
+}
+
+// lookupRecordings shares the new recordings and transcripts of the meeting of lookup in its thread, and retries
+// later while Webex may still produce them. Webex transcribes a meeting along with its recording, so a transcript that
+// isn't listed once the recording is won't be.
+func (p *Plugin) lookupRecordings(lookup RecordingLookup) {
+	meeting, err := p.store.LoadMeeting(lookup.PostID)
+	if err != nil {
+		p.errorf("unable to load the meeting of a recording lookup for post: %s, error: %v", lookup.PostID, err)
+		return
+	}
+
+	if meeting.HostUserID == "" {
+		p.API.LogInfo("Not sharing the recordings of a meeting whose host has no Mattermost account", "post_id", meeting.PostID)
+		return
+	}
+
+	// The recordings are listed with the host's token, so they are only shared in a channel the host is a member of.
+	if _, appErr := p.API.GetChannelMember(meeting.ChannelID, meeting.HostUserID); appErr != nil {
+		p.API.LogInfo("Not sharing the recordings of a meeting whose host left its channel", "post_id", meeting.PostID)
+		return
+	}
+
+	token, err := p.getUserToken(meeting.HostUserID)
+	if err == ErrNotConnected {
+		p.API.LogInfo("Not sharing the recordings of a meeting whose host disconnected their Webex account", "post_id", meeting.PostID)
+		return
+	}
+	if err != nil {
+		p.errorf("unable to get the Webex token of the host of the meeting for post: %s, error: %v", meeting.PostID, err)
+		p.retryRecordingLookup(lookup)
+		return
+	}
+
+	recordings := p.shareRecordings(token.AccessToken, meeting)
+	transcripts := p.shareTranscripts(token.AccessToken, meeting)
+	p.ensureWebhooks(meeting.HostUserID, token)
+
+	switch {
+	case recordings == lookupDone && transcripts != lookupFailed:
+		return
+	case recordings == lookupNothing && transcripts != lookupFailed && lookup.Attempt+1 >= maxUnrecordedLookupAttempts:
+		p.API.LogInfo("Not looking up the recordings of a meeting that Webex didn't record", "post_id", meeting.PostID)
+		return
+	}
+	p.retryRecordingLookup(lookup)
+}
+
+// shareRecordings shares the new recordings of meeting in its thread.
+func (p *Plugin) shareRecordings(token string, meeting *Meeting) lookupOutcome {
+	recordings, err := p.webexRESTClient.ListRecordings(context.Background(), token, meeting.WebexMeetingID)
+	if err != nil {
+		p.errorf("unable to list the recordings of the Webex meeting: %s, error: %v", meeting.WebexMeetingID, err)
+		return lookupFailed
+	}
+
+	added, err := p.addMeetingRecordings(meeting.PostID, recordings)
+	if err != nil {
+		p.errorf("unable to store the recordings of the meeting for post: %s, error: %v", meeting.PostID, err)
+		return lookupFailed
+	}
+
+	for _, recording := range added {
+		if err = p.postRecording(meeting, recording); err != nil {
+			p.errorf("error posting a recording of the meeting for post: %s, error: %v", meeting.PostID, err)
+		}
+	}
+
+	if len(added) == 0 && len(meeting.Recordings) == 0 {
+		return lookupNothing
+	}
+	return lookupDone
+}
+
+// retryRecordingLookup schedules the next attempt of lookup, unless it was the last one.
+func (p *Plugin) retryRecordingLookup(lookup RecordingLookup) {
+	attempt := lookup.Attempt + 1
+	if attempt >= maxRecordingLookupAttempts {
+		return
+	}
+	if err := p.scheduleRecordingLookup(lookup.PostID, attempt, time.Now().Add(recordingLookupDelay(attempt))); err != nil {
+		p.errorf("error scheduling the recording lookup for post: %s, error: %v", lookup.PostID, err)
+	}
+}
+
+// addMeetingRecordings stores the available recordings that are not yet known for the meeting of postID, and returns
+// them.
+func (p *Plugin) addMeetingRecordings(postID string, recordings []webex.Recording) ([]MeetingRecording, error) {
+	var added []MeetingRecording
+	err := p.updateMeeting(postID, func(meeting *Meeting) bool {
+		added = nil
+		known := map[string]bool{}
+		for _, recording := range meeting.Recordings {
+			known[recording.ID] = true
+		}
+
+		for _, recording := range recordings {
+			if recording.Status != webex.RecordingStatusAvailable || recording.PlaybackURL == "" || known[recording.ID] {
+				continue
+			}
+			known[recording.ID] = true
+			added = append(added, MeetingRecording{
+				ID:              recording.ID,
+				URL:             recording.PlaybackURL,
+				Password:        recording.Password,
+				DurationSeconds: recording.DurationSeconds,
+			})
+		}
+		meeting.Recordings = append(meeting.Recordings, added...)
+		return len(added) > 0
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// postRecording shares recording in the thread of meeting. Its password isn't posted, as the post is seen by whoever
+// joins the channel later: a button shows it to the current members of the channel instead.
+func (p *Plugin) postRecording(meeting *Meeting, recording MeetingRecording) error {
+	post := &model.Post{
+		UserId:    p.botUserID,
+		ChannelId: meeting.ChannelID,
+		RootId:    meeting.PostID,
+		Message:   formatRecording(recording),
+	}
+	if recording.Password != "" {
+		model.ParseSlackAttachment(post, []*model.SlackAttachment{{
+			Actions: []*model.PostAction{{
+				Name: "Show password",
+				Type: model.PostActionTypeButton,
+				Integration: &model.PostActionIntegration{
+					URL:     p.GetPluginURLPath() + routeAPIrecordingPassword,
+					Context: map[string]interface{}{"post_id": meeting.PostID, "recording_id": recording.ID},
+				},
+			}},
+		}})
+	}
+
+	if _, appErr := p.API.CreatePost(post); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// formatRecording renders the reply sharing recording in its meeting's thread.
+func formatRecording(recording MeetingRecording) string {
+	var b strings.Builder
+	b.WriteString("#### Meeting recording\n")
+	fmt.Fprintf(&b, "**Link:** %s\n", recording.URL)
+	fmt.Fprintf(&b, "**Duration:** %s", formatDuration(time.Duration(recording.DurationSeconds)*time.Second))
+	if recording.Password != "" {
+		b.WriteString("\n**Password:** click *Show password* to see it")
+	}
+	return b.String()
+}
+
+// handleRecordingPassword shows the password of a recording to the member of its meeting's channel who asked for it.
+func (p *Plugin) handleRecordingPassword(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed,
+			errors.New("method " + r.Method + " is not allowed, must be POST")
+	}
+
+	userID := r.Header.Get("Mattermost-User-Id")
+	if userID == "" {
+		return http.StatusUnauthorized, errors.New("not authorized")
+	}
+
+	var req model.PostActionIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("err: %v", err)
+	}
+	postID, _ := req.Context["post_id"].(string)
+	recordingID, _ := req.Context["recording_id"].(string)
+	if postID == "" || recordingID == "" {
+		return http.StatusBadRequest, errors.New("post id and recording id required")
+	}
+
+	meeting, err := p.store.LoadMeeting(postID)
+	if err == ErrMeetingNotFound {
+		return http.StatusNotFound, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	// The membership is checked against the stored meeting, not the channel the request claims.
+	if _, appErr := p.API.GetChannelMember(meeting.ChannelID, userID); appErr != nil {
+		return http.StatusForbidden, errors.New("forbidden")
+	}
+
+	response := &model.PostActionIntegrationResponse{EphemeralText: "This recording is no longer available."}
+	for _, recording := range meeting.Recordings {
+		if recording.ID == recordingID && recording.Password != "" {
+			response.EphemeralText = fmt.Sprintf("The password of the recording is `%s`.", recording.Password)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(response); err != nil {
+		p.API.LogWarn("failed to write response", "error", err.Error())
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,351 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingStore keeps the recording lookups scheduled by the plugin.
+type recordingStore struct {
+	*userStore
+
+	scheduled []RecordingLookup
+}
+
+func (store *recordingStore) ScheduleRecordingLookup(lookup RecordingLookup) error {
+	store.scheduled = append(store.scheduled, lookup)
+	return nil
+}
+
+func TestRecordingLookupDelay(t *testing.T) {
+	assert.Equal(t, 5*time.Minute, recordingLookupDelay(0))
+	assert.Equal(t, 10*time.Minute, recordingLookupDelay(1))
+	assert.Equal(t, 80*time.Minute, recordingLookupDelay(4))
+	assert.Equal(t, 2*time.Hour, recordingLookupDelay(5))
+	assert.Equal(t, 2*time.Hour, recordingLookupDelay(maxRecordingLookupAttempts))
+}
+
+func TestScheduleRecordingLookupQueue(t *testing.T) {
+	now := time.Now()
+	queue := scheduleRecordingLookup(nil, RecordingLookup{PostID: "post1", Attempt: 3, At: now.Add(time.Hour)})
+	queue = scheduleRecordingLookup(queue, RecordingLookup{PostID: "post2", At: now.Add(time.Hour)})
+
+	// A webhook event brings the pending lookup of its meeting forward, keeping its attempt count.
+	queue = scheduleRecordingLookup(queue, RecordingLookup{PostID: "post1", At: now})
+	require.Len(t, queue, 2)
+	assert.Equal(t, RecordingLookup{PostID: "post1", Attempt: 3, At: now}, queue[0])
+
+	// A later lookup does not postpone the pending one.
+	queue = scheduleRecordingLookup(queue, RecordingLookup{PostID: "post1", At: now.Add(time.Hour)})
+	require.Len(t, queue, 2)
+	assert.Equal(t, now, queue[0].At)
+}
+
+// newRecordingPlugin returns a plugin whose Webex meeting has the given recordings and transcripts, as JSON arrays.
+// The content of transcript t1 is the sample Webex transcript of the webex package.
+func newRecordingPlugin(t *testing.T, recordings, transcripts string) (*Plugin, *plugintest.API, *recordingStore) {
+	config := newOAuthConfiguration("")
+	encryptedToken := newEncryptedToken(t, config, &webex.Token{AccessToken: "thetoken", Expiry: time.Now().Add(time.Hour)})
+
+	api := &plugintest.API{}
+	api.On("KVSetWithOptions", "mutex_meeting_thepostid", mock.Anything, mock.Anything).Return(true, nil)
+	api.On("LogError", mock.Anything).Return()
+
+	store := &recordingStore{userStore: newUserStore(map[string]UserInfo{"hostid": {EncryptedToken: encryptedToken}})}
+	store.meetings = map[string]*Meeting{"thepostid": {
+		PostID:         "thepostid",
+		ChannelID:      "thechannelid",
+		HostUserID:     "hostid",
+		Status:         webex.StatusEnded,
+		WebexMeetingID: "series1_I_123",
+	}}
+
+	p := newTestPlugin(api, config, store, nil)
+	p.botUserID = "thebotid"
+	serveWebexAPI(t, p, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer thetoken", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/recordings":
+			assert.Equal(t, "series1_I_123", r.URL.Query().Get("meetingId"))
+			_, _ = fmt.Fprintf(w, `{"items":%s}`, recordings)
+		case "/meetingTranscripts":
+			assert.Equal(t, "series1_I_123", r.URL.Query().Get("meetingId"))
+			_, _ = fmt.Fprintf(w, `{"items":%s}`, transcripts)
+		case "/meetingTranscripts/t1/download":
+			http.ServeFile(w, r, filepath.Join("webex", "testdata", "webex.vtt"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	return p, api, store
+}
+
+func TestLookupRecordings(t *testing.T) {
+	available := `[
+		{"id":"r1","playbackUrl":"https://site.webex.com/recordingservice/r1/playback","password":"secret","durationSeconds":754,"status":"available"},
+		{"id":"r2","playbackUrl":"https://site.webex.com/recordingservice/r2/playback","status":"deleted"}
+	]`
+
+	t.Run("shares the available recordings", func(t *testing.T) {
+		p, api, store := newRecordingPlugin(t, available, `[]`)
+		api.On("GetChannelMember", "thechannelid", "hostid").Return(&model.ChannelMember{}, nil)
+		api.On("GetPost", "thepostid").Return(&model.Post{Id: "thepostid", Type: "custom_webex"}, nil)
+		var updated *model.Post
+		api.On("UpdatePost", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+			updated = args.Get(0).(*model.Post)
+		}).Return(&model.Post{}, nil)
+		var replies []*model.Post
+		api.On("CreatePost", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+			replies = append(replies, args.Get(0).(*model.Post))
+		}).Return(&model.Post{}, nil)
+
+		p.lookupRecordings(RecordingLookup{PostID: "thepostid"})
+
+		require.Len(t, replies, 1)
+		assert.Equal(t, "thepostid", replies[0].RootId)
+		assert.Equal(t, "thebotid", replies[0].UserId)
+		assert.Equal(t, "#### Meeting recording\n"+
+			"**Link:** https://site.webex.com/recordingservice/r1/playback\n"+
+			"**Duration:** 13m\n"+
+			"**Password:** click *Show password* to see it", replies[0].Message)
+		attachments := replies[0].Attachments()
+		require.Len(t, attachments, 1)
+		require.Len(t, attachments[0].Actions, 1)
+		assert.Equal(t, "/plugins/"+manifest.Id+routeAPIrecordingPassword, attachments[0].Actions[0].Integration.URL)
+		assert.Equal(t, map[string]interface{}{"post_id": "thepostid", "recording_id": "r1"}, attachments[0].Actions[0].Integration.Context)
+		data, err := replies[0].ToJSON()
+		require.NoError(t, err)
+		assert.NotContains(t, data, "secret")
+
+		require.NotNil(t, updated)
+		assert.Equal(t, "https://site.webex.com/recordingservice/r1/playback", updated.GetProp("recording_url"))
+		assert.Equal(t, 754, updated.GetProp("recording_duration"))
+		assert.Nil(t, updated.GetProp("recording_password"), "the password isn't shown to the whole channel")
+
+		// Webex listed no transcript along with the recording, so there won't be any.
+		assert.Empty(t, store.scheduled, "the lookup is done")
+	})
+
+	t.Run("attaches the transcripts", func(t *testing.T) {
+		p, api, store := newRecordingPlugin(t, available, `[{"id":"t1","status":"available"}]`)
+		api.On("GetChannelMember", "thechannelid", "hostid").Return(&model.ChannelMember{}, nil)
+		api.On("GetPost", "thepostid").Return(&model.Post{Id: "thepostid", Type: "custom_webex"}, nil)
+		api.On("UpdatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{}, nil)
+		var transcript []byte
+		api.On("UploadFile", mock.Anything, "thechannelid", "Webex Meeting transcript.txt").Run(func(args mock.Arguments) {
+			transcript = args.Get(0).([]byte)
+		}).Return(&model.FileInfo{Id: "thefileid"}, nil)
+		var replies []*model.Post
+		api.On("CreatePost", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+			replies = append(replies, args.Get(0).(*model.Post))
+		}).Return(&model.Post{}, nil)
+
+		p.lookupRecordings(RecordingLookup{PostID: "thepostid"})
+
+		require.Len(t, replies, 2)
+		assert.Equal(t, "thepostid", replies[1].RootId)
+		assert.Equal(t, model.StringArray{"thefileid"}, replies[1].FileIds)
+		assert.Contains(t, replies[1].Message, "#### Transcript highlights")
+		assert.Contains(t, string(transcript), "Bob Jones: The build is green.")
+		assert.Empty(t, store.scheduled, "the lookup is done")
+
+		meeting, err := store.LoadMeeting("thepostid")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t1"}, meeting.TranscriptIDs)
+	})
+
+	t.Run("downloads a transcript without captions only once", func(t *testing.T) {
+		p, api, store := newRecordingPlugin(t, `[]`, `[]`)
+		downloads := 0
+		serveWebexAPI(t, p, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/recordings":
+				_, _ = fmt.Fprint(w, `{"items":[]}`)
+			case "/meetingTranscripts":
+				_, _ = fmt.Fprint(w, `{"items":[{"id":"t2","status":"available"}]}`)
+			case "/meetingTranscripts/t2/download":
+				downloads++
+				_, _ = fmt.Fprint(w, "WEBVTT\n\n")
+			}
+		})
+
+		api.On("GetChannelMember", "thechannelid", "hostid").Return(&model.ChannelMember{}, nil)
+		api.On("GetPost", "thepostid").Return(&model.Post{Id: "thepostid", Type: "custom_webex"}, nil)
+		api.On("UpdatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{}, nil)
+		api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+		p.lookupRecordings(RecordingLookup{PostID: "thepostid"})
+		p.lookupRecordings(RecordingLookup{PostID: "thepostid", Attempt: 1})
+		assert.Equal(t, 1, downloads)
+		api.AssertNotCalled(t, "CreatePost", mock.Anything)
+
+		meeting, err := store.LoadMeeting("thepostid")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t2"}, meeting.TranscriptIDs)
+	})
+
+	t.Run("retries the transcripts that failed to be shared", func(t *testing.T) {
+		p, api, store := newRecordingPlugin(t, available, `[{"id":"t1","status":"available"}]`)
+		api.On("GetChannelMember", "thechannelid", "hostid").Return(&model.ChannelMember{}, nil)
+		api.On("GetPost", "thepostid").Return(&model.Post{Id: "thepostid", Type: "custom_webex"}, nil)
+		api.On("UpdatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{}, nil)
+		api.On("UploadFile", mock.Anything, "thechannelid", mock.Anything).Return(nil, &model.AppError{Message: "file storage is full"})
+		api.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{}, nil)
+
+		p.lookupRecordings(RecordingLookup{PostID: "thepostid"})
+
+		meeting, err := store.LoadMeeting("thepostid")
+		require.NoError(t, err)
+		assert.Empty(t, meeting.TranscriptIDs)
+		assert.Len(t, store.scheduled, 1, "the lookup goes on")
+	})
+
+	t.Run("retries with backoff until Webex has a recording", func(t *testing.T) {
+		p, api, store := newRecordingPlugin(t, `[]`, `[]`)
+		api.On("GetChannelMember", "thechannelid", "hostid").Return(&model.ChannelMember{}, nil)
+		api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything).Return()
+
+		p.lookupRecordings(RecordingLookup{PostID: "thepostid", Attempt: 1})
+		require.Len(t, store.scheduled, 1)
+		assert.Equal(t, 2, store.scheduled[0].Attempt)
+		assert.WithinDuration(t, time.Now().Add(20*time.Minute), store.scheduled[0].At, time.Minute)
+
+		p.lookupRecordings(RecordingLookup{PostID: "thepostid", Attempt: maxUnrecordedLookupAttempts - 1})
+		assert.Len(t, store.scheduled, 1, "a meeting without recordings is not looked up any longer")
+		api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("retries the transcripts until the last attempt", func(t *testing.T) {
+		p, api, store := newRecordingPlugin(t, `[]`, `[{"id":"t1","status":"available"}]`)
+		api.On("GetChannelMember", "thechannelid", "hostid").Return(&model.ChannelMember{}, nil)
+		api.On("GetPost", "thepostid").Return(&model.Post{Id: "thepostid", Type: "custom_webex"}, nil)
+		api.On("UpdatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{}, nil)
+		api.On("UploadFile", mock.Anything, "thechannelid", mock.Anything).Return(nil, &model.AppError{Message: "file storage is full"})
+
+		p.lookupRecordings(RecordingLookup{PostID: "thepostid", Attempt: maxUnrecordedLookupAttempts})
+		require.Len(t, store.scheduled, 1)
+
+		p.lookupRecordings(RecordingLookup{PostID: "thepostid", Attempt: maxRecordingLookupAttempts - 1})
+		assert.Len(t, store.scheduled, 1, "the last attempt is not retried")
+	})
+
+	t.Run("asks the host to connect again when Webex refuses the transcripts", func(t *testing.T) {
+		p, api, store := newRecordingPlugin(t, available, `[]`)
+		transcriptRequests := 0
+		serveWebexAPI(t, p, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/recordings" {
+				_, _ = fmt.Fprintf(w, `{"items":%s}`, available)
+				return
+			}
+			assert.Equal(t, "/meetingTranscripts", r.URL.Path)
+			transcriptRequests++
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = fmt.Fprint(w, `{"message":"The server understood the request, but refused to fulfill it because the access token is missing required scopes."}`)
+		})
+
+		api.On("GetChannelMember", "thechannelid", "hostid").Return(&model.ChannelMember{}, nil)
+		api.On("GetPost", "thepostid").Return(&model.Post{Id: "thepostid", Type: "custom_webex"}, nil)
+		api.On("UpdatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{}, nil)
+		api.On("GetDirectChannel", "hostid", "thebotid").Return(&model.Channel{Id: "thedmid"}, nil)
+		api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything).Return()
+		var posts []*model.Post
+		api.On("CreatePost", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+			posts = append(posts, args.Get(0).(*model.Post))
+		}).Return(&model.Post{}, nil)
+
+		p.lookupRecordings(RecordingLookup{PostID: "thepostid"})
+		require.Len(t, posts, 2)
+		assert.Equal(t, "thedmid", posts[1].ChannelId)
+		assert.Contains(t, posts[1].Message, "`/webex connect`")
+		assert.True(t, store.userInfos["hostid"].TranscriptsUnauthorized)
+		assert.Empty(t, store.scheduled, "the lookup is done")
+		api.AssertNotCalled(t, "LogError", mock.Anything)
+
+		// The host isn't asked again, nor are their transcripts listed, until they connect again.
+		store.meetings["thepostid"].Recordings = nil
+		p.lookupRecordings(RecordingLookup{PostID: "thepostid"})
+		assert.Len(t, posts, 3)
+		assert.Equal(t, 1, transcriptRequests)
+	})
+
+	t.Run("skips meetings whose host disconnected their account", func(t *testing.T) {
+		p, api, store := newRecordingPlugin(t, available, `[]`)
+		store.userInfos["hostid"] = UserInfo{}
+		api.On("GetChannelMember", "thechannelid", "hostid").Return(&model.ChannelMember{}, nil)
+		api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything).Return()
+
+		p.lookupRecordings(RecordingLookup{PostID: "thepostid"})
+		assert.Empty(t, store.scheduled)
+		api.AssertCalled(t, "LogInfo", mock.Anything, "post_id", "thepostid")
+	})
+
+	t.Run("skips meetings whose host left the channel", func(t *testing.T) {
+		p, api, store := newRecordingPlugin(t, available, `[]`)
+		api.On("GetChannelMember", "thechannelid", "hostid").Return(nil, &model.AppError{Message: "not found", StatusCode: http.StatusNotFound})
+		api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything).Return()
+
+		p.lookupRecordings(RecordingLookup{PostID: "thepostid"})
+		assert.Empty(t, store.scheduled)
+		api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+}
+
+func TestHandleRecordingPassword(t *testing.T) {
+	newRequest := func(userID string, context map[string]interface{}) *http.Request {
+		body, err := json.Marshal(model.PostActionIntegrationRequest{UserId: userID, ChannelId: "otherchannelid", Context: context})
+		require.NoError(t, err)
+		r := httptest.NewRequest(http.MethodPost, routeAPIrecordingPassword, strings.NewReader(string(body)))
+		r.Header.Set("Mattermost-User-Id", userID)
+		return r
+	}
+
+	p, api, store := newRecordingPlugin(t, `[]`, `[]`)
+	store.meetings["thepostid"].Recordings = []MeetingRecording{{ID: "r1", URL: "https://site.webex.com/r1", Password: "secret"}}
+	api.On("GetChannelMember", "thechannelid", "memberid").Return(&model.ChannelMember{}, nil)
+	api.On("GetChannelMember", "thechannelid", "outsiderid").Return(nil, &model.AppError{Message: "not found"})
+	context := map[string]interface{}{"post_id": "thepostid", "recording_id": "r1"}
+
+	t.Run("shows the password to a member", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		status, err := p.handleRecordingPassword(w, newRequest("memberid", context))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+
+		var response model.PostActionIntegrationResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(t, "The password of the recording is `secret`.", response.EphemeralText)
+	})
+
+	t.Run("refuses whoever isn't a member of the meeting's channel", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		status, err := p.handleRecordingPassword(w, newRequest("outsiderid", context))
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.NotContains(t, w.Body.String(), "secret")
+	})
+
+	t.Run("unknown recording", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		status, err := p.handleRecordingPassword(w, newRequest("memberid", map[string]interface{}{"post_id": "thepostid", "recording_id": "r2"}))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotContains(t, w.Body.String(), "secret")
+	})
+}
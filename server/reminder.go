@@ -0,0 +1,170 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	defaultReminderMinutes = 10
+	maxReminderMinutes     = 24 * 60
+
+	reminderJobKey      = "reminder_job"
+	reminderJobInterval = time.Minute
+)
+
+// Reminder is a pending reminder of a scheduled meeting. An empty UserID reminds the meeting's channel.
+type Reminder struct {
+	PostID string    `json:"post_id"`
+	UserID string    `json:"user_id,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// reminderMinutes returns the first of settings that is set, or the default.
+func reminderMinutes(settings ...*int) int {
+	for _, minutes := range settings {
+		if minutes != nil {
+			return *minutes
+		}
+	}
+	return defaultReminderMinutes
+}
+
+// parseReminderMinutes parses a reminder setting, where "off" disables reminders.
+func parseReminderMinutes(s string) (int, error) {
+	if strings.EqualFold(s, "off") {
+		return 0, nil
+	}
+	minutes, err := strconv.Atoi(s)
+	if err != nil || minutes < 0 || minutes > maxReminderMinutes {
+		return 0, fmt.Errorf("please enter a number of minutes between 0 and %d, or `off`", maxReminderMinutes)
+	}
+	return minutes, nil
+}
+
+func formatReminderMinutes(minutes int) string {
+	if minutes == 0 {
+		return "off"
+	}
+	return fmt.Sprintf("%d minutes before the meeting", minutes)
+}
+
+// scheduleReminders queues the reminders of a scheduled meeting: one for its channel, and one for each invitee.
+func (p *Plugin) scheduleReminders(meeting *Meeting) error {
+	if meeting.Status != webex.StatusScheduled || meeting.Start.IsZero() {
+		return nil
+	}
+
+	channelInfo, err := p.store.LoadChannelInfo(meeting.ChannelID)
+	if err != nil {
+		return err
+	}
+	hostInfo, _ := p.store.LoadUserInfo(meeting.HostUserID)
+
+	now := time.Now()
+	var reminders []Reminder
+	add := func(userID string, minutes int) {
+		at := meeting.Start.Add(-time.Duration(minutes) * time.Minute)
+		if minutes > 0 && at.After(now) {
+			reminders = append(reminders, Reminder{PostID: meeting.PostID, UserID: userID, At: at})
+		}
+	}
+
+	add("", reminderMinutes(channelInfo.ReminderMinutes, hostInfo.ReminderMinutes))
+	for _, userID := range meeting.InviteeUserIDs {
+		userInfo, _ := p.store.LoadUserInfo(userID)
+		add(userID, reminderMinutes(userInfo.ReminderMinutes))
+	}
+
+	if len(reminders) == 0 {
+		return nil
+	}
+	return p.store.ScheduleReminders(reminders)
+}
+
+// ensureReminderJob starts the job sending reminders, unless it is already running. Reminders only exist for
+// meetings created through the Webex REST API, so the job only runs once users can connect their accounts.
+func (p *Plugin) ensureReminderJob() {
+	p.reminderJobLock.Lock()
+	defer p.reminderJobLock.Unlock()
+
+	if p.reminderJob != nil || !p.getConfiguration().IsOAuthConfigured() {
+		return
+	}
+
+	job, err := cluster.Schedule(p.API, reminderJobKey, cluster.MakeWaitForInterval(reminderJobInterval), p.sendDueReminders)
+	if err != nil {
+		p.errorf("unable to schedule the reminder job: %v", err)
+		return
+	}
+	p.reminderJob = job
+}
+
+func (p *Plugin) stopReminderJob() {
+	p.reminderJobLock.Lock()
+	defer p.reminderJobLock.Unlock()
+
+	if p.reminderJob == nil {
+		return
+	}
+	if err := p.reminderJob.Close(); err != nil {
+		p.errorf("unable to close the reminder job: %v", err)
+	}
+	p.reminderJob = nil
+}
+
+// sendDueReminders is run on a single node of the cluster at a time.
+func (p *Plugin) sendDueReminders() {
+	reminders, err := p.store.PopDueReminders(time.Now())
+	if err != nil {
+		p.errorf("unable to load the due reminders: %v", err)
+		return
+	}
+
+	for _, reminder := range reminders {
+		p.sendReminder(reminder)
+	}
+}
+
+func (p *Plugin) sendReminder(reminder Reminder) {
+	meeting, err := p.store.LoadMeeting(reminder.PostID)
+	if err != nil {
+		p.errorf("unable to load the meeting of a reminder for post: %s, error: %v", reminder.PostID, err)
+		return
+	}
+
+	// Started, ended and cancelled meetings need no reminder.
+	if meeting.Status != webex.StatusScheduled {
+		return
+	}
+
+	when := "is starting now"
+	if minutes := int(time.Until(meeting.Start).Round(time.Minute) / time.Minute); minutes > 0 {
+		when = fmt.Sprintf("starts in %d minute(s)", minutes)
+	}
+	message := fmt.Sprintf("Reminder: **%s** %s. [Join the meeting](%s)", meeting.Title, when, meeting.JoinURL)
+
+	if reminder.UserID != "" {
+		p.dm(reminder.UserID, message)
+		return
+	}
+
+	post := &model.Post{
+		UserId:    p.botUserID,
+		ChannelId: meeting.ChannelID,
+		Message:   message,
+	}
+	if _, appErr := p.API.CreatePost(post); appErr != nil {
+		p.errorf("unable to post a reminder in channel: %s, error: %v", meeting.ChannelID, appErr)
+	}
+}
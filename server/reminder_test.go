@@ -0,0 +1,97 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReminderMinutes(t *testing.T) {
+	zero, five := 0, 5
+
+	assert.Equal(t, defaultReminderMinutes, reminderMinutes())
+	assert.Equal(t, defaultReminderMinutes, reminderMinutes(nil, nil))
+	assert.Equal(t, 5, reminderMinutes(nil, &five))
+	assert.Equal(t, 0, reminderMinutes(&zero, &five))
+}
+
+func TestParseReminderMinutes(t *testing.T) {
+	minutes, err := parseReminderMinutes("15")
+	require.NoError(t, err)
+	assert.Equal(t, 15, minutes)
+
+	minutes, err = parseReminderMinutes("OFF")
+	require.NoError(t, err)
+	assert.Equal(t, 0, minutes)
+
+	for _, input := range []string{"", "-1", "soon", "1441"} {
+		_, err = parseReminderMinutes(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestScheduleReminders(t *testing.T) {
+	api, kv := newKVAPI()
+	p := &Plugin{}
+	p.SetAPI(api)
+	p.store = NewStore(p)
+
+	setUserReminder := func(userID string, minutes int) {
+		data, err := json.Marshal(UserInfo{Email: userID + "@example.com", ReminderMinutes: &minutes})
+		require.NoError(t, err)
+		kv[prefixUserInfo+userID] = data
+	}
+	setUserReminder("hostid", 15)
+	setUserReminder("offid", 0)
+	setUserReminder("earlyid", 120)
+
+	start := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	meeting := &Meeting{
+		PostID:         "thepostid",
+		ChannelID:      "thechannelid",
+		HostUserID:     "hostid",
+		Status:         webex.StatusScheduled,
+		Start:          start,
+		InviteeUserIDs: []string{"offid", "earlyid", "defaultid"},
+	}
+
+	t.Run("the channel follows the host", func(t *testing.T) {
+		require.NoError(t, p.scheduleReminders(meeting))
+
+		reminders, err := p.store.PopDueReminders(start)
+		require.NoError(t, err)
+		assert.Equal(t, []Reminder{
+			{PostID: "thepostid", At: start.Add(-15 * time.Minute)},
+			{PostID: "thepostid", UserID: "defaultid", At: start.Add(-defaultReminderMinutes * time.Minute)},
+		}, reminders, "no reminder when disabled or already past")
+	})
+
+	t.Run("the channel's own setting", func(t *testing.T) {
+		minutes := 30
+		require.NoError(t, p.store.StoreChannelInfo("thechannelid", ChannelInfo{ReminderMinutes: &minutes}))
+		require.NoError(t, p.scheduleReminders(meeting))
+
+		reminders, err := p.store.PopDueReminders(start)
+		require.NoError(t, err)
+		require.NotEmpty(t, reminders)
+		assert.Equal(t, Reminder{PostID: "thepostid", At: start.Add(-30 * time.Minute)}, reminders[0])
+	})
+
+	t.Run("not scheduled", func(t *testing.T) {
+		started := *meeting
+		started.Status = webex.StatusStarted
+		require.NoError(t, p.scheduleReminders(&started))
+
+		reminders, err := p.store.PopDueReminders(start)
+		require.NoError(t, err)
+		assert.Empty(t, reminders)
+	})
+}
@@ -0,0 +1,400 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// roomImportTimeout bounds the Webex validation of an import.
+	roomImportTimeout = 10 * time.Minute
+
+	// roomImportConcurrency is how many rooms are validated at once.
+	roomImportConcurrency = 8
+
+	// roomImportMaxFileSize bounds the size of an imported CSV file.
+	roomImportMaxFileSize = 1024 * 1024
+
+	// roomImportSearchedPosts is how many recent posts of the channel are searched for the CSV file to import.
+	roomImportSearchedPosts = 50
+
+	// roomImportMaxReportRows bounds the rows listed in an import report, to stay under the maximum post size.
+	roomImportMaxReportRows = 100
+
+	roomExportFileName = "webex-rooms.csv"
+)
+
+var errNoRoomImportFile = errors.New("no CSV file found: please post the CSV file in this channel first, then run the command again")
+
+// roomImportRow is a line of an imported CSV file.
+type roomImportRow struct {
+	Line   int
+	User   string
+	RoomID string
+
+	// MattermostUserID is set once User is resolved, along with SiteHost, the Webex site of the user's teams that the
+	// room is validated on.
+	MattermostUserID string
+	Username         string
+	SiteHost         string
+
+	// Problem explains why the row can't be imported.
+	Problem string
+
+	// Warning explains why the row is imported without validating its room, which the import was forced to do.
+	Warning string
+}
+
+// roomImport is the outcome of importing a CSV file of room mappings.
+type roomImport struct {
+	DryRun bool
+	Rows   []*roomImportRow
+
+	// Force imports the rooms that can't be validated, those of users whose Webex site is unknown.
+	Force bool
+
+	// SiteHost is the default Webex site, reported when no room was validated.
+	SiteHost string
+
+	// StoreError is set when some of the valid rows couldn't be stored.
+	StoreError error
+}
+
+func (i *roomImport) valid() []*roomImportRow {
+	var valid []*roomImportRow
+	for _, row := range i.Rows {
+		if row.Problem == "" {
+			valid = append(valid, row)
+		}
+	}
+	return valid
+}
+
+// parseRoomImport parses a CSV file with one `<username or email>[,<username or email>...],<room id>` per line. A
+// header, whose last column is `room_id`, is skipped, so files written by the export can be imported back.
+func parseRoomImport(data []byte) ([]*roomImportRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var rows []*roomImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV file: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		roomID := strings.TrimSpace(record[len(record)-1])
+		if len(rows) == 0 && isRoomImportHeader(roomID) {
+			continue
+		}
+
+		row := &roomImportRow{Line: line, RoomID: roomID}
+		for _, field := range record[:len(record)-1] {
+			if field = strings.TrimSpace(field); field != "" {
+				row.User = field
+				break
+			}
+		}
+		switch {
+		case row.User == "":
+			row.Problem = "expected `<username or email>,<room id>`"
+		case row.RoomID == "":
+			row.Problem = "the room ID is missing"
+		case strings.ContainsAny(row.RoomID, " /"):
+			row.Problem = "the room ID can't contain spaces or slashes"
+		}
+		rows = append(rows, row)
+	}
+}
+
+func isRoomImportHeader(column string) bool {
+	switch strings.ToLower(column) {
+	case "room_id", "room id", "roomid", "room":
+		return true
+	}
+	return false
+}
+
+// importRooms sets the rooms of the users listed in data, once each room is validated on the Webex site of the user's
+// teams. With force, the rooms of users whose site is unknown are set without validation. With dryRun, nothing is
+// stored.
+func (p *Plugin) importRooms(ctx context.Context, data []byte, dryRun, force bool) (*roomImport, error) {
+	rows, err := parseRoomImport(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &roomImport{DryRun: dryRun, Force: force, Rows: rows, SiteHost: p.getConfiguration().SiteHost}
+	p.resolveRoomImportUsers(rows, force)
+	p.validateRoomImportRooms(ctx, result.valid())
+
+	if dryRun {
+		return result, nil
+	}
+
+	rooms := map[string]string{}
+	for _, row := range result.valid() {
+		rooms[row.MattermostUserID] = row.RoomID
+	}
+	result.StoreError = p.store.StoreUserRooms(rooms)
+	for mattermostUserID := range rooms {
+		p.invalidatePMRCache(mattermostUserID)
+	}
+	return result, nil
+}
+
+func (p *Plugin) resolveRoomImportUsers(rows []*roomImportRow, force bool) {
+	lines := map[string]int{}
+	for _, row := range rows {
+		if row.Problem != "" {
+			continue
+		}
+
+		var user *model.User
+		var appErr *model.AppError
+		if identifier := strings.TrimPrefix(row.User, "@"); strings.Contains(identifier, "@") {
+			user, appErr = p.API.GetUserByEmail(identifier)
+		} else {
+			user, appErr = p.API.GetUserByUsername(identifier)
+		}
+		if appErr != nil {
+			row.Problem = "no Mattermost user found"
+			continue
+		}
+
+		row.MattermostUserID = user.Id
+		row.Username = user.Username
+		if line, ok := lines[user.Id]; ok {
+			row.Problem = fmt.Sprintf("the user is already listed on line %d", line)
+			continue
+		}
+		lines[user.Id] = row.Line
+
+		// The room can't be validated without knowing which site the user would use it on.
+		siteHost, err := p.siteHostForUser(user.Id)
+		if err != nil && force {
+			row.Warning = "not validated, the Webex site of the user is unknown: " + err.Error()
+			continue
+		}
+		if err != nil {
+			row.Problem = "the Webex site of the user is unknown, import with `--force` to set the room without validation: " + err.Error()
+			continue
+		}
+		row.SiteHost = siteHost
+	}
+}
+
+// validateRoomImportRooms looks up the room of each row on its site, a few at a time.
+func (p *Plugin) validateRoomImportRooms(ctx context.Context, rows []*roomImportRow) {
+	semaphore := make(chan struct{}, roomImportConcurrency)
+	var wg sync.WaitGroup
+	for _, row := range rows {
+		if row.Warning != "" {
+			continue
+		}
+		wg.Add(1)
+		go func(row *roomImportRow) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if _, err := p.getURLFromRoomID(ctx, row.SiteHost, row.RoomID); err != nil {
+				row.Problem = "no Personal Room found on Webex: " + strings.ReplaceAll(explainLookupError(err), "\n* ", " ")
+			}
+		}(row)
+	}
+	wg.Wait()
+}
+
+// describeValidation tells how the rooms of the valid rows were validated, following the number of rows.
+func (i *roomImport) describeValidation() string {
+	unvalidated := 0
+	for _, row := range i.valid() {
+		if row.Warning != "" {
+			unvalidated++
+		}
+	}
+	switch validated := len(i.valid()) - unvalidated; {
+	case unvalidated == 0:
+		return "validated on " + i.validatedSites()
+	case validated == 0:
+		return "all without validation"
+	default:
+		return fmt.Sprintf("%d validated on %s and %d without validation", validated, i.validatedSites(), unvalidated)
+	}
+}
+
+// validatedSites names the Webex sites that the rooms of the valid rows were validated on.
+func (i *roomImport) validatedSites() string {
+	var siteHosts []string
+	seen := map[string]bool{}
+	for _, row := range i.valid() {
+		if row.SiteHost != "" && !seen[row.SiteHost] {
+			seen[row.SiteHost] = true
+			siteHosts = append(siteHosts, "`"+row.SiteHost+"`")
+		}
+	}
+	if len(siteHosts) == 0 {
+		return "`" + i.SiteHost + "`"
+	}
+	sort.Strings(siteHosts)
+	return strings.Join(siteHosts, ", ")
+}
+
+// formatRoomImport renders the report of an import as markdown.
+func formatRoomImport(result *roomImport) string {
+	valid := len(result.valid())
+	invalid := len(result.Rows) - valid
+
+	var b strings.Builder
+	if result.DryRun {
+		command := "/webex admin import"
+		if result.Force {
+			command += " --force"
+		}
+		fmt.Fprintf(&b, "#### Webex rooms import (dry run)\n%d of %d rows can be imported, %s. Nothing was changed: run `%s` to import them.\n", valid, len(result.Rows), result.describeValidation(), command)
+	} else {
+		fmt.Fprintf(&b, "#### Webex rooms import\nImported the rooms of %d users, %s, and skipped %d rows.\n", valid, result.describeValidation(), invalid)
+	}
+	if result.StoreError != nil {
+		fmt.Fprintf(&b, "**Error:** %s\n", result.StoreError.Error())
+	}
+
+	// The dry run lists every row, the import only the skipped ones and the ones with a warning.
+	var listed []*roomImportRow
+	for _, row := range result.Rows {
+		if result.DryRun || row.Problem != "" || row.Warning != "" {
+			listed = append(listed, row)
+		}
+	}
+	if len(listed) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\n| Line | User | Room ID | Status |\n| ---: | :--- | :------ | :----- |\n")
+	for i, row := range listed {
+		if i == roomImportMaxReportRows {
+			fmt.Fprintf(&b, "\n...and %d more rows.\n", len(listed)-i)
+			break
+		}
+		status := "OK"
+		switch {
+		case row.Problem != "":
+			status = row.Problem
+		case row.Warning != "":
+			status = "OK, " + row.Warning
+		}
+		user := row.User
+		if row.Username != "" && row.Username != strings.TrimPrefix(user, "@") {
+			user += " (@" + row.Username + ")"
+		}
+		fmt.Fprintf(&b, "| %d | %s | %s | %s |\n", row.Line, escapeTableCell(user), escapeTableCell(row.RoomID), escapeTableCell(status))
+	}
+	return b.String()
+}
+
+// findRoomImportFile returns the content of the latest CSV file posted by mattermostUserID in channelID.
+func (p *Plugin) findRoomImportFile(mattermostUserID, channelID string) ([]byte, error) {
+	posts, appErr := p.API.GetPostsForChannel(channelID, 0, roomImportSearchedPosts)
+	if appErr != nil {
+		return nil, fmt.Errorf("failed to load the posts of the channel: %w", appErr)
+	}
+
+	for _, postID := range posts.Order {
+		post := posts.Posts[postID]
+		if post == nil || post.UserId != mattermostUserID {
+			continue
+		}
+		for _, fileID := range post.FileIds {
+			info, appErr := p.API.GetFileInfo(fileID)
+			if appErr != nil || !strings.EqualFold(filepath.Ext(info.Name), ".csv") {
+				continue
+			}
+			if info.Size > roomImportMaxFileSize {
+				return nil, fmt.Errorf("the file `%s` is too large, the maximum is 1 MB", info.Name)
+			}
+			data, appErr := p.API.GetFile(fileID)
+			if appErr != nil {
+				return nil, fmt.Errorf("failed to read the file `%s`: %w", info.Name, appErr)
+			}
+			return data, nil
+		}
+	}
+	return nil, errNoRoomImportFile
+}
+
+// exportRooms returns a CSV file of the rooms set by users, sorted by username.
+func (p *Plugin) exportRooms() ([]byte, int, error) {
+	userInfos, err := p.store.LoadAllUserInfo()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var records [][]string
+	for _, userInfo := range userInfos {
+		if userInfo.RoomID == "" {
+			continue
+		}
+		username := ""
+		if user, appErr := p.API.GetUser(userInfo.MattermostUserID); appErr == nil {
+			username = user.Username
+		}
+		records = append(records, []string{username, userInfo.Email, userInfo.RoomID})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i][0]+records[i][1] < records[j][0]+records[j][1]
+	})
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write([]string{"username", "email", "room_id"})
+	_ = writer.WriteAll(records)
+	if err = writer.Error(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), len(records), nil
+}
+
+// sendFile sends data to mattermostUserID as a file attached to a direct message from the bot.
+func (p *Plugin) sendFile(mattermostUserID, fileName, message string, data []byte) error {
+	channel, appErr := p.API.GetDirectChannel(mattermostUserID, p.botUserID)
+	if appErr != nil {
+		return fmt.Errorf("failed to get the direct channel: %w", appErr)
+	}
+
+	info, appErr := p.API.UploadFile(data, channel.Id, fileName)
+	if appErr != nil {
+		return fmt.Errorf("failed to upload the file: %w", appErr)
+	}
+
+	post := &model.Post{
+		UserId:    p.botUserID,
+		ChannelId: channel.Id,
+		Message:   message,
+		FileIds:   []string{info.Id},
+	}
+	if _, appErr = p.API.CreatePost(post); appErr != nil {
+		return fmt.Errorf("failed to post the file: %w", appErr)
+	}
+	return nil
+}
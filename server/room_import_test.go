@@ -0,0 +1,165 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRoomImport(t *testing.T) {
+	rows, err := parseRoomImport([]byte("username,email,room_id\n" +
+		"alice,alice@example.com,alice.room\n" +
+		"# comment\n" +
+		", bob@example.com , bob.room\n" +
+		"carol\n" +
+		"dave,\n" +
+		"erin,erin room\n"))
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+
+	assert.Equal(t, &roomImportRow{Line: 2, User: "alice", RoomID: "alice.room"}, rows[0])
+	assert.Equal(t, &roomImportRow{Line: 4, User: "bob@example.com", RoomID: "bob.room"}, rows[1])
+	assert.NotEmpty(t, rows[2].Problem, "missing user")
+	assert.NotEmpty(t, rows[3].Problem, "missing room")
+	assert.NotEmpty(t, rows[4].Problem, "invalid room")
+
+	_, err = parseRoomImport([]byte("alice,\"alice.room\n"))
+	assert.Error(t, err)
+}
+
+func TestImportRooms(t *testing.T) {
+	newPlugin := func(rooms map[string]string) *Plugin {
+		api := &plugintest.API{}
+		api.On("GetUserByUsername", "alice").Return(&model.User{Id: "aliceid", Username: "alice"}, nil)
+		api.On("GetUserByEmail", "bob@example.com").Return(&model.User{Id: "bobid", Username: "bob"}, nil)
+		api.On("GetUserByUsername", "nobody").Return(nil, &model.AppError{Message: "not found"})
+		api.On("GetUser", mock.Anything).Return(&model.User{Email: "user@example.com", Username: "user"}, nil)
+
+		return newTestPlugin(api, &configuration{SiteHost: "site.webex.com"}, mockStore{rooms: rooms}, &countingClient{rooms: map[string]string{
+			"alice.room": "https://site.webex.com/meet/alice.room",
+			"bob.room":   "https://site.webex.com/meet/bob.room",
+		}})
+	}
+	data := []byte("alice,alice.room\n" +
+		"@bob@example.com,bob.room\n" +
+		"nobody,nobody.room\n" +
+		"alice,unknown.room\n")
+
+	t.Run("dry run", func(t *testing.T) {
+		rooms := map[string]string{}
+		p := newPlugin(rooms)
+
+		result, err := p.importRooms(context.Background(), data, true, false)
+		require.NoError(t, err)
+		assert.Empty(t, rooms)
+
+		require.Len(t, result.Rows, 4)
+		assert.Empty(t, result.Rows[0].Problem)
+		assert.Empty(t, result.Rows[1].Problem)
+		assert.Equal(t, "no Mattermost user found", result.Rows[2].Problem)
+		assert.Equal(t, "the user is already listed on line 1", result.Rows[3].Problem)
+
+		report := formatRoomImport(result)
+		assert.Contains(t, report, "2 of 4 rows can be imported")
+		assert.Contains(t, report, "| 2 | @bob@example.com (@bob) | bob.room | OK |")
+	})
+
+	t.Run("import", func(t *testing.T) {
+		rooms := map[string]string{}
+		p := newPlugin(rooms)
+
+		result, err := p.importRooms(context.Background(), []byte("alice,alice.room\nbob@example.com,unknown.room\n"), false, false)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"aliceid": "alice.room"}, rooms)
+		assert.Contains(t, result.Rows[1].Problem, "no Personal Room found on Webex")
+
+		report := formatRoomImport(result)
+		assert.Contains(t, report, "Imported the rooms of 1 users")
+		assert.NotContains(t, report, "alice.room", "only the skipped rows are listed")
+	})
+}
+
+func TestImportRoomsWithTeamSites(t *testing.T) {
+	salesTeamID := model.NewId()
+	supportTeamID := model.NewId()
+
+	api := &plugintest.API{}
+	api.On("GetUserByUsername", "alice").Return(&model.User{Id: "aliceid", Username: "alice"}, nil)
+	api.On("GetUserByUsername", "bob").Return(&model.User{Id: "bobid", Username: "bob"}, nil)
+	api.On("GetUserByUsername", "carol").Return(&model.User{Id: "carolid", Username: "carol"}, nil)
+	api.On("GetUserByUsername", "dave").Return(&model.User{Id: "daveid", Username: "dave"}, nil)
+	api.On("GetTeamsForUser", "aliceid").Return([]*model.Team{{Id: salesTeamID}}, nil)
+	api.On("GetTeamsForUser", "bobid").Return([]*model.Team{}, nil)
+	api.On("GetTeamsForUser", "carolid").Return([]*model.Team{{Id: salesTeamID}, {Id: supportTeamID}}, nil)
+	api.On("GetTeamsForUser", "daveid").Return(nil, &model.AppError{Message: "unavailable"})
+	api.On("GetUser", mock.Anything).Return(&model.User{Email: "user@example.com", Username: "user"}, nil)
+
+	p := newTestPlugin(api, &configuration{
+		SiteHost:      "default.webex.com",
+		teamSiteHosts: map[string]string{salesTeamID: "sales.webex.com", supportTeamID: "support.webex.com"},
+	}, mockStore{rooms: map[string]string{}}, nil)
+	p.setWebexClients(map[string]webex.Client{
+		"default.webex.com": &countingClient{rooms: map[string]string{"bob.room": "https://default.webex.com/meet/bob.room"}},
+		"sales.webex.com":   &countingClient{rooms: map[string]string{"alice.room": "https://sales.webex.com/meet/alice.room"}},
+	})
+
+	data := []byte("alice,alice.room\nbob,bob.room\ncarol,carol.room\ndave,dave.room\n")
+	result, err := p.importRooms(context.Background(), data, true, false)
+	require.NoError(t, err)
+	require.Len(t, result.Rows, 4)
+
+	assert.Equal(t, "sales.webex.com", result.Rows[0].SiteHost)
+	assert.Empty(t, result.Rows[0].Problem)
+	assert.Equal(t, "default.webex.com", result.Rows[1].SiteHost, "a user in no team uses the default site")
+	assert.Empty(t, result.Rows[1].Problem)
+	for _, row := range result.Rows[2:] {
+		assert.Contains(t, row.Problem, "the Webex site of the user is unknown", "a room whose site is unknown is refused")
+	}
+
+	report := formatRoomImport(result)
+	assert.Contains(t, report, "2 of 4 rows can be imported, validated on `default.webex.com`, `sales.webex.com`.")
+	assert.Contains(t, report, "| 3 | carol | carol.room | the Webex site of the user is unknown, import with `--force`")
+
+	// Forced, the rooms whose site is unknown are imported without validation, and reported as such.
+	result, err = p.importRooms(context.Background(), data, true, true)
+	require.NoError(t, err)
+	for _, row := range result.Rows[2:] {
+		assert.Empty(t, row.Problem)
+		assert.Contains(t, row.Warning, "not validated")
+	}
+
+	report = formatRoomImport(result)
+	assert.Contains(t, report, "4 of 4 rows can be imported, 2 validated on `default.webex.com`, `sales.webex.com` and 2 without validation. Nothing was changed: run `/webex admin import --force` to import them.")
+	assert.Contains(t, report, "| 3 | carol | carol.room | OK, not validated")
+}
+
+func TestExportRooms(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("GetUser", "aliceid").Return(&model.User{Id: "aliceid", Username: "alice"}, nil)
+
+	p := newTestPlugin(api, nil, mockStore{userInfo: UserInfo{MattermostUserID: "aliceid", Email: "alice@example.com", RoomID: "alice.room"}}, nil)
+
+	data, count, err := p.exportRooms()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"username", "email", "room_id"}, {"alice", "alice@example.com", "alice.room"}}, records)
+
+	rows, err := parseRoomImport(data)
+	require.NoError(t, err)
+	assert.Equal(t, []*roomImportRow{{Line: 2, User: "alice", RoomID: "alice.room"}}, rows)
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	scheduleTimeFormat = "Mon Jan 2, 2006 at 3:04 PM MST"
+
+	maxMeetingDuration = 24 * time.Hour
+)
+
+var (
+	timeOfDayRegexp = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+	weekdays        = map[string]time.Weekday{
+		"sun": time.Sunday, "sunday": time.Sunday,
+		"mon": time.Monday, "monday": time.Monday,
+		"tue": time.Tuesday, "tuesday": time.Tuesday,
+		"wed": time.Wednesday, "wednesday": time.Wednesday,
+		"thu": time.Thursday, "thursday": time.Thursday,
+		"fri": time.Friday, "friday": time.Friday,
+		"sat": time.Saturday, "saturday": time.Saturday,
+	}
+)
+
+// parseMeetingTime parses a meeting start time from the beginning of args, relative to now and in now's location.
+// It returns the start time and the number of args consumed. Supported forms are:
+//
+//	now
+//	in 30m, in 1h30m
+//	9am, 9:30pm, 14:00 (today, or tomorrow when that time has passed)
+//	today 9am, tomorrow 14:00, monday 10am, 2026-10-20 9:30am
+//	2026-10-20T15:04, 2026-10-20T15:04:05Z07:00
+func parseMeetingTime(args []string, now time.Time) (time.Time, int, error) {
+	if len(args) == 0 {
+		return time.Time{}, 0, fmt.Errorf("please specify when the meeting starts")
+	}
+
+	first := strings.ToLower(args[0])
+	switch first {
+	case "now":
+		return now, 1, nil
+	case "in":
+		if len(args) < 2 {
+			return time.Time{}, 0, fmt.Errorf("please specify a duration after `in`, for example `in 30m`")
+		}
+		d, err := parseMeetingDuration(args[1])
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+		return now.Add(d), 2, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, args[0], now.Location()); err == nil {
+			return t, 1, nil
+		}
+	}
+
+	if hour, minute, ok := parseTimeOfDay(first); ok {
+		t := atTimeOfDay(now, hour, minute)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, 1, nil
+	}
+
+	day, ok := parseDay(first, now)
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("could not understand when the meeting starts: `%s`", args[0])
+	}
+	if len(args) < 2 {
+		return time.Time{}, 0, fmt.Errorf("please specify a time after `%s`, for example `%s 9:30am`", args[0], args[0])
+	}
+	hour, minute, ok := parseTimeOfDay(strings.ToLower(args[1]))
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("could not understand the time `%s`", args[1])
+	}
+	return atTimeOfDay(day, hour, minute), 2, nil
+}
+
+// parseMeetingDuration accepts Go durations such as 30m or 1h30m, or a bare number of minutes.
+func parseMeetingDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		minutes, convErr := strconv.Atoi(s)
+		if convErr != nil {
+			return 0, fmt.Errorf("could not understand the duration `%s`, use for example `30m` or `1h30m`", s)
+		}
+		d = time.Duration(minutes) * time.Minute
+	}
+	if d <= 0 || d > maxMeetingDuration {
+		return 0, fmt.Errorf("the duration `%s` must be between 1 minute and 24 hours", s)
+	}
+	return d, nil
+}
+
+func parseTimeOfDay(s string) (int, int, bool) {
+	matches := timeOfDayRegexp.FindStringSubmatch(s)
+	if matches == nil {
+		return 0, 0, false
+	}
+
+	hour, _ := strconv.Atoi(matches[1])
+	minute := 0
+	if matches[2] != "" {
+		minute, _ = strconv.Atoi(matches[2])
+	}
+
+	switch matches[3] {
+	case "":
+		// A bare number is too ambiguous to be a time, e.g. it could be a duration in minutes.
+		if matches[2] == "" {
+			return 0, 0, false
+		}
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if matches[3] == "pm" {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+func parseDay(s string, now time.Time) (time.Time, bool) {
+	switch s {
+	case "today":
+		return now, true
+	case "tomorrow":
+		return now.AddDate(0, 0, 1), true
+	}
+
+	if weekday, ok := weekdays[s]; ok {
+		days := (int(weekday) - int(now.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return now.AddDate(0, 0, days), true
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func atTimeOfDay(day time.Time, hour, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+}
+
+// getUserLocation returns the location of mattermostUserID's preferred timezone, defaulting to UTC.
+func (p *Plugin) getUserLocation(mattermostUserID string) *time.Location {
+	user, appErr := p.API.GetUser(mattermostUserID)
+	if appErr != nil {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(user.GetPreferredTimezone())
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
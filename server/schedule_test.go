@@ -0,0 +1,75 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMeetingTime(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// A Wednesday afternoon.
+	now := time.Date(2026, 10, 14, 13, 45, 0, 0, loc)
+
+	for _, tc := range []struct {
+		Input            string
+		Expected         time.Time
+		ExpectedConsumed int
+		ExpectError      bool
+	}{
+		{Input: "now 30m", Expected: now, ExpectedConsumed: 1},
+		{Input: "in 1h30m 30m", Expected: now.Add(90 * time.Minute), ExpectedConsumed: 2},
+		{Input: "in 45 30m", Expected: now.Add(45 * time.Minute), ExpectedConsumed: 2},
+		{Input: "3pm 30m", Expected: time.Date(2026, 10, 14, 15, 0, 0, 0, loc), ExpectedConsumed: 1},
+		{Input: "9:30am 30m", Expected: time.Date(2026, 10, 15, 9, 30, 0, 0, loc), ExpectedConsumed: 1},
+		{Input: "14:00 30m", Expected: time.Date(2026, 10, 14, 14, 0, 0, 0, loc), ExpectedConsumed: 1},
+		{Input: "12am 30m", Expected: time.Date(2026, 10, 15, 0, 0, 0, 0, loc), ExpectedConsumed: 1},
+		{Input: "today 5PM 30m", Expected: time.Date(2026, 10, 14, 17, 0, 0, 0, loc), ExpectedConsumed: 2},
+		{Input: "tomorrow 9:30am 15m", Expected: time.Date(2026, 10, 15, 9, 30, 0, 0, loc), ExpectedConsumed: 2},
+		{Input: "monday 10am 1h", Expected: time.Date(2026, 10, 19, 10, 0, 0, 0, loc), ExpectedConsumed: 2},
+		{Input: "wed 10am 1h", Expected: time.Date(2026, 10, 21, 10, 0, 0, 0, loc), ExpectedConsumed: 2},
+		{Input: "2026-11-02 12:15pm 1h", Expected: time.Date(2026, 11, 2, 12, 15, 0, 0, loc), ExpectedConsumed: 2},
+		{Input: "2026-11-02T08:00 1h", Expected: time.Date(2026, 11, 2, 8, 0, 0, 0, loc), ExpectedConsumed: 1},
+		{Input: "2026-11-02T08:00:00Z 1h", Expected: time.Date(2026, 11, 2, 8, 0, 0, 0, time.UTC), ExpectedConsumed: 1},
+		{Input: "", ExpectError: true},
+		{Input: "in", ExpectError: true},
+		{Input: "9 30m", ExpectError: true},
+		{Input: "tomorrow", ExpectError: true},
+		{Input: "tomorrow 25:00", ExpectError: true},
+		{Input: "someday 9am", ExpectError: true},
+	} {
+		t.Run(tc.Input, func(t *testing.T) {
+			start, consumed, err := parseMeetingTime(strings.Fields(tc.Input), now)
+			if tc.ExpectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tc.Expected.Equal(start), "expected %v, got %v", tc.Expected, start)
+			assert.Equal(t, tc.ExpectedConsumed, consumed)
+		})
+	}
+}
+
+func TestParseMeetingDuration(t *testing.T) {
+	d, err := parseMeetingDuration("1h30m")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+
+	d, err = parseMeetingDuration("45")
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Minute, d)
+
+	for _, input := range []string{"", "0", "-5m", "25h", "soon"} {
+		_, err = parseMeetingDuration(input)
+		assert.Error(t, err, input)
+	}
+}
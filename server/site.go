@@ -0,0 +1,148 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// parseTeamSites parses the TeamSites setting, one `<team id> = <site host>[, <site name>]` per line, into the site
+// hosts by team ID and the site names given explicitly by site host. Blank lines and lines starting with # are ignored.
+func parseTeamSites(teamSites string) (map[string]string, map[string]string, error) {
+	siteHosts := map[string]string{}
+	siteNames := map[string]string{}
+	for i, line := range strings.Split(teamSites, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		teamID, site, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, nil, fmt.Errorf("line %d: expected `<team id> = <site host>`", i+1)
+		}
+		teamID = strings.TrimSpace(teamID)
+		siteHost, siteName, _ := strings.Cut(site, ",")
+		siteHost = parseHostFromURL(strings.TrimSpace(siteHost))
+		siteName = strings.TrimSpace(siteName)
+
+		if !model.IsValidId(teamID) {
+			return nil, nil, fmt.Errorf("line %d: `%s` is not a valid team ID", i+1, teamID)
+		}
+		if siteHost == "" {
+			return nil, nil, fmt.Errorf("line %d: the Webex site hostname is missing", i+1)
+		}
+		if _, ok := siteHosts[teamID]; ok {
+			return nil, nil, fmt.Errorf("line %d: the team `%s` is mapped more than once", i+1, teamID)
+		}
+		if siteName != "" {
+			if previous, ok := siteNames[siteHost]; ok && previous != siteName {
+				return nil, nil, fmt.Errorf("line %d: the site `%s` is named both `%s` and `%s`", i+1, siteHost, previous, siteName)
+			}
+			siteNames[siteHost] = siteName
+		}
+		siteHosts[teamID] = siteHost
+	}
+	return siteHosts, siteNames, nil
+}
+
+// siteHostForTeam returns the Webex site of teamID, or the default site.
+func (c *configuration) siteHostForTeam(teamID string) string {
+	if siteHost, ok := c.teamSiteHosts[teamID]; ok {
+		return siteHost
+	}
+	return c.SiteHost
+}
+
+// siteHosts returns every configured Webex site, the default first.
+func (c *configuration) siteHosts() []string {
+	var siteHosts, teamSiteHosts []string
+	if c.SiteHost != "" {
+		siteHosts = append(siteHosts, c.SiteHost)
+	}
+	seen := map[string]bool{c.SiteHost: true}
+	for _, siteHost := range c.teamSiteHosts {
+		if !seen[siteHost] {
+			seen[siteHost] = true
+			teamSiteHosts = append(teamSiteHosts, siteHost)
+		}
+	}
+	sort.Strings(teamSiteHosts)
+	return append(siteHosts, teamSiteHosts...)
+}
+
+// siteHostForChannel returns the Webex site used by the meetings of channelID, according to its team. Direct and
+// group messages, which have no team, use the default site.
+func (p *Plugin) siteHostForChannel(channelID string) string {
+	config := p.getConfiguration()
+	if len(config.teamSiteHosts) == 0 {
+		return config.SiteHost
+	}
+
+	channel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		p.errorf("error getting channel: %s, using the default Webex site, error: %v", channelID, appErr)
+		return config.SiteHost
+	}
+	return config.siteHostForTeam(channel.TeamId)
+}
+
+// siteHostForUser returns the Webex site of the teams of mattermostUserID, or the default site when they are in none.
+// It fails when their teams use different sites.
+func (p *Plugin) siteHostForUser(mattermostUserID string) (string, error) {
+	config := p.getConfiguration()
+	if len(config.teamSiteHosts) == 0 {
+		return config.SiteHost, nil
+	}
+
+	teams, appErr := p.API.GetTeamsForUser(mattermostUserID)
+	if appErr != nil {
+		return "", appErr
+	}
+	siteHost := config.SiteHost
+	for i, team := range teams {
+		teamSiteHost := config.siteHostForTeam(team.Id)
+		if i > 0 && teamSiteHost != siteHost {
+			return "", fmt.Errorf("the teams of the user use different Webex sites: `%s` and `%s`", siteHost, teamSiteHost)
+		}
+		siteHost = teamSiteHost
+	}
+	return siteHost, nil
+}
+
+// newWebexClients returns one client for each site of c, by site host.
+func (p *Plugin) newWebexClients(c *configuration) map[string]webex.Client {
+	clients := map[string]webex.Client{}
+	for _, siteHost := range c.siteHosts() {
+		client := webex.NewClient(siteHost, c.siteNameForHost(siteHost), c.webexClientOptions())
+		clients[siteHost] = p.newCachingClient(client, siteHost, time.Duration(c.PersonalRoomCacheMinutes)*time.Minute)
+	}
+	return clients
+}
+
+// getWebexClient returns the client of siteHost.
+func (p *Plugin) getWebexClient(siteHost string) (webex.Client, error) {
+	p.webexClientsLock.RLock()
+	defer p.webexClientsLock.RUnlock()
+
+	client, ok := p.webexClients[siteHost]
+	if !ok {
+		return nil, fmt.Errorf("the Webex site `%s` is not configured", siteHost)
+	}
+	return client, nil
+}
+
+func (p *Plugin) setWebexClients(clients map[string]webex.Client) {
+	p.webexClientsLock.Lock()
+	defer p.webexClientsLock.Unlock()
+
+	p.webexClients = clients
+}
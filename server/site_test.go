@@ -0,0 +1,73 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTeamSites(t *testing.T) {
+	salesTeamID := model.NewId()
+	supportTeamID := model.NewId()
+
+	govTeamID := model.NewId()
+
+	siteHosts, siteNames, err := parseTeamSites("\n# Business units\n" +
+		salesTeamID + " = sales.webex.com\n" +
+		"  " + supportTeamID + "=https://support.my.webex.com/  \n" +
+		govTeamID + " = meet.agency.example.gov, agency\n")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		salesTeamID:   "sales.webex.com",
+		supportTeamID: "support.my.webex.com",
+		govTeamID:     "meet.agency.example.gov",
+	}, siteHosts)
+	assert.Equal(t, map[string]string{"meet.agency.example.gov": "agency"}, siteNames)
+
+	for name, teamSites := range map[string]string{
+		"missing separator": salesTeamID + " sales.webex.com",
+		"invalid team ID":   "sales = sales.webex.com",
+		"missing site":      salesTeamID + " = ",
+		"duplicate team":    salesTeamID + " = sales.webex.com\n" + salesTeamID + " = other.webex.com",
+		"conflicting names": salesTeamID + " = meet.example.gov, a\n" + supportTeamID + " = meet.example.gov, b",
+	} {
+		_, _, err = parseTeamSites(teamSites)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestSiteHostForChannel(t *testing.T) {
+	salesTeamID := model.NewId()
+
+	api := &plugintest.API{}
+	api.On("GetChannel", "saleschannel").Return(&model.Channel{Id: "saleschannel", TeamId: salesTeamID}, nil)
+	api.On("GetChannel", "otherchannel").Return(&model.Channel{Id: "otherchannel", TeamId: model.NewId()}, nil)
+	api.On("GetChannel", "dmchannel").Return(&model.Channel{Id: "dmchannel", Type: model.ChannelTypeDirect}, nil)
+
+	p := Plugin{}
+	p.SetAPI(api)
+	p.setConfiguration(&configuration{
+		SiteHost:      "default.webex.com",
+		teamSiteHosts: map[string]string{salesTeamID: "sales.webex.com"},
+	})
+
+	assert.Equal(t, "sales.webex.com", p.siteHostForChannel("saleschannel"))
+	assert.Equal(t, "default.webex.com", p.siteHostForChannel("otherchannel"))
+	assert.Equal(t, "default.webex.com", p.siteHostForChannel("dmchannel"))
+	assert.Equal(t, []string{"default.webex.com", "sales.webex.com"}, p.getConfiguration().siteHosts())
+
+	clients := p.newWebexClients(p.getConfiguration())
+	assert.Len(t, clients, 2)
+	p.setWebexClients(clients)
+
+	_, err := p.getWebexClient("sales.webex.com")
+	assert.NoError(t, err)
+	_, err = p.getWebexClient("unknown.webex.com")
+	assert.Error(t, err)
+}
@@ -4,17 +4,57 @@ import (
 	"crypto/md5" //nolint:gosec // md5 is used for user-hash generation and not encryption
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/pkg/errors"
 )
 
 const (
-	prefixUserInfo = "user_info_"
+	// prefixUserInfo is followed by the plain Mattermost user ID, so the users can be listed.
+	prefixUserInfo = "user_v2_"
+
+	// prefixHashedUserInfo is followed by the md5 of the Mattermost user ID, before schema version 2.
+	prefixHashedUserInfo = "user_info_"
+
+	prefixOAuthState  = "oauth_state_"
+	prefixChannelInfo = "channel_info_"
+
+	oauthStateTTLSeconds = 5 * 60
+
+	maxAtomicUpdateAttempts = 5
+
+	kvListPerPage = 1000
 )
 
 type Store interface {
-	StoreUserInfo(mattermostUserID string, info UserInfo) error
+	Migrate() error
+	UpdateUserInfo(mattermostUserID string, update func(info *UserInfo) error) error
 	LoadUserInfo(mattermostUserID string) (UserInfo, error)
+	DeleteUserInfo(mattermostUserID string) error
+	StoreUserRooms(rooms map[string]string) error
+	LoadAllUserInfo() ([]UserInfo, error)
+	StoreOAuthState(mattermostUserID, state string) error
+	VerifyOAuthState(mattermostUserID, state string) error
+	StoreMeeting(meeting *Meeting) error
+	LoadMeeting(postID string) (*Meeting, error)
+	LoadMeetingsByChannel(channelID string) ([]*Meeting, error)
+	LoadMeetingsByUser(mattermostUserID string) ([]*Meeting, error)
+	LoadMeetingByWebexID(webexMeetingID string) (*Meeting, error)
+	LoadMeetingByRoomURL(roomURL string) (*Meeting, error)
+	DeleteMeeting(postID string) error
+	StoreChannelInfo(channelID string, info ChannelInfo) error
+	LoadChannelInfo(channelID string) (ChannelInfo, error)
+	ScheduleReminders(reminders []Reminder) error
+	CancelReminders(postID string) error
+	PopDueReminders(now time.Time) ([]Reminder, error)
+	ScheduleRecordingLookup(lookup RecordingLookup) error
+	PopDueRecordingLookups(now time.Time) ([]RecordingLookup, error)
+	StorePMRCacheEntry(key string, entry PMRCacheEntry, ttl time.Duration) error
+	LoadPMRCacheEntry(key string) (PMRCacheEntry, error)
+	DeletePMRCacheEntry(key string) error
 }
 
 type store struct {
@@ -32,6 +72,7 @@ func hashkey(prefix, key string) string {
 }
 
 var ErrUserNotFound = errors.New("user not found")
+var ErrInvalidOAuthState = errors.New("invalid oauth state, please try again")
 
 func (store store) get(key string, v interface{}) error {
 	data, appErr := store.plugin.API.KVGet(key)
@@ -64,23 +105,80 @@ func (store store) set(key string, v interface{}) error {
 	return nil
 }
 
-func (store store) StoreUserInfo(mattermostUserID string, info UserInfo) error {
-	// Set the email because we need a field in the userInfo that cannot be blank (in order to tell if a user was found)
+// atomicUpdate replaces the value stored at key with the result of update, retrying when another update raced
+// with this one. data is nil when the key does not exist.
+func (store store) atomicUpdate(key string, update func(data []byte) ([]byte, error)) error {
+	for i := 0; i < maxAtomicUpdateAttempts; i++ {
+		data, appErr := store.plugin.API.KVGet(key)
+		if appErr != nil {
+			return appErr
+		}
+
+		newData, err := update(data)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := store.plugin.API.KVSetWithOptions(key, newData, model.PluginKVSetOptions{
+			Atomic:   true,
+			OldValue: data,
+		})
+		if appErr != nil {
+			return appErr
+		}
+		if ok {
+			return nil
+		}
+	}
+	return errors.Errorf("failed to update %s: too many concurrent updates", key)
+}
+
+// UpdateUserInfo atomically applies update to the settings of mattermostUserID, which are empty when none were stored,
+// so that concurrent updates, such as a token refresh and a new connection, don't overwrite each other. update may be
+// called again when another update raced with it.
+func (store store) UpdateUserInfo(mattermostUserID string, update func(info *UserInfo) error) error {
 	email, _, err := store.plugin.getEmailAndUserName(mattermostUserID)
 	if err != nil {
 		return err
 	}
-	info.Email = email
-	err = store.set(hashkey(prefixUserInfo, mattermostUserID), info)
+
+	err = store.atomicUpdate(prefixUserInfo+mattermostUserID, func(data []byte) ([]byte, error) {
+		var info UserInfo
+		var loadErr error
+		if data != nil {
+			loadErr = json.Unmarshal(data, &info)
+		} else if loadErr = store.get(hashkey(prefixHashedUserInfo, mattermostUserID), &info); loadErr == ErrUserNotFound {
+			loadErr = nil
+		}
+		if loadErr != nil {
+			return nil, loadErr
+		}
+
+		if updateErr := update(&info); updateErr != nil {
+			return nil, updateErr
+		}
+		// Set the email because we need a field in the userInfo that cannot be blank (in order to tell if a user was found)
+		info.Email = email
+		info.MattermostUserID = mattermostUserID
+		// Keep the version of a record written by a newer version of the plugin, whose unknown fields are kept as well.
+		info.Version = max(info.Version, userInfoVersion)
+		return json.Marshal(info)
+	})
 	if err != nil {
-		return errors.WithMessage(err, fmt.Sprintf("failed to store UserInfo for: %s", mattermostUserID))
+		return errors.WithMessage(err, fmt.Sprintf("failed to update UserInfo for: %s", mattermostUserID))
 	}
 	return nil
 }
 
+// LoadUserInfo returns the settings of mattermostUserID. Until the store is migrated to schema version 2, their
+// settings may only be found under the hashed key.
 func (store store) LoadUserInfo(mattermostUserID string) (UserInfo, error) {
 	userInfo := UserInfo{}
-	err := store.get(hashkey(prefixUserInfo, mattermostUserID), &userInfo)
+	err := store.get(prefixUserInfo+mattermostUserID, &userInfo)
+	if err == ErrUserNotFound {
+		err = store.get(hashkey(prefixHashedUserInfo, mattermostUserID), &userInfo)
+		userInfo.MattermostUserID = mattermostUserID
+	}
 	if err != nil && err == ErrUserNotFound {
 		return UserInfo{}, err
 	}
@@ -94,3 +192,121 @@ func (store store) LoadUserInfo(mattermostUserID string) (UserInfo, error) {
 	}
 	return userInfo, nil
 }
+
+// DeleteUserInfo removes every setting of mattermostUserID, including their Webex token, along with the settings kept
+// under the hashed key.
+func (store store) DeleteUserInfo(mattermostUserID string) error {
+	for _, key := range []string{prefixUserInfo + mattermostUserID, hashkey(prefixHashedUserInfo, mattermostUserID)} {
+		if appErr := store.plugin.API.KVDelete(key); appErr != nil {
+			return errors.WithMessage(appErr, fmt.Sprintf("failed to delete UserInfo for: %s", mattermostUserID))
+		}
+	}
+	return nil
+}
+
+// StoreUserRooms sets the room of each Mattermost user in rooms, keeping the rest of their settings. It stores every
+// room it can, and returns an error listing the users whose room couldn't be stored.
+func (store store) StoreUserRooms(rooms map[string]string) error {
+	var failed []string
+	for mattermostUserID, roomID := range rooms {
+		err := store.UpdateUserInfo(mattermostUserID, func(info *UserInfo) error {
+			info.RoomID = roomID
+			return nil
+		})
+		if err != nil {
+			failed = append(failed, mattermostUserID)
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return errors.Errorf("failed to store the room of %d users: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// LoadAllUserInfo returns the settings of every user who has any.
+func (store store) LoadAllUserInfo() ([]UserInfo, error) {
+	keys, err := store.listKeys(prefixUserInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []UserInfo
+	for _, key := range keys {
+		var userInfo UserInfo
+		if err = store.get(key, &userInfo); err != nil {
+			if err == ErrUserNotFound {
+				continue
+			}
+			return nil, errors.WithMessage(err, fmt.Sprintf("failed to load userInfo: %s", key))
+		}
+		if userInfo.Email != "" {
+			userInfo.MattermostUserID = strings.TrimPrefix(key, prefixUserInfo)
+			all = append(all, userInfo)
+		}
+	}
+	return all, nil
+}
+
+// listKeys returns the stored keys starting with prefix.
+func (store store) listKeys(prefix string) ([]string, error) {
+	var keys []string
+	for page := 0; ; page++ {
+		pageKeys, appErr := store.plugin.API.KVList(page, kvListPerPage)
+		if appErr != nil {
+			return nil, errors.WithMessage(appErr, "failed to list the stored keys")
+		}
+
+		for _, key := range pageKeys {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+
+		if len(pageKeys) < kvListPerPage {
+			return keys, nil
+		}
+	}
+}
+
+func (store store) StoreOAuthState(mattermostUserID, state string) error {
+	appErr := store.plugin.API.KVSetWithExpiry(hashkey(prefixOAuthState, mattermostUserID), []byte(state), oauthStateTTLSeconds)
+	if appErr != nil {
+		return errors.WithMessage(appErr, fmt.Sprintf("failed to store oauth state for: %s", mattermostUserID))
+	}
+	return nil
+}
+
+func (store store) VerifyOAuthState(mattermostUserID, state string) error {
+	key := hashkey(prefixOAuthState, mattermostUserID)
+	data, appErr := store.plugin.API.KVGet(key)
+	if appErr != nil {
+		return errors.WithMessage(appErr, fmt.Sprintf("failed to load oauth state for: %s", mattermostUserID))
+	}
+
+	// The state can only be used once.
+	_ = store.plugin.API.KVDelete(key)
+
+	if data == nil || state == "" || string(data) != state {
+		return ErrInvalidOAuthState
+	}
+	return nil
+}
+
+func (store store) StoreChannelInfo(channelID string, info ChannelInfo) error {
+	err := store.set(hashkey(prefixChannelInfo, channelID), info)
+	if err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("failed to store ChannelInfo for: %s", channelID))
+	}
+	return nil
+}
+
+// LoadChannelInfo returns the settings of channelID, which are empty when none were stored.
+func (store store) LoadChannelInfo(channelID string) (ChannelInfo, error) {
+	channelInfo := ChannelInfo{}
+	err := store.get(hashkey(prefixChannelInfo, channelID), &channelInfo)
+	if err != nil && err != ErrUserNotFound {
+		return ChannelInfo{}, errors.WithMessage(err, fmt.Sprintf("failed to load ChannelInfo for: %s", channelID))
+	}
+	return channelInfo, nil
+}
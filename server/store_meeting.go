@@ -0,0 +1,235 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	prefixMeeting           = "meeting_"
+	prefixMeetingsByChannel = "meetings_by_channel_"
+	prefixMeetingsByUser    = "meetings_by_user_"
+	prefixMeetingByWebexID  = "meeting_by_webex_id_"
+	prefixMeetingByRoomURL  = "meeting_by_room_url_"
+
+	// maxIndexSize bounds the number of meetings remembered per channel and per user, oldest first out.
+	maxIndexSize = 100
+)
+
+var ErrMeetingNotFound = errors.New("meeting not found")
+
+// StoreMeeting creates or updates meeting, and indexes it by channel, by host and by Webex meeting ID. A Webex meeting
+// shared again keeps pointing to its first post. A Personal Room meeting is indexed by room URL until Webex reports its
+// meeting instance, so its events can be matched to it.
+func (store store) StoreMeeting(meeting *Meeting) error {
+	err := store.set(hashkey(prefixMeeting, meeting.PostID), meeting)
+	if err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("failed to store meeting for post: %s", meeting.PostID))
+	}
+
+	for _, webexMeetingID := range []string{meeting.WebexMeetingID, meeting.WebexInstanceID} {
+		if webexMeetingID == "" {
+			continue
+		}
+		_, appErr := store.plugin.API.KVSetWithOptions(hashkey(prefixMeetingByWebexID, webexMeetingID), []byte(meeting.PostID),
+			model.PluginKVSetOptions{Atomic: true, OldValue: nil})
+		if appErr != nil {
+			return errors.WithMessage(appErr, fmt.Sprintf("failed to index meeting for Webex meeting: %s", webexMeetingID))
+		}
+	}
+
+	if meeting.isUnmatchedPersonalRoomMeeting() {
+		appErr := store.plugin.API.KVSet(roomURLKey(meeting.RoomURL), []byte(meeting.PostID))
+		if appErr != nil {
+			return errors.WithMessage(appErr, fmt.Sprintf("failed to index meeting for room: %s", meeting.RoomURL))
+		}
+	}
+
+	add := func(postIDs []string) []string {
+		for _, postID := range postIDs {
+			if postID == meeting.PostID {
+				return postIDs
+			}
+		}
+		postIDs = append(postIDs, meeting.PostID)
+		if len(postIDs) > maxIndexSize {
+			postIDs = postIDs[len(postIDs)-maxIndexSize:]
+		}
+		return postIDs
+	}
+
+	if err = store.updateIndex(hashkey(prefixMeetingsByChannel, meeting.ChannelID), add); err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("failed to index meeting for channel: %s", meeting.ChannelID))
+	}
+	if meeting.HostUserID == "" {
+		return nil
+	}
+	if err = store.updateIndex(hashkey(prefixMeetingsByUser, meeting.HostUserID), add); err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("failed to index meeting for user: %s", meeting.HostUserID))
+	}
+	return nil
+}
+
+func (store store) LoadMeeting(postID string) (*Meeting, error) {
+	meeting := &Meeting{}
+	err := store.get(hashkey(prefixMeeting, postID), meeting)
+	if err == ErrUserNotFound {
+		return nil, ErrMeetingNotFound
+	}
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to load meeting for post: %s", postID))
+	}
+	return meeting, nil
+}
+
+// LoadMeetingsByChannel returns the meetings shared in channelID, oldest first.
+func (store store) LoadMeetingsByChannel(channelID string) ([]*Meeting, error) {
+	return store.loadIndexedMeetings(hashkey(prefixMeetingsByChannel, channelID))
+}
+
+// LoadMeetingsByUser returns the meetings hosted by mattermostUserID, oldest first.
+func (store store) LoadMeetingsByUser(mattermostUserID string) ([]*Meeting, error) {
+	return store.loadIndexedMeetings(hashkey(prefixMeetingsByUser, mattermostUserID))
+}
+
+func (store store) LoadMeetingByWebexID(webexMeetingID string) (*Meeting, error) {
+	data, appErr := store.plugin.API.KVGet(hashkey(prefixMeetingByWebexID, webexMeetingID))
+	if appErr != nil {
+		return nil, errors.WithMessage(appErr, fmt.Sprintf("failed to load meeting for Webex meeting: %s", webexMeetingID))
+	}
+	if data == nil {
+		return nil, ErrMeetingNotFound
+	}
+	return store.LoadMeeting(string(data))
+}
+
+// LoadMeetingByRoomURL returns the latest Personal Room meeting shared for roomURL that Webex hasn't reported yet.
+func (store store) LoadMeetingByRoomURL(roomURL string) (*Meeting, error) {
+	data, appErr := store.plugin.API.KVGet(roomURLKey(roomURL))
+	if appErr != nil {
+		return nil, errors.WithMessage(appErr, fmt.Sprintf("failed to load meeting for room: %s", roomURL))
+	}
+	if data == nil {
+		return nil, ErrMeetingNotFound
+	}
+	meeting, err := store.LoadMeeting(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if !meeting.isUnmatchedPersonalRoomMeeting() {
+		return nil, ErrMeetingNotFound
+	}
+	return meeting, nil
+}
+
+// roomURLKey returns the key of the index of roomURL, which Webex may report with another scheme or case.
+func roomURLKey(roomURL string) string {
+	roomURL = strings.ToLower(strings.TrimSpace(roomURL))
+	if i := strings.Index(roomURL, "://"); i >= 0 {
+		roomURL = roomURL[i+3:]
+	}
+	return hashkey(prefixMeetingByRoomURL, strings.TrimSuffix(roomURL, "/"))
+}
+
+func (store store) DeleteMeeting(postID string) error {
+	meeting, err := store.LoadMeeting(postID)
+	if err == ErrMeetingNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	remove := func(postIDs []string) []string {
+		kept := postIDs[:0]
+		for _, id := range postIDs {
+			if id != postID {
+				kept = append(kept, id)
+			}
+		}
+		return kept
+	}
+
+	if err = store.updateIndex(hashkey(prefixMeetingsByChannel, meeting.ChannelID), remove); err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("failed to unindex meeting for channel: %s", meeting.ChannelID))
+	}
+	if meeting.HostUserID != "" {
+		if err = store.updateIndex(hashkey(prefixMeetingsByUser, meeting.HostUserID), remove); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("failed to unindex meeting for user: %s", meeting.HostUserID))
+		}
+	}
+
+	for _, webexMeetingID := range []string{meeting.WebexMeetingID, meeting.WebexInstanceID} {
+		if webexMeetingID == "" {
+			continue
+		}
+		key := hashkey(prefixMeetingByWebexID, webexMeetingID)
+		data, appErr := store.plugin.API.KVGet(key)
+		if appErr == nil && string(data) == postID {
+			appErr = store.plugin.API.KVDelete(key)
+		}
+		if appErr != nil {
+			return errors.WithMessage(appErr, fmt.Sprintf("failed to unindex meeting for Webex meeting: %s", webexMeetingID))
+		}
+	}
+
+	if meeting.RoomURL != "" {
+		data, appErr := store.plugin.API.KVGet(roomURLKey(meeting.RoomURL))
+		if appErr == nil && string(data) == postID {
+			appErr = store.plugin.API.KVDelete(roomURLKey(meeting.RoomURL))
+		}
+		if appErr != nil {
+			return errors.WithMessage(appErr, fmt.Sprintf("failed to unindex meeting for room: %s", meeting.RoomURL))
+		}
+	}
+
+	appErr := store.plugin.API.KVDelete(hashkey(prefixMeeting, postID))
+	if appErr != nil {
+		return errors.WithMessage(appErr, fmt.Sprintf("failed to delete meeting for post: %s", postID))
+	}
+	return nil
+}
+
+func (store store) loadIndexedMeetings(indexKey string) ([]*Meeting, error) {
+	var postIDs []string
+	err := store.get(indexKey, &postIDs)
+	if err == ErrUserNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	meetings := make([]*Meeting, 0, len(postIDs))
+	for _, postID := range postIDs {
+		meeting, err := store.LoadMeeting(postID)
+		if err == ErrMeetingNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		meetings = append(meetings, meeting)
+	}
+	return meetings, nil
+}
+
+// updateIndex atomically replaces the list of post IDs stored at key with the result of update.
+func (store store) updateIndex(key string, update func(postIDs []string) []string) error {
+	return store.atomicUpdate(key, func(data []byte) ([]byte, error) {
+		var postIDs []string
+		if data != nil {
+			if err := json.Unmarshal(data, &postIDs); err != nil {
+				return nil, err
+			}
+		}
+		return json.Marshal(update(postIDs))
+	})
+}
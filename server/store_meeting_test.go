@@ -0,0 +1,125 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKVStore() (store, map[string][]byte) {
+	api, kv := newKVAPI()
+	p := &Plugin{}
+	p.SetAPI(api)
+	return store{plugin: p}, kv
+}
+
+func TestStoreMeeting(t *testing.T) {
+	s, kv := newKVStore()
+
+	created := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	meeting := &Meeting{
+		PostID:         "post1",
+		ChannelID:      "channel1",
+		HostUserID:     "alice",
+		Status:         webex.StatusStarted,
+		WebexMeetingID: "webex1",
+		CreatedAt:      created,
+	}
+	require.NoError(t, s.StoreMeeting(meeting))
+	require.NoError(t, s.StoreMeeting(&Meeting{PostID: "post2", ChannelID: "channel1", HostUserID: "bob", CreatedAt: created}))
+
+	// Storing a meeting again updates it without indexing it twice.
+	meeting.Status = webex.StatusEnded
+	require.NoError(t, s.StoreMeeting(meeting))
+
+	loaded, err := s.LoadMeeting("post1")
+	require.NoError(t, err)
+	assert.Equal(t, meeting, loaded)
+
+	_, err = s.LoadMeeting("unknown")
+	assert.Equal(t, ErrMeetingNotFound, err)
+
+	byChannel, err := s.LoadMeetingsByChannel("channel1")
+	require.NoError(t, err)
+	require.Len(t, byChannel, 2)
+	assert.Equal(t, "post1", byChannel[0].PostID)
+	assert.Equal(t, "post2", byChannel[1].PostID)
+
+	byUser, err := s.LoadMeetingsByUser("alice")
+	require.NoError(t, err)
+	require.Len(t, byUser, 1)
+	assert.Equal(t, webex.StatusEnded, byUser[0].Status)
+
+	byWebexID, err := s.LoadMeetingByWebexID("webex1")
+	require.NoError(t, err)
+	assert.Equal(t, "post1", byWebexID.PostID)
+
+	_, err = s.LoadMeetingByWebexID("unknown")
+	assert.Equal(t, ErrMeetingNotFound, err)
+
+	none, err := s.LoadMeetingsByChannel("channel2")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	require.NoError(t, s.DeleteMeeting("post1"))
+	require.NoError(t, s.DeleteMeeting("post1"), "deleting twice is harmless")
+	_, err = s.LoadMeeting("post1")
+	assert.Equal(t, ErrMeetingNotFound, err)
+	_, err = s.LoadMeetingByWebexID("webex1")
+	assert.Equal(t, ErrMeetingNotFound, err)
+
+	byChannel, err = s.LoadMeetingsByChannel("channel1")
+	require.NoError(t, err)
+	require.Len(t, byChannel, 1)
+	assert.Equal(t, "post2", byChannel[0].PostID)
+
+	byUser, err = s.LoadMeetingsByUser("alice")
+	require.NoError(t, err)
+	assert.Empty(t, byUser)
+	assert.NotContains(t, kv, hashkey(prefixMeeting, "post1"))
+}
+
+func TestStoreMeetingIndexSize(t *testing.T) {
+	s, _ := newKVStore()
+
+	for i := 0; i < maxIndexSize+5; i++ {
+		require.NoError(t, s.StoreMeeting(&Meeting{PostID: fmt.Sprintf("post%d", i), ChannelID: "channel1", HostUserID: "alice"}))
+	}
+
+	meetings, err := s.LoadMeetingsByChannel("channel1")
+	require.NoError(t, err)
+	require.Len(t, meetings, maxIndexSize)
+	assert.Equal(t, "post5", meetings[0].PostID, "the oldest meetings are forgotten first")
+	assert.Equal(t, fmt.Sprintf("post%d", maxIndexSize+4), meetings[maxIndexSize-1].PostID)
+}
+
+func TestStoreSharedMeetingAgain(t *testing.T) {
+	s, _ := newKVStore()
+
+	require.NoError(t, s.StoreMeeting(&Meeting{PostID: "post1", ChannelID: "channel1", HostUserID: "alice", WebexMeetingID: "webex1"}))
+	require.NoError(t, s.StoreMeeting(&Meeting{PostID: "post2", ChannelID: "channel2", WebexMeetingID: "webex1"}))
+
+	// The events of the Webex meeting keep updating the post it was first shared in.
+	meeting, err := s.LoadMeetingByWebexID("webex1")
+	require.NoError(t, err)
+	assert.Equal(t, "post1", meeting.PostID)
+
+	// Nor does deleting a later post forget the first one.
+	require.NoError(t, s.DeleteMeeting("post2"))
+	meeting, err = s.LoadMeetingByWebexID("webex1")
+	require.NoError(t, err)
+	assert.Equal(t, "post1", meeting.PostID)
+
+	// A meeting shared by someone who isn't its host isn't listed as anyone's.
+	byUser, err := s.LoadMeetingsByUser("")
+	require.NoError(t, err)
+	assert.Empty(t, byUser)
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+	"github.com/pkg/errors"
+)
+
+const (
+	keySchemaVersion = "schema_version"
+
+	// schemaVersion is the version of the KV store written by this version of the plugin:
+	//   - 1: the user settings are keyed by the md5 of the user ID.
+	//   - 2: the user settings are keyed by the plain user ID, so they can be listed.
+	schemaVersion = 2
+
+	migrationMutexKey = "store_migration"
+
+	migrationUsersPerPage = 200
+)
+
+// Migrate upgrades the store to the current schema version, on a single node of the cluster at a time. A store
+// written by a newer version of the plugin is left as it is. It may take a while, so it runs in the background, with
+// LoadUserInfo reading the records not migrated yet.
+func (store store) Migrate() error {
+	version, err := store.loadSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version >= schemaVersion {
+		return nil
+	}
+
+	mutex, err := cluster.NewMutex(store.plugin.API, migrationMutexKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the migration mutex")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// Another node may have migrated the store while this one waited.
+	if version, err = store.loadSchemaVersion(); err != nil || version >= schemaVersion {
+		return err
+	}
+
+	if version < 2 {
+		if err = store.migrateHashedUserInfo(); err != nil {
+			return errors.WithMessage(err, "failed to migrate the user settings to schema version 2")
+		}
+	}
+
+	appErr := store.plugin.API.KVSet(keySchemaVersion, []byte(strconv.Itoa(schemaVersion)))
+	if appErr != nil {
+		return errors.WithMessage(appErr, "failed to store the schema version")
+	}
+	return nil
+}
+
+// loadSchemaVersion returns the version of the store, 1 when it predates versioning.
+func (store store) loadSchemaVersion() (int, error) {
+	data, appErr := store.plugin.API.KVGet(keySchemaVersion)
+	if appErr != nil {
+		return 0, errors.WithMessage(appErr, "failed to load the schema version")
+	}
+	if data == nil {
+		return 1, nil
+	}
+
+	version, err := strconv.Atoi(string(data))
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid schema version: %s", data)
+	}
+	return version, nil
+}
+
+// migrateHashedUserInfo copies the user settings keyed by the md5 of the user ID to plain keys. As the hashes can't be
+// reversed, the key of every Mattermost user is hashed to find theirs. The records that can't be migrated are logged
+// and skipped. The hashed keys are kept for the nodes still running the previous version during an upgrade.
+func (store store) migrateHashedUserInfo() error {
+	hashedKeys, err := store.listKeys(prefixHashedUserInfo)
+	if err != nil {
+		return err
+	}
+	remaining := map[string]bool{}
+	for _, key := range hashedKeys {
+		remaining[key] = true
+	}
+
+	for page := 0; len(remaining) > 0; page++ {
+		users, appErr := store.plugin.API.GetUsers(&model.UserGetOptions{Page: page, PerPage: migrationUsersPerPage})
+		if appErr != nil {
+			return errors.WithMessage(appErr, "failed to list the users")
+		}
+
+		for _, user := range users {
+			hashedKey := hashkey(prefixHashedUserInfo, user.Id)
+			if !remaining[hashedKey] {
+				continue
+			}
+			if err = store.migrateUserInfoKey(hashedKey, user.Id); err != nil {
+				store.plugin.API.LogWarn("Skipped the settings of a user that couldn't be migrated", "user_id", user.Id, "error", err.Error())
+			}
+			delete(remaining, hashedKey)
+		}
+
+		if len(users) < migrationUsersPerPage {
+			break
+		}
+	}
+
+	if len(remaining) > 0 {
+		store.plugin.API.LogWarn(fmt.Sprintf("Left the settings of %d deleted users unmigrated", len(remaining)))
+	}
+	return nil
+}
+
+func (store store) migrateUserInfoKey(hashedKey, mattermostUserID string) error {
+	data, appErr := store.plugin.API.KVGet(hashedKey)
+	if appErr != nil {
+		return errors.WithMessage(appErr, fmt.Sprintf("failed to load userInfo for mattermostUserId: %s", mattermostUserID))
+	}
+	if data == nil {
+		return nil
+	}
+
+	var userInfo UserInfo
+	if err := json.Unmarshal(data, &userInfo); err != nil {
+		return errors.Wrapf(err, "failed to parse userInfo for mattermostUserId: %s", mattermostUserID)
+	}
+	userInfo.MattermostUserID = mattermostUserID
+	userInfo.Version = userInfoVersion
+	data, err := json.Marshal(userInfo)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal userInfo for mattermostUserId: %s", mattermostUserID)
+	}
+
+	// The settings stored since the plugin was upgraded are newer, and kept.
+	_, appErr = store.plugin.API.KVSetWithOptions(prefixUserInfo+mattermostUserID, data, model.PluginKVSetOptions{
+		Atomic:   true,
+		OldValue: nil,
+	})
+	if appErr != nil {
+		return errors.WithMessage(appErr, fmt.Sprintf("failed to store userInfo for mattermostUserId: %s", mattermostUserID))
+	}
+	return nil
+}
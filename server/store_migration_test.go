@@ -0,0 +1,164 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Run("up to date", func(t *testing.T) {
+		api := &plugintest.API{}
+		api.On("KVGet", keySchemaVersion).Return([]byte("2"), nil)
+
+		s := store{plugin: &Plugin{}}
+		s.plugin.SetAPI(api)
+		require.NoError(t, s.Migrate())
+		api.AssertExpectations(t)
+	})
+
+	t.Run("from hashed keys", func(t *testing.T) {
+		s, kv := newKVStore()
+		api := s.plugin.API.(*plugintest.API)
+		aliceKey := hashkey(prefixHashedUserInfo, "aliceid")
+		bobKey := hashkey(prefixHashedUserInfo, "bobid")
+		carolKey := hashkey(prefixHashedUserInfo, "carolid")
+		deletedKey := hashkey(prefixHashedUserInfo, "deletedid")
+		kv[aliceKey] = []byte(`{"email":"alice@example.com","room_id":"alice.room","future":"kept"}`)
+		kv[bobKey] = []byte(`{"email":"bob@example.com","room_id":"old.room"}`)
+		kv[prefixUserInfo+"bobid"] = []byte(`{"email":"bob@example.com","room_id":"new.room"}`)
+		kv[carolKey] = []byte(`{"email":`)
+		kv[deletedKey] = []byte(`{"email":"deleted@example.com"}`)
+		kv["oauth_state_x"] = []byte("state")
+
+		api.On("GetUsers", &model.UserGetOptions{Page: 0, PerPage: migrationUsersPerPage}).Return(
+			[]*model.User{{Id: "aliceid"}, {Id: "bobid"}, {Id: "carolid"}, {Id: "daveid"}}, nil)
+		api.On("LogWarn", "Skipped the settings of a user that couldn't be migrated", "user_id", "carolid", "error", mock.Anything).Return()
+		api.On("LogWarn", "Left the settings of 1 deleted users unmigrated").Return()
+
+		require.NoError(t, s.Migrate())
+		api.AssertCalled(t, "LogWarn", "Skipped the settings of a user that couldn't be migrated", "user_id", "carolid", "error", mock.Anything)
+		api.AssertCalled(t, "LogWarn", "Left the settings of 1 deleted users unmigrated")
+		assert.Equal(t, "2", string(kv[keySchemaVersion]))
+
+		var userInfo UserInfo
+		require.NoError(t, json.Unmarshal(kv[prefixUserInfo+"aliceid"], &userInfo))
+		assert.Equal(t, "aliceid", userInfo.MattermostUserID)
+		assert.Equal(t, userInfoVersion, userInfo.Version)
+		assert.Equal(t, "alice.room", userInfo.RoomID)
+		assert.JSONEq(t, `"kept"`, string(userInfo.unknownFields["future"]))
+
+		assert.JSONEq(t, `{"email":"bob@example.com","room_id":"new.room"}`, string(kv[prefixUserInfo+"bobid"]), "newer settings are kept")
+		assert.NotContains(t, kv, prefixUserInfo+"carolid")
+		for _, key := range []string{aliceKey, bobKey, carolKey, deletedKey} {
+			assert.Contains(t, kv, key, "the hashed keys are kept for the previous version")
+		}
+	})
+}
+
+func TestLoadUserInfoFromHashedKey(t *testing.T) {
+	s, kv := newKVStore()
+	kv[hashkey(prefixHashedUserInfo, "aliceid")] = []byte(`{"email":"alice@example.com","room_id":"alice.room"}`)
+
+	userInfo, err := s.LoadUserInfo("aliceid")
+	require.NoError(t, err)
+	assert.Equal(t, "aliceid", userInfo.MattermostUserID)
+	assert.Equal(t, "alice.room", userInfo.RoomID)
+
+	_, err = s.LoadUserInfo("bobid")
+	assert.Equal(t, ErrUserNotFound, err)
+
+	kv[prefixUserInfo+"aliceid"] = []byte(`{"email":"alice@example.com","room_id":"new.room"}`)
+	userInfo, err = s.LoadUserInfo("aliceid")
+	require.NoError(t, err)
+	assert.Equal(t, "new.room", userInfo.RoomID)
+
+	require.NoError(t, s.DeleteUserInfo("aliceid"))
+	_, err = s.LoadUserInfo("aliceid")
+	assert.Equal(t, ErrUserNotFound, err)
+}
+
+func TestUserInfoUnknownFields(t *testing.T) {
+	var userInfo UserInfo
+	require.NoError(t, json.Unmarshal([]byte(`{"version":3,"email":"alice@example.com","room_id":"alice.room","future":{"a":[1,2]}}`), &userInfo))
+	assert.Equal(t, 3, userInfo.Version)
+	assert.Equal(t, "alice.room", userInfo.RoomID)
+
+	userInfo.RoomID = "other.room"
+	data, err := json.Marshal(userInfo)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version":3,"email":"alice@example.com","room_id":"other.room","future":{"a":[1,2]}}`, string(data))
+
+	data, err = json.Marshal(UserInfo{Email: "bob@example.com"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"email":"bob@example.com","room_id":""}`, string(data))
+}
+
+func TestUpdateUserInfo(t *testing.T) {
+	s, kv := newKVStore()
+	api := s.plugin.API.(*plugintest.API)
+	api.On("GetUser", "aliceid").Return(&model.User{Id: "aliceid", Email: "alice@example.com"}, nil)
+
+	setRoom := func(roomID string) func(info *UserInfo) error {
+		return func(info *UserInfo) error {
+			info.RoomID = roomID
+			return nil
+		}
+	}
+
+	t.Run("from the hashed key", func(t *testing.T) {
+		kv[hashkey(prefixHashedUserInfo, "aliceid")] = []byte(`{"email":"alice@example.com","encrypted_token":"thetoken"}`)
+		require.NoError(t, s.UpdateUserInfo("aliceid", setRoom("alice.room")))
+
+		userInfo, err := s.LoadUserInfo("aliceid")
+		require.NoError(t, err)
+		assert.Equal(t, "alice.room", userInfo.RoomID)
+		assert.Equal(t, "thetoken", userInfo.EncryptedToken)
+		assert.Equal(t, userInfoVersion, userInfo.Version)
+	})
+
+	t.Run("keeps a newer version", func(t *testing.T) {
+		kv[prefixUserInfo+"aliceid"] = []byte(`{"version":99,"email":"alice@example.com","future":true}`)
+		require.NoError(t, s.UpdateUserInfo("aliceid", setRoom("other.room")))
+		assert.JSONEq(t, `{"version":99,"mattermost_user_id":"aliceid","email":"alice@example.com","room_id":"other.room","future":true}`,
+			string(kv[prefixUserInfo+"aliceid"]))
+	})
+
+	t.Run("concurrent updates don't overwrite each other", func(t *testing.T) {
+		delete(kv, prefixUserInfo+"aliceid")
+		var wg sync.WaitGroup
+		for _, update := range []func(info *UserInfo) error{
+			setRoom("alice.room"),
+			func(info *UserInfo) error {
+				info.EncryptedToken = "therefreshedtoken"
+				return nil
+			},
+			func(info *UserInfo) error {
+				info.WebhookIDs = []string{"w1"}
+				return nil
+			},
+		} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, s.UpdateUserInfo("aliceid", update))
+			}()
+		}
+		wg.Wait()
+
+		userInfo, err := s.LoadUserInfo("aliceid")
+		require.NoError(t, err)
+		assert.Equal(t, "alice.room", userInfo.RoomID)
+		assert.Equal(t, "therefreshedtoken", userInfo.EncryptedToken)
+		assert.Equal(t, []string{"w1"}, userInfo.WebhookIDs)
+	})
+}
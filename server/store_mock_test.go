@@ -1,12 +1,212 @@
 package main
 
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+)
+
+// newKVAPI returns an API whose KV store is kept in memory, to test the store against, and the store's values by key.
+func newKVAPI() (*plugintest.API, map[string][]byte) {
+	var lock sync.Mutex
+	kv := map[string][]byte{}
+
+	api := &plugintest.API{}
+	api.On("KVGet", mock.AnythingOfType("string")).Return(func(key string) ([]byte, *model.AppError) {
+		lock.Lock()
+		defer lock.Unlock()
+		return kv[key], nil
+	})
+	api.On("KVSet", mock.AnythingOfType("string"), mock.Anything).Return(func(key string, value []byte) *model.AppError {
+		lock.Lock()
+		defer lock.Unlock()
+		kv[key] = value
+		return nil
+	})
+	api.On("KVSetWithOptions", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(
+		func(key string, value []byte, options model.PluginKVSetOptions) (bool, *model.AppError) {
+			lock.Lock()
+			defer lock.Unlock()
+			if options.Atomic && !bytes.Equal(options.OldValue, kv[key]) {
+				return false, nil
+			}
+			if value == nil {
+				delete(kv, key)
+			} else {
+				kv[key] = value
+			}
+			return true, nil
+		})
+	api.On("KVDelete", mock.AnythingOfType("string")).Return(func(key string) *model.AppError {
+		lock.Lock()
+		defer lock.Unlock()
+		delete(kv, key)
+		return nil
+	})
+	api.On("KVList", mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(func(page, perPage int) ([]string, *model.AppError) {
+		lock.Lock()
+		defer lock.Unlock()
+		keys := make([]string, 0, len(kv))
+		for key := range kv {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		start := min(page*perPage, len(keys))
+		return keys[start:min(start+perPage, len(keys))], nil
+	})
+	return api, kv
+}
+
 type mockStore struct {
 	userInfo UserInfo
+	rooms    map[string]string
+	meetings map[string]*Meeting
+	pmrCache map[string]PMRCacheEntry
 }
 
-func (store mockStore) StoreUserInfo(_ string, _ UserInfo) error {
+func (store mockStore) Migrate() error {
 	return nil
 }
+func (store mockStore) UpdateUserInfo(_ string, update func(info *UserInfo) error) error {
+	info := store.userInfo
+	return update(&info)
+}
 func (store mockStore) LoadUserInfo(_ string) (UserInfo, error) {
 	return store.userInfo, nil
 }
+func (store mockStore) DeleteUserInfo(_ string) error {
+	return nil
+}
+func (store mockStore) StoreUserRooms(rooms map[string]string) error {
+	for mattermostUserID, roomID := range rooms {
+		if store.rooms != nil {
+			store.rooms[mattermostUserID] = roomID
+		}
+	}
+	return nil
+}
+func (store mockStore) LoadAllUserInfo() ([]UserInfo, error) {
+	if store.userInfo.Email == "" {
+		return nil, nil
+	}
+	return []UserInfo{store.userInfo}, nil
+}
+func (store mockStore) StoreOAuthState(_, _ string) error {
+	return nil
+}
+func (store mockStore) VerifyOAuthState(_, _ string) error {
+	return nil
+}
+func (store mockStore) StoreMeeting(meeting *Meeting) error {
+	if store.meetings != nil {
+		store.meetings[meeting.PostID] = meeting
+	}
+	return nil
+}
+func (store mockStore) LoadMeeting(postID string) (*Meeting, error) {
+	meeting, ok := store.meetings[postID]
+	if !ok {
+		return nil, ErrMeetingNotFound
+	}
+	copied := *meeting
+	return &copied, nil
+}
+func (store mockStore) LoadMeetingsByChannel(_ string) ([]*Meeting, error) {
+	return nil, nil
+}
+func (store mockStore) LoadMeetingsByUser(_ string) ([]*Meeting, error) {
+	return nil, nil
+}
+func (store mockStore) LoadMeetingByWebexID(_ string) (*Meeting, error) {
+	return nil, ErrMeetingNotFound
+}
+func (store mockStore) LoadMeetingByRoomURL(_ string) (*Meeting, error) {
+	return nil, ErrMeetingNotFound
+}
+func (store mockStore) DeleteMeeting(_ string) error {
+	return nil
+}
+func (store mockStore) StoreChannelInfo(_ string, _ ChannelInfo) error {
+	return nil
+}
+func (store mockStore) LoadChannelInfo(_ string) (ChannelInfo, error) {
+	return ChannelInfo{}, nil
+}
+func (store mockStore) ScheduleReminders(_ []Reminder) error {
+	return nil
+}
+func (store mockStore) CancelReminders(_ string) error {
+	return nil
+}
+func (store mockStore) PopDueReminders(_ time.Time) ([]Reminder, error) {
+	return nil, nil
+}
+func (store mockStore) ScheduleRecordingLookup(_ RecordingLookup) error {
+	return nil
+}
+func (store mockStore) PopDueRecordingLookups(_ time.Time) ([]RecordingLookup, error) {
+	return nil, nil
+}
+func (store mockStore) StorePMRCacheEntry(key string, entry PMRCacheEntry, _ time.Duration) error {
+	if store.pmrCache != nil {
+		store.pmrCache[key] = entry
+	}
+	return nil
+}
+func (store mockStore) LoadPMRCacheEntry(key string) (PMRCacheEntry, error) {
+	entry, ok := store.pmrCache[key]
+	if !ok {
+		return PMRCacheEntry{}, ErrPMRNotCached
+	}
+	return entry, nil
+}
+func (store mockStore) DeletePMRCacheEntry(key string) error {
+	delete(store.pmrCache, key)
+	return nil
+}
+
+// userStore keeps the settings of several users, and records the ones stored and deleted.
+type userStore struct {
+	mockStore
+
+	userInfos map[string]UserInfo
+	stored    map[string]UserInfo
+	deleted   []string
+}
+
+func newUserStore(userInfos map[string]UserInfo) *userStore {
+	return &userStore{userInfos: userInfos, stored: map[string]UserInfo{}}
+}
+
+func (store *userStore) LoadUserInfo(mattermostUserID string) (UserInfo, error) {
+	userInfo, ok := store.userInfos[mattermostUserID]
+	if !ok {
+		return UserInfo{}, ErrUserNotFound
+	}
+	return userInfo, nil
+}
+func (store *userStore) LoadAllUserInfo() ([]UserInfo, error) {
+	var all []UserInfo
+	for _, userInfo := range store.userInfos {
+		all = append(all, userInfo)
+	}
+	return all, nil
+}
+func (store *userStore) UpdateUserInfo(mattermostUserID string, update func(info *UserInfo) error) error {
+	info := store.userInfos[mattermostUserID]
+	if err := update(&info); err != nil {
+		return err
+	}
+	store.userInfos[mattermostUserID] = info
+	store.stored[mattermostUserID] = info
+	return nil
+}
+func (store *userStore) DeleteUserInfo(mattermostUserID string) error {
+	store.deleted = append(store.deleted, mattermostUserID)
+	return nil
+}
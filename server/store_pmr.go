@@ -0,0 +1,51 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const prefixPMRCache = "pmr_cache_"
+
+var ErrPMRNotCached = errors.New("personal meeting room not cached")
+
+// StorePMRCacheEntry caches entry at key, until ttl expires.
+func (store store) StorePMRCacheEntry(key string, entry PMRCacheEntry, ttl time.Duration) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	appErr := store.plugin.API.KVSetWithExpiry(hashkey(prefixPMRCache, key), data, int64(ttl/time.Second))
+	if appErr != nil {
+		return errors.WithMessage(appErr, fmt.Sprintf("failed to cache the personal meeting room of: %s", key))
+	}
+	return nil
+}
+
+// LoadPMRCacheEntry returns the entry cached at key, or ErrPMRNotCached.
+func (store store) LoadPMRCacheEntry(key string) (PMRCacheEntry, error) {
+	entry := PMRCacheEntry{}
+	err := store.get(hashkey(prefixPMRCache, key), &entry)
+	if err == ErrUserNotFound {
+		return PMRCacheEntry{}, ErrPMRNotCached
+	}
+	if err != nil {
+		return PMRCacheEntry{}, errors.WithMessage(err, fmt.Sprintf("failed to load the cached personal meeting room of: %s", key))
+	}
+	return entry, nil
+}
+
+func (store store) DeletePMRCacheEntry(key string) error {
+	appErr := store.plugin.API.KVDelete(hashkey(prefixPMRCache, key))
+	if appErr != nil {
+		return errors.WithMessage(appErr, fmt.Sprintf("failed to delete the cached personal meeting room of: %s", key))
+	}
+	return nil
+}
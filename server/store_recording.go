@@ -0,0 +1,72 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const keyRecordingLookupQueue = "recording_lookup_queue"
+
+// ScheduleRecordingLookup adds lookup to the queue. When a lookup is already pending for the same post, it is brought
+// forward to lookup.At instead, keeping its attempt count, so that each meeting has a single chain of lookups.
+func (store store) ScheduleRecordingLookup(lookup RecordingLookup) error {
+	err := store.updateRecordingLookupQueue(func(queue []RecordingLookup) []RecordingLookup {
+		return scheduleRecordingLookup(queue, lookup)
+	})
+	if err != nil {
+		return errors.WithMessage(err, "failed to schedule a recording lookup")
+	}
+	return nil
+}
+
+func scheduleRecordingLookup(queue []RecordingLookup, lookup RecordingLookup) []RecordingLookup {
+	for i := range queue {
+		if queue[i].PostID == lookup.PostID {
+			if lookup.At.Before(queue[i].At) {
+				queue[i].At = lookup.At
+			}
+			return queue
+		}
+	}
+	return append(queue, lookup)
+}
+
+// PopDueRecordingLookups atomically removes and returns the lookups due at now, so that each lookup is only returned
+// once across the cluster.
+func (store store) PopDueRecordingLookups(now time.Time) ([]RecordingLookup, error) {
+	var due []RecordingLookup
+	err := store.updateRecordingLookupQueue(func(queue []RecordingLookup) []RecordingLookup {
+		due = nil
+		var pending []RecordingLookup
+		for _, lookup := range queue {
+			if lookup.At.After(now) {
+				pending = append(pending, lookup)
+			} else {
+				due = append(due, lookup)
+			}
+		}
+		return pending
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to pop due recording lookups")
+	}
+	return due, nil
+}
+
+// updateRecordingLookupQueue atomically replaces the recording lookup queue with the result of update.
+func (store store) updateRecordingLookupQueue(update func(queue []RecordingLookup) []RecordingLookup) error {
+	return store.atomicUpdate(keyRecordingLookupQueue, func(data []byte) ([]byte, error) {
+		var queue []RecordingLookup
+		if data != nil {
+			if err := json.Unmarshal(data, &queue); err != nil {
+				return nil, err
+			}
+		}
+		return json.Marshal(update(queue))
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const keyReminderQueue = "reminder_queue"
+
+// ScheduleReminders adds reminders to the queue.
+func (store store) ScheduleReminders(reminders []Reminder) error {
+	err := store.updateReminderQueue(func(queue []Reminder) []Reminder {
+		return append(queue, reminders...)
+	})
+	if err != nil {
+		return errors.WithMessage(err, "failed to schedule reminders")
+	}
+	return nil
+}
+
+// CancelReminders removes the pending reminders of the meeting of postID.
+func (store store) CancelReminders(postID string) error {
+	err := store.updateReminderQueue(func(queue []Reminder) []Reminder {
+		var kept []Reminder
+		for _, reminder := range queue {
+			if reminder.PostID != postID {
+				kept = append(kept, reminder)
+			}
+		}
+		return kept
+	})
+	if err != nil {
+		return errors.WithMessage(err, "failed to cancel reminders")
+	}
+	return nil
+}
+
+// PopDueReminders atomically removes and returns the reminders due at now, so that each reminder is only returned
+// once across the cluster.
+func (store store) PopDueReminders(now time.Time) ([]Reminder, error) {
+	var due []Reminder
+	err := store.updateReminderQueue(func(queue []Reminder) []Reminder {
+		due = nil
+		var pending []Reminder
+		for _, reminder := range queue {
+			if reminder.At.After(now) {
+				pending = append(pending, reminder)
+			} else {
+				due = append(due, reminder)
+			}
+		}
+		return pending
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to pop due reminders")
+	}
+	return due, nil
+}
+
+// updateReminderQueue atomically replaces the reminder queue with the result of update.
+func (store store) updateReminderQueue(update func(queue []Reminder) []Reminder) error {
+	return store.atomicUpdate(keyReminderQueue, func(data []byte) ([]byte, error) {
+		var queue []Reminder
+		if data != nil {
+			if err := json.Unmarshal(data, &queue); err != nil {
+				return nil, err
+			}
+		}
+		return json.Marshal(update(queue))
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReminderQueue(t *testing.T) {
+	s, kv := newKVStore()
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+
+	due, err := s.PopDueReminders(now)
+	require.NoError(t, err)
+	assert.Empty(t, due, "the queue starts empty")
+
+	require.NoError(t, s.ScheduleReminders([]Reminder{
+		{PostID: "post1", At: now.Add(-time.Minute)},
+		{PostID: "post1", UserID: "aliceid", At: now},
+		{PostID: "post2", At: now.Add(time.Hour)},
+	}))
+	require.NoError(t, s.ScheduleReminders([]Reminder{{PostID: "post3", UserID: "bobid", At: now.Add(-time.Hour)}}))
+	require.NoError(t, s.CancelReminders("post3"))
+	require.NoError(t, s.CancelReminders("unknownpost"))
+
+	due, err = s.PopDueReminders(now)
+	require.NoError(t, err)
+	assert.Equal(t, []Reminder{
+		{PostID: "post1", At: now.Add(-time.Minute)},
+		{PostID: "post1", UserID: "aliceid", At: now},
+	}, due, "a reminder due now is popped, a cancelled one is not")
+
+	due, err = s.PopDueReminders(now)
+	require.NoError(t, err)
+	assert.Empty(t, due, "popped reminders are removed")
+
+	due, err = s.PopDueReminders(now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []Reminder{{PostID: "post2", At: now.Add(time.Hour)}}, due)
+	assert.JSONEq(t, `null`, string(kv[keyReminderQueue]))
+}
+
+func TestPopDueRemindersConcurrently(t *testing.T) {
+	s, _ := newKVStore()
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+
+	var reminders []Reminder
+	for _, userID := range []string{"aliceid", "bobid", "carolid"} {
+		reminders = append(reminders, Reminder{PostID: "post1", UserID: userID, At: now})
+	}
+	require.NoError(t, s.ScheduleReminders(reminders))
+
+	// Each failed attempt of a node means another one updated the queue, so five nodes never run out of attempts.
+	var lock sync.Mutex
+	var popped []Reminder
+	var wg sync.WaitGroup
+	for i := 0; i < maxAtomicUpdateAttempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			due, err := s.PopDueReminders(now)
+			assert.NoError(t, err)
+			lock.Lock()
+			defer lock.Unlock()
+			popped = append(popped, due...)
+		}()
+	}
+	wg.Wait()
+
+	assert.ElementsMatch(t, reminders, popped, "each reminder is popped once")
+}
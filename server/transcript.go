@@ -0,0 +1,284 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// maxTranscriptHighlights bounds the number of key moments quoted from a transcript.
+const maxTranscriptHighlights = 5
+
+// highlightKeywords mark the cues of a transcript quoted as its key moments. The key moments are only the cues
+// mentioning one of them, the transcript isn't summarized.
+var highlightKeywords = []string{
+	"action item",
+	"agreed",
+	"decided",
+	"decision",
+	"deadline",
+	"follow up",
+	"follow-up",
+	"next step",
+	"to do",
+	"todo",
+}
+
+// shareTranscripts attaches the new transcripts of meeting to its thread. The token of a host who connected their
+// account before transcripts were supported can't list them: the host is asked once to connect it again, and their
+// transcripts are skipped until then.
+func (p *Plugin) shareTranscripts(token string, meeting *Meeting) lookupOutcome {
+	hostInfo, err := p.store.LoadUserInfo(meeting.HostUserID)
+	if err != nil {
+		p.errorf("unable to load the user info of the host of the meeting for post: %s, error: %v", meeting.PostID, err)
+		return lookupFailed
+	}
+	if hostInfo.TranscriptsUnauthorized {
+		return lookupNothing
+	}
+
+	transcripts, err := p.webexRESTClient.ListTranscripts(context.Background(), token, meeting.WebexMeetingID)
+	if webex.IsUnauthorized(err) {
+		p.requestTranscriptsAccess(meeting.HostUserID)
+		return lookupNothing
+	}
+	if err != nil {
+		p.errorf("unable to list the transcripts of the Webex meeting: %s, error: %v", meeting.WebexMeetingID, err)
+		return lookupFailed
+	}
+
+	known := map[string]bool{}
+	for _, transcriptID := range meeting.TranscriptIDs {
+		known[transcriptID] = true
+	}
+	failed := false
+	outcome := lookupNothing
+	if len(known) > 0 {
+		outcome = lookupDone
+	}
+	for _, transcript := range transcripts {
+		if transcript.Status != webex.TranscriptStatusAvailable || known[transcript.ID] {
+			continue
+		}
+		if err = p.shareTranscript(token, meeting, transcript); err != nil {
+			p.errorf("error sharing the transcript: %s of the meeting for post: %s, error: %v", transcript.ID, meeting.PostID, err)
+			failed = true
+			continue
+		}
+		outcome = lookupDone
+	}
+	if failed {
+		return lookupFailed
+	}
+	return outcome
+}
+
+// requestTranscriptsAccess asks mattermostUserID to connect their account again, the first time Webex refuses their
+// token access to transcripts.
+func (p *Plugin) requestTranscriptsAccess(mattermostUserID string) {
+	userInfo, err := p.store.LoadUserInfo(mattermostUserID)
+	if err != nil {
+		p.errorf("unable to load the user info for mattermostUserID: %s, error: %v", mattermostUserID, err)
+		return
+	}
+	if userInfo.TranscriptsUnauthorized {
+		return
+	}
+
+	// Another node may have asked in the meantime.
+	asked := false
+	err = p.store.UpdateUserInfo(mattermostUserID, func(info *UserInfo) error {
+		asked = info.TranscriptsUnauthorized
+		info.TranscriptsUnauthorized = true
+		return nil
+	})
+	if err != nil {
+		p.errorf("error storing user info for mattermostUserID: %s, error: %v", mattermostUserID, err)
+		return
+	}
+	if asked {
+		return
+	}
+	p.API.LogInfo("Not sharing the transcripts of a host whose Webex token can't access them", "user_id", mattermostUserID)
+	p.dm(mattermostUserID, "Your Webex account was connected before meeting transcripts were supported, so the transcripts of your meetings can't be shared. Run `/webex connect` to connect it again and share them.")
+}
+
+// shareTranscript attaches transcript to the thread of meeting as speaker-attributed text, with its highlights.
+func (p *Plugin) shareTranscript(token string, meeting *Meeting, transcript webex.Transcript) error {
+	data, err := p.webexRESTClient.DownloadTranscript(context.Background(), token, transcript.ID)
+	if err != nil {
+		return err
+	}
+	cues, err := webex.ParseVTT(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if len(cues) == 0 {
+		// Recorded as handled all the same, so that it isn't downloaded again by the next lookups.
+		p.API.LogInfo("Not sharing a Webex transcript without any caption", "transcript_id", transcript.ID, "post_id", meeting.PostID)
+		_, err = p.setTranscriptShared(meeting.PostID, transcript.ID, true)
+		return err
+	}
+
+	// Record the transcript before sharing it so that it is only shared once, and forget it if sharing fails so that
+	// the next lookup tries again.
+	recorded, err := p.setTranscriptShared(meeting.PostID, transcript.ID, true)
+	if err != nil || !recorded {
+		return err
+	}
+	if err = p.postTranscript(meeting, cues); err != nil {
+		if _, forgetErr := p.setTranscriptShared(meeting.PostID, transcript.ID, false); forgetErr != nil {
+			p.errorf("error forgetting the transcript: %s of the meeting for post: %s, error: %v", transcript.ID, meeting.PostID, forgetErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// setTranscriptShared adds transcriptID to the shared transcripts of the meeting of postID, or removes it, and
+// reports whether that changed anything.
+func (p *Plugin) setTranscriptShared(postID, transcriptID string, shared bool) (bool, error) {
+	changed := false
+	err := p.updateMeeting(postID, func(meeting *Meeting) bool {
+		changed = false
+		var kept []string
+		for _, id := range meeting.TranscriptIDs {
+			if id != transcriptID {
+				kept = append(kept, id)
+			}
+		}
+		if shared == (len(kept) < len(meeting.TranscriptIDs)) {
+			return false
+		}
+		if shared {
+			kept = append(kept, transcriptID)
+		}
+		meeting.TranscriptIDs = kept
+		changed = true
+		return true
+	})
+	return changed, err
+}
+
+// postTranscript replies to the post of meeting with cues attached as text, and their highlights.
+func (p *Plugin) postTranscript(meeting *Meeting, cues []webex.Cue) error {
+	fileInfo, appErr := p.API.UploadFile([]byte(webex.FormatTranscript(cues)+"\n"), meeting.ChannelID, transcriptFilename(meeting))
+	if appErr != nil {
+		return appErr
+	}
+
+	post := &model.Post{
+		UserId:    p.botUserID,
+		ChannelId: meeting.ChannelID,
+		RootId:    meeting.PostID,
+		Message:   formatTranscriptHighlights(cues),
+		FileIds:   model.StringArray{fileInfo.Id},
+	}
+	if _, appErr = p.API.CreatePost(post); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// transcriptFilename names the transcript file of meeting after its title.
+func transcriptFilename(meeting *Meeting) string {
+	title := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '-'
+		}
+		return r
+	}, topicOrDefault(meeting.Title))
+	return title + " transcript.txt"
+}
+
+// formatTranscriptHighlights renders the share of speaking time of each speaker of cues, and their key moments.
+func formatTranscriptHighlights(cues []webex.Cue) string {
+	var b strings.Builder
+	b.WriteString("#### Transcript highlights\n")
+	fmt.Fprintf(&b, "**Speakers:** %s\n", formatSpeakers(cues))
+
+	var moments []string
+	for _, cue := range cues {
+		if len(moments) == maxTranscriptHighlights {
+			break
+		}
+		if isHighlight(cue.Text) {
+			moment := fmt.Sprintf("- %s %s", formatCueTime(cue.Start), cue.Text)
+			if cue.Speaker != "" {
+				moment = fmt.Sprintf("- %s %s: %s", formatCueTime(cue.Start), cue.Speaker, cue.Text)
+			}
+			moments = append(moments, moment)
+		}
+	}
+	if len(moments) == 0 {
+		b.WriteString("**Key moments:** nothing mentions decisions, action items or next steps\n")
+	} else {
+		fmt.Fprintf(&b, "**Key moments** (mentioning decisions, action items or next steps):\n%s\n", strings.Join(moments, "\n"))
+	}
+
+	b.WriteString("The full transcript is attached.")
+	return b.String()
+}
+
+// formatSpeakers lists the speakers of cues by decreasing speaking time, with their share of it.
+func formatSpeakers(cues []webex.Cue) string {
+	times := map[string]time.Duration{}
+	var total time.Duration
+	for _, cue := range cues {
+		if cue.Speaker == "" || cue.End <= cue.Start {
+			continue
+		}
+		times[cue.Speaker] += cue.End - cue.Start
+		total += cue.End - cue.Start
+	}
+	if total == 0 {
+		return "not identified in the transcript"
+	}
+
+	speakers := make([]string, 0, len(times))
+	for speaker := range times {
+		speakers = append(speakers, speaker)
+	}
+	sort.Slice(speakers, func(i, j int) bool {
+		if times[speakers[i]] != times[speakers[j]] {
+			return times[speakers[i]] > times[speakers[j]]
+		}
+		return speakers[i] < speakers[j]
+	})
+
+	shares := make([]string, 0, len(speakers))
+	for _, speaker := range speakers {
+		shares = append(shares, fmt.Sprintf("%s (%d%%)", speaker, int(math.Round(100*float64(times[speaker])/float64(total)))))
+	}
+	return strings.Join(shares, ", ")
+}
+
+func isHighlight(text string) bool {
+	text = strings.ToLower(text)
+	for _, keyword := range highlightKeywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatCueTime formats the offset of a cue in its transcript, e.g. "4:05" or "1:02:03".
+func formatCueTime(d time.Duration) string {
+	seconds := int(d / time.Second)
+	if seconds < 3600 {
+		return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatTranscriptHighlights(t *testing.T) {
+	cues := []webex.Cue{
+		{Start: 0, End: 30 * time.Second, Speaker: "Alice", Text: "Let's review the release."},
+		{Start: 30 * time.Second, End: 40 * time.Second, Speaker: "Bob", Text: "We agreed to ship on Friday."},
+		{Start: 65 * time.Second, End: 75 * time.Second, Speaker: "Carol", Text: "I'll follow up with QA."},
+		{Start: time.Hour + 2*time.Minute + 3*time.Second, End: time.Hour + 2*time.Minute + 13*time.Second, Text: "The next step is the demo."},
+	}
+
+	assert.Equal(t, "#### Transcript highlights\n"+
+		"**Speakers:** Alice (60%), Bob (20%), Carol (20%)\n"+
+		"**Key moments** (mentioning decisions, action items or next steps):\n"+
+		"- 0:30 Bob: We agreed to ship on Friday.\n"+
+		"- 1:05 Carol: I'll follow up with QA.\n"+
+		"- 1:02:03 The next step is the demo.\n"+
+		"The full transcript is attached.", formatTranscriptHighlights(cues))
+
+	assert.Equal(t, "#### Transcript highlights\n"+
+		"**Speakers:** not identified in the transcript\n"+
+		"**Key moments:** nothing mentions decisions, action items or next steps\n"+
+		"The full transcript is attached.", formatTranscriptHighlights([]webex.Cue{{End: time.Second, Text: "Hello."}}))
+}
+
+func TestTranscriptFilename(t *testing.T) {
+	assert.Equal(t, "Q3-Q4 planning- review transcript.txt", transcriptFilename(&Meeting{Title: "Q3/Q4 planning: review"}))
+	assert.Equal(t, "Webex Meeting transcript.txt", transcriptFilename(&Meeting{}))
+}
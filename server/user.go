@@ -1,23 +1,127 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
 )
 
+// userInfoVersion is the version of the UserInfo written by this version of the plugin.
+const userInfoVersion = 2
+
 type UserInfo struct {
+	// Version is the version of the UserInfo when it was stored, 0 for the ones stored before versioning.
+	Version int `json:"version,omitempty"`
+
+	MattermostUserID string `json:"mattermost_user_id,omitempty"`
+
 	Email  string `json:"email"`
 	RoomID string `json:"room_id"`
+
+	// EncryptedToken is the user's Webex OAuth token, encrypted with the configured EncryptionKey.
+	EncryptedToken string `json:"encrypted_token,omitempty"`
+
+	// WebhookIDs are the Webex webhooks registered with the user's token, removed on disconnect.
+	WebhookIDs []string `json:"webhook_ids,omitempty"`
+
+	// TranscriptsUnauthorized is set once Webex refused the user's token access to transcripts, which connecting the
+	// account again grants.
+	TranscriptsUnauthorized bool `json:"transcripts_unauthorized,omitempty"`
+
+	// ReminderMinutes is how long before scheduled meetings the user is reminded. Nil uses the default, 0 disables.
+	ReminderMinutes *int `json:"reminder_minutes,omitempty"`
+
+	// unknownFields are the fields this version of the plugin doesn't know, written by a newer one. They are stored
+	// back as they were, so they aren't lost when different versions of the plugin run during an upgrade or a downgrade.
+	unknownFields map[string]json.RawMessage
+}
+
+// userInfoFields is the serialization of UserInfo, without its custom methods.
+type userInfoFields UserInfo
+
+// knownUserInfoFields are the JSON names of the fields of UserInfo.
+var knownUserInfoFields = jsonFieldNames(reflect.TypeOf(UserInfo{}))
+
+func (u UserInfo) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(userInfoFields(u))
+	if err != nil || len(u.unknownFields) == 0 {
+		return data, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range u.unknownFields {
+		if !knownUserInfoFields[name] {
+			fields[name] = value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+func (u *UserInfo) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*userInfoFields)(u)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	u.unknownFields = nil
+	for name, value := range fields {
+		if !knownUserInfoFields[name] {
+			if u.unknownFields == nil {
+				u.unknownFields = map[string]json.RawMessage{}
+			}
+			u.unknownFields[name] = value
+		}
+	}
+	return nil
+}
+
+// jsonFieldNames returns the names of the fields of the struct t once serialized.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
 }
 
 func (p *Plugin) getEmailAndUserName(mattermostUserID string) (string, string, error) {
+	user, err := p.getUser(mattermostUserID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return user.Email, user.Username, nil
+}
+
+func (p *Plugin) getUser(mattermostUserID string) (*model.User, error) {
 	user, appErr := p.API.GetUser(mattermostUserID)
 	if appErr != nil {
 		p.errorf("error getting mattermost user from mattermostUserID: %s", mattermostUserID)
-		return "", "", errors.New("error getting mattermost user from mattermostUserID, please contact your system administrator")
+		return nil, errors.New("error getting mattermost user from mattermostUserID, please contact your system administrator")
 	}
 
-	return user.Email, user.Username, nil
+	return user, nil
 }
 
 func (p *Plugin) getRoomOrDefault(mattermostUserID string) (string, error) {
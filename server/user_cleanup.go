@@ -0,0 +1,168 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	userSweepJobKey   = "user_sweep_job"
+	userSweepInterval = 24 * time.Hour
+)
+
+// UserHasBeenDeactivated disconnects the Webex account of the deactivated user right away.
+func (p *Plugin) UserHasBeenDeactivated(_ *plugin.Context, user *model.User) {
+	p.disconnectDeactivatedUser(user.Id)
+}
+
+// UserHasLoggedIn keeps the email stored in the user's settings in sync with their account.
+func (p *Plugin) UserHasLoggedIn(_ *plugin.Context, user *model.User) {
+	p.syncUserEmail(user)
+}
+
+// removeUser deletes the settings of a deleted user: their room, their Webex token and webhooks, and the cached
+// lookups of their Personal Room. Lookups cached under the identity of a deleted account expire on their own.
+func (p *Plugin) removeUser(mattermostUserID string) {
+	userInfo, err := p.store.LoadUserInfo(mattermostUserID)
+	if err == ErrUserNotFound {
+		return
+	}
+	if err != nil {
+		p.errorf("error loading user info for mattermostUserID: %s, error: %v", mattermostUserID, err)
+		return
+	}
+
+	p.deleteDepartedUserWebhooks(mattermostUserID, userInfo)
+	p.invalidatePMRCache(mattermostUserID, userInfo.RoomID)
+
+	if err = p.store.DeleteUserInfo(mattermostUserID); err != nil {
+		p.errorf("error removing user info for mattermostUserID: %s, error: %v", mattermostUserID, err)
+		return
+	}
+	p.API.LogInfo("Removed the Webex settings of a departed user", "user_id", mattermostUserID)
+}
+
+// disconnectDeactivatedUser deletes the Webex token and webhooks of a deactivated user. Their room and preferences,
+// which an admin may have set, are kept for when they are reactivated.
+func (p *Plugin) disconnectDeactivatedUser(mattermostUserID string) {
+	userInfo, err := p.store.LoadUserInfo(mattermostUserID)
+	if err == ErrUserNotFound {
+		return
+	}
+	if err != nil {
+		p.errorf("error loading user info for mattermostUserID: %s, error: %v", mattermostUserID, err)
+		return
+	}
+	if userInfo.EncryptedToken == "" && len(userInfo.WebhookIDs) == 0 {
+		return
+	}
+
+	p.deleteDepartedUserWebhooks(mattermostUserID, userInfo)
+
+	err = p.store.UpdateUserInfo(mattermostUserID, func(info *UserInfo) error {
+		info.EncryptedToken = ""
+		info.WebhookIDs = nil
+		info.TranscriptsUnauthorized = false
+		return nil
+	})
+	if err != nil {
+		p.errorf("error storing user info for mattermostUserID: %s, error: %v", mattermostUserID, err)
+		return
+	}
+	p.API.LogInfo("Disconnected the Webex account of a deactivated user", "user_id", mattermostUserID)
+}
+
+// deleteDepartedUserWebhooks removes the Webex webhooks of a deleted or deactivated user, logging any failure. The
+// token is used as it is, as storing a refreshed token fails for a deleted user.
+func (p *Plugin) deleteDepartedUserWebhooks(mattermostUserID string, userInfo UserInfo) {
+	if userInfo.EncryptedToken == "" || len(userInfo.WebhookIDs) == 0 {
+		return
+	}
+
+	token, err := p.decryptUserToken(mattermostUserID, userInfo)
+	if err != nil {
+		p.API.LogWarn("Not deleting the Webex webhooks of a departed user", "user_id", mattermostUserID, "error", err.Error())
+		return
+	}
+	p.deleteWebhooks(token, userInfo.WebhookIDs)
+}
+
+// syncUserEmail stores the current email of user in their settings when it has changed, and forgets the lookups of
+// their Personal Room cached under the previous one.
+func (p *Plugin) syncUserEmail(user *model.User) {
+	userInfo, err := p.store.LoadUserInfo(user.Id)
+	if err == ErrUserNotFound {
+		return
+	}
+	if err != nil {
+		p.errorf("error loading user info for mattermostUserID: %s, error: %v", user.Id, err)
+		return
+	}
+	if userInfo.Email == user.Email {
+		return
+	}
+
+	previousEmail := userInfo.Email
+	err = p.store.UpdateUserInfo(user.Id, func(info *UserInfo) error {
+		info.Email = user.Email
+		return nil
+	})
+	if err != nil {
+		p.errorf("error storing user info for mattermostUserID: %s, error: %v", user.Id, err)
+		return
+	}
+	p.deletePMRCacheEntries([]webexIdentity{{Username: user.Username, Email: previousEmail}}, nil)
+	p.invalidatePMRCache(user.Id)
+}
+
+// sweepUsers removes the settings of the users who were deleted, disconnects those who were deactivated, and syncs the
+// email of the others.
+// Mattermost doesn't tell plugins when users are deleted, so this is the only cleanup for them. It is run on a single
+// node of the cluster at a time.
+func (p *Plugin) sweepUsers() {
+	userInfos, err := p.store.LoadAllUserInfo()
+	if err != nil {
+		p.errorf("unable to load the user infos to sweep: %v", err)
+		return
+	}
+
+	for _, userInfo := range userInfos {
+		user, appErr := p.API.GetUser(userInfo.MattermostUserID)
+		switch {
+		case appErr != nil && appErr.StatusCode == http.StatusNotFound:
+			p.removeUser(userInfo.MattermostUserID)
+		case appErr != nil:
+			p.errorf("unable to get the user %s to sweep: %v", userInfo.MattermostUserID, appErr)
+		case user.DeleteAt != 0:
+			p.disconnectDeactivatedUser(user.Id)
+		default:
+			p.syncUserEmail(user)
+		}
+	}
+}
+
+func (p *Plugin) startUserSweepJob() {
+	job, err := cluster.Schedule(p.API, userSweepJobKey, cluster.MakeWaitForInterval(userSweepInterval), p.sweepUsers)
+	if err != nil {
+		p.errorf("unable to schedule the user sweep job: %v", err)
+		return
+	}
+	p.userSweepJob = job
+}
+
+func (p *Plugin) stopUserSweepJob() {
+	if p.userSweepJob == nil {
+		return
+	}
+	if err := p.userSweepJob.Close(); err != nil {
+		p.errorf("unable to close the user sweep job: %v", err)
+	}
+	p.userSweepJob = nil
+}
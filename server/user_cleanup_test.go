@@ -0,0 +1,122 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweepUsers(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("GetUser", "activeid").Return(&model.User{Id: "activeid", Username: "active", Email: "active@example.com"}, nil)
+	api.On("GetUser", "movedid").Return(&model.User{Id: "movedid", Username: "moved", Email: "moved@new.example.com"}, nil)
+	api.On("GetUser", "deactivatedid").Return(&model.User{Id: "deactivatedid", Username: "deactivated", DeleteAt: 1}, nil)
+	api.On("GetUser", "deletedid").Return(nil, &model.AppError{Message: "not found", StatusCode: http.StatusNotFound})
+	api.On("GetUser", "unavailableid").Return(nil, &model.AppError{Message: "unavailable", StatusCode: http.StatusInternalServerError})
+	api.On("LogError", mock.Anything).Return()
+	api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	s := newUserStore(map[string]UserInfo{
+		"activeid":      {MattermostUserID: "activeid", Email: "active@example.com"},
+		"movedid":       {MattermostUserID: "movedid", Email: "moved@old.example.com", RoomID: "moved.room"},
+		"deactivatedid": {MattermostUserID: "deactivatedid", Email: "deactivated@example.com", RoomID: "deactivated.room", EncryptedToken: "thetoken"},
+		"deletedid":     {MattermostUserID: "deletedid", Email: "deleted@example.com", RoomID: "deleted.room"},
+		"unavailableid": {MattermostUserID: "unavailableid", Email: "unavailable@example.com"},
+	})
+
+	p := newTestPlugin(api, &configuration{SiteHost: "site.webex.com"}, s, nil)
+	p.pmrCache.set(pmrCacheKey("site.webex.com", "", "moved", "moved@old.example.com"), PMRCacheEntry{}, time.Now())
+	p.pmrCache.set(pmrCacheKey("site.webex.com", "deleted.room", "", ""), PMRCacheEntry{}, time.Now())
+
+	p.sweepUsers()
+
+	assert.Equal(t, []string{"deletedid"}, s.deleted)
+	require.Contains(t, s.stored, "movedid")
+	assert.Equal(t, "moved@new.example.com", s.stored["movedid"].Email)
+	require.Contains(t, s.stored, "deactivatedid")
+	assert.Empty(t, s.stored["deactivatedid"].EncryptedToken, "the token of a deactivated user is deleted")
+	assert.Equal(t, "deactivated.room", s.stored["deactivatedid"].RoomID, "the room of a deactivated user is kept")
+	assert.Len(t, s.stored, 2)
+	assert.Empty(t, p.pmrCache.entries)
+}
+
+func TestRemoveUserDeletesWebhooks(t *testing.T) {
+	config := newOAuthConfiguration("")
+	// An expired token is used as it is, rather than refreshed and stored for a user who is gone.
+	encryptedToken := newEncryptedToken(t, config, &webex.Token{AccessToken: "theexpiredtoken", RefreshToken: "therefreshtoken", Expiry: time.Now().Add(-time.Hour)})
+
+	api := &plugintest.API{}
+	api.On("GetUser", "deletedid").Return(nil, &model.AppError{Message: "not found", StatusCode: http.StatusNotFound})
+	api.On("LogError", mock.Anything).Return()
+	api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	s := newUserStore(map[string]UserInfo{
+		"deletedid": {MattermostUserID: "deletedid", Email: "deleted@example.com", EncryptedToken: encryptedToken, WebhookIDs: []string{"w1", "w2"}},
+	})
+
+	var deleted []string
+	p := newTestPlugin(api, config, s, nil)
+	serveWebexAPI(t, p, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "Bearer theexpiredtoken", r.Header.Get("Authorization"))
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	p.removeUser("deletedid")
+
+	assert.Equal(t, []string{"/webhooks/w1", "/webhooks/w2"}, deleted)
+	assert.Empty(t, s.stored)
+	assert.Equal(t, []string{"deletedid"}, s.deleted)
+}
+
+func TestUserHasBeenDeactivated(t *testing.T) {
+	config := newOAuthConfiguration("")
+	encryptedToken := newEncryptedToken(t, config, &webex.Token{AccessToken: "thetoken", Expiry: time.Now().Add(time.Hour)})
+
+	api := &plugintest.API{}
+	api.On("LogInfo", "Disconnected the Webex account of a deactivated user", "user_id", "deactivatedid").Return()
+
+	reminderMinutes := 5
+	s := newUserStore(map[string]UserInfo{
+		"deactivatedid": {
+			MattermostUserID: "deactivatedid",
+			Email:            "deactivated@example.com",
+			RoomID:           "admin.set.room",
+			EncryptedToken:   encryptedToken,
+			WebhookIDs:       []string{"w1"},
+			ReminderMinutes:  &reminderMinutes,
+		},
+	})
+
+	var deleted []string
+	p := newTestPlugin(api, config, s, nil)
+	serveWebexAPI(t, p, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	p.UserHasBeenDeactivated(nil, &model.User{Id: "deactivatedid"})
+
+	assert.Equal(t, []string{"/webhooks/w1"}, deleted)
+	assert.Empty(t, s.deleted)
+	require.Contains(t, s.stored, "deactivatedid")
+	stored := s.stored["deactivatedid"]
+	assert.Empty(t, stored.EncryptedToken)
+	assert.Empty(t, stored.WebhookIDs)
+	assert.Equal(t, "admin.set.room", stored.RoomID)
+	assert.Equal(t, &reminderMinutes, stored.ReminderMinutes)
+	api.AssertExpectations(t)
+}
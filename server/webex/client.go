@@ -5,152 +5,444 @@ package webex
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
-	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 const (
-	StatusStarted = "STARTED"
-	StatusInvited = "INVITED"
+	StatusStarted   = "STARTED"
+	StatusInvited   = "INVITED"
+	StatusScheduled = "SCHEDULED"
+	StatusEnded     = "ENDED"
 )
 
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrSiteNotFound = errors.New("site not found")
+	ErrAuthRequired = errors.New("authentication required")
+)
+
+// Exception IDs returned by the XML API in header/response/exceptionID.
+const (
+	exceptionUserNotFound = "030001"
+	exceptionNoRecord     = "000015"
+)
+
+// XMLAPIError is a failed response of the XML API.
+type XMLAPIError struct {
+	Result      string
+	ExceptionID string
+	Reason      string
+
+	// Err is one of ErrUserNotFound, ErrSiteNotFound or ErrAuthRequired, when the failure was recognized.
+	Err error
+}
+
+func (e *XMLAPIError) Error() string {
+	message := e.Reason
+	if message == "" {
+		message = "request failed"
+	}
+	if e.ExceptionID != "" {
+		message += " (exception " + e.ExceptionID + ")"
+	}
+	if e.Err != nil {
+		message = e.Err.Error() + ": " + message
+	}
+	return message
+}
+
+func (e *XMLAPIError) Unwrap() error {
+	return e.Err
+}
+
+// newXMLAPIError classifies a failed response. The site and authentication failures don't have stable exception
+// IDs across Webex versions, so they are recognized from their reason.
+func newXMLAPIError(response Response) *XMLAPIError {
+	apiErr := &XMLAPIError{
+		Result:      response.Result,
+		ExceptionID: response.ExceptionID,
+		Reason:      response.Reason,
+	}
+
+	reason := strings.ToLower(response.Reason)
+	switch {
+	case response.ExceptionID == exceptionUserNotFound, response.ExceptionID == exceptionNoRecord:
+		apiErr.Err = ErrUserNotFound
+	case strings.Contains(reason, "site") && (strings.Contains(reason, "not found") || strings.Contains(reason, "not exist")):
+		apiErr.Err = ErrSiteNotFound
+	case strings.Contains(reason, "authenticat"), strings.Contains(reason, "log in"), strings.Contains(reason, "login"),
+		strings.Contains(reason, "access token"):
+		apiErr.Err = ErrAuthRequired
+	case strings.Contains(reason, "user") && strings.Contains(reason, "not found"):
+		apiErr.Err = ErrUserNotFound
+	}
+	return apiErr
+}
+
+const (
+	IdentifierRoomID   = "room ID"
+	IdentifierUsername = "username"
+	IdentifierEmail    = "email"
+)
+
+// LookupFailure is why looking up a Personal Room by one identifier failed.
+type LookupFailure struct {
+	// Identifier is one of IdentifierRoomID, IdentifierUsername or IdentifierEmail.
+	Identifier string
+	Value      string
+	Err        error
+}
+
+func (f LookupFailure) userCard() GetUserCard {
+	switch f.Identifier {
+	case IdentifierRoomID:
+		return GetUserCard{PersonalURL: f.Value}
+	case IdentifierUsername:
+		return GetUserCard{WebExID: f.Value}
+	}
+	return GetUserCard{Email: f.Value}
+}
+
+// LookupError is returned when none of the identifiers of a user resolved to a Personal Room.
+type LookupError struct {
+	Failures []LookupFailure
+}
+
+func (e *LookupError) Error() string {
+	messages := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		messages = append(messages, failure.Identifier+" `"+failure.Value+"`: "+failure.Err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *LookupError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		errs = append(errs, failure.Err)
+	}
+	return errs
+}
+
+// IsUserNotFound reports whether err says the user has no Personal Room, as opposed to a failure of the site or
+// of the request. A *LookupError only qualifies when each of its lookups found no user.
+func IsUserNotFound(err error) bool {
+	var lookupErr *LookupError
+	if errors.As(err, &lookupErr) {
+		for _, failure := range lookupErr.Failures {
+			if !IsUserNotFound(failure.Err) {
+				return false
+			}
+		}
+		return len(lookupErr.Failures) > 0
+	}
+	return errors.Is(err, ErrUserNotFound)
+}
+
 type Client interface {
-	GetPersonalMeetingRoomURL(roomID, username, email string) (string, error)
+	GetPersonalMeetingRoomURL(ctx context.Context, roomID, username, email string) (string, error)
+	GetPersonalMeetingRoom(ctx context.Context, roomID string) (*PMR, error)
+	CheckSite(ctx context.Context, email string) (*SiteCheck, error)
+}
+
+// SiteCheck is how a site responded to a sample GetUserCard request, to diagnose its configuration.
+type SiteCheck struct {
+	URL      string
+	SiteName string
+	Latency  time.Duration
+	Response Response
+
+	// PMRUrl is the Personal Room of the sample user, if found.
+	PMRUrl string
+}
+
+// Failure returns why the site refused the request, or nil when it succeeded.
+func (c *SiteCheck) Failure() *XMLAPIError {
+	if c.Response.Result == ResultSuccess {
+		return nil
+	}
+	return newXMLAPIError(c.Response)
+}
+
+// ClientOptions configures the XML API client. Zero values are replaced by the defaults.
+type ClientOptions struct {
+	// HTTPClient sends the requests. The default client is shared by every Client so connections are reused.
+	HTTPClient *http.Client
+
+	// RequestTimeout bounds each attempt of a request.
+	RequestTimeout time.Duration
+
+	// LookupTimeout bounds a whole lookup, including its concurrent requests and their retries.
+	LookupTimeout time.Duration
+
+	// MaxRetries is the number of times a request is retried after a 429 or 5xx response. Negative disables retries.
+	MaxRetries int
+
+	// MinBackoff and MaxBackoff bound the exponential backoff between retries. A request for which Webex asks for a
+	// longer wait than MaxBackoff with Retry-After is not retried.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
 }
 
+const (
+	DefaultRequestTimeout = 10 * time.Second
+	DefaultLookupTimeout  = 20 * time.Second
+	DefaultMaxRetries     = 3
+	DefaultMinBackoff     = 500 * time.Millisecond
+	DefaultMaxBackoff     = 8 * time.Second
+)
+
+var defaultHTTPClient = &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+
 // Client represents a Webex API client
 type client struct {
-	httpClient *http.Client
-	xmlURL     string
-	siteName   string
+	httpClient    *http.Client
+	xmlURL        string
+	siteName      string
+	retry         retryPolicy
+	lookupTimeout time.Duration
 }
 
 // NewClient returns a new Webex XML API client.
-func NewClient(siteHost, siteName string) Client {
+func NewClient(siteHost, siteName string, options ClientOptions) Client {
 	webexURL := (&url.URL{
 		Scheme: "https",
 		Host:   siteHost,
 		Path:   "/WBXService/XMLService",
 	}).String()
 
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient
+	}
+
+	lookupTimeout := options.LookupTimeout
+	if lookupTimeout <= 0 {
+		lookupTimeout = DefaultLookupTimeout
+	}
+
 	return &client{
-		httpClient: &http.Client{},
-		xmlURL:     webexURL,
-		siteName:   siteName,
+		httpClient:    httpClient,
+		xmlURL:        webexURL,
+		siteName:      siteName,
+		retry:         newRetryPolicy(options),
+		lookupTimeout: lookupTimeout,
 	}
 }
 
-// GetPersonalMeetingRoomURL prefers roomID, username, and email for finding the PMR url (in that order).
-func (c *client) GetPersonalMeetingRoomURL(roomID, username, email string) (string, error) {
+// GetPersonalMeetingRoomURL prefers roomID, username, and email for finding the PMR url (in that order). The
+// lookups run concurrently, sharing the client's lookup timeout. When none succeeds, it returns a *LookupError
+// explaining why each of them failed.
+func (c *client) GetPersonalMeetingRoomURL(ctx context.Context, roomID, username, email string) (string, error) {
+	var lookups []LookupFailure
 	if roomID != "" {
-		pmrURL, err := c.getPMRFromRoomID(roomID)
-		if err == nil && pmrURL != "" {
-			return pmrURL, nil
-		}
+		lookups = append(lookups, LookupFailure{Identifier: IdentifierRoomID, Value: roomID})
 	}
 	if username != "" {
-		pmrURL, err := c.getPMRFromUserName(username)
-		if err == nil && pmrURL != "" {
-			return pmrURL, nil
-		}
+		lookups = append(lookups, LookupFailure{Identifier: IdentifierUsername, Value: username})
 	}
 	if email != "" {
-		pmrURL, err := c.getPMRFromEmail(email)
-		if err == nil && pmrURL != "" {
-			return pmrURL, nil
-		}
+		lookups = append(lookups, LookupFailure{Identifier: IdentifierEmail, Value: email})
+	}
+	if len(lookups) == 0 {
+		return "", ErrUserNotFound
 	}
 
-	return "", errors.New("couldn't get PMR url")
-}
+	ctx, cancel := context.WithTimeout(ctx, c.lookupTimeout)
+	defer cancel()
 
-const payloadWrapper = `<?xml version="1.0" encoding="UTF-8"?>
-<serv:message xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
-    <header>
-        <securityContext>
-            <siteName>%s</siteName>
-        </securityContext>
-    </header>
-    <body>
-        <bodyContent xsi:type="java:com.webex.service.binding.user.GetUserCard">
-            %s
-        </bodyContent>
-    </body>
-</serv:message>`
+	type result struct {
+		index  int
+		pmrURL string
+		err    error
+	}
+	results := make(chan result, len(lookups))
+	for i, lookup := range lookups {
+		go func() {
+			var pmrURL string
+			pmr, err := c.getPMR(ctx, lookup.userCard())
+			if err == nil {
+				pmrURL = pmr.PMRUrl
+			}
+			results <- result{index: i, pmrURL: pmrURL, err: err}
+		}()
+	}
 
-const roomIDContent = `<personalUrl>%s</personalUrl>`
-const webexIDContent = `<webExId>%s</webExId>`
-const emailContent = `<email>%s</email>`
+	pmrURLs := make([]string, len(lookups))
+	done := make([]bool, len(lookups))
+	for range lookups {
+		r := <-results
+		done[r.index] = true
+		pmrURLs[r.index] = r.pmrURL
+		lookups[r.index].Err = r.err
 
-// getPMRFromRoomID gets a Personal Meeting Room using a roomID, or returns an error if not found
-func (c *client) getPMRFromRoomID(roomID string) (string, error) {
-	content := fmt.Sprintf(roomIDContent, roomID)
-	return c.getPMR(content)
-}
+		// Return as soon as every lookup preferred over a success has failed.
+		for i := range lookups {
+			if !done[i] {
+				break
+			}
+			if lookups[i].Err == nil {
+				return pmrURLs[i], nil
+			}
+		}
+	}
 
-// getPMRFromroomID gets a Personal Meeting Room using a userName, or returns an error if not found
-func (c *client) getPMRFromUserName(userName string) (string, error) {
-	content := fmt.Sprintf(webexIDContent, userName)
-	return c.getPMR(content)
+	return "", &LookupError{Failures: lookups}
 }
 
-// getPMRFromroomID gets a Personal Meeting Room using an email, or returns an error if not found
-func (c *client) getPMRFromEmail(email string) (string, error) {
-	content := fmt.Sprintf(emailContent, email)
-	return c.getPMR(content)
+const (
+	xmlNSServ       = "http://www.webex.com/schemas/2002/06/service"
+	xmlNSXSI        = "http://www.w3.org/2001/XMLSchema-instance"
+	getUserCardType = "java:com.webex.service.binding.user.GetUserCard"
+)
+
+// GetPersonalMeetingRoom returns the Personal Room of roomID, with its title, within the client's lookup timeout.
+func (c *client) GetPersonalMeetingRoom(ctx context.Context, roomID string) (*PMR, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.lookupTimeout)
+	defer cancel()
+
+	lookup := LookupFailure{Identifier: IdentifierRoomID, Value: roomID}
+	pmr, err := c.getPMR(ctx, lookup.userCard())
+	if err != nil {
+		lookup.Err = err
+		return nil, &LookupError{Failures: []LookupFailure{lookup}}
+	}
+	return pmr, nil
 }
 
-// getPMR gets a Personal Meeting Room given the body content
-func (c *client) getPMR(content string) (string, error) {
-	payload := fmt.Sprintf(payloadWrapper, c.siteName, content)
-	buf, err := c.roundTrip(payload)
+// getPMR gets a Personal Meeting Room given the GetUserCard lookup, or returns an error if not found
+func (c *client) getPMR(ctx context.Context, lookup GetUserCard) (*PMR, error) {
+	payload, err := c.userCardPayload(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := c.roundTrip(ctx, payload)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var message GetPMRR
 	err = xml.Unmarshal(buf.Bytes(), &message)
 	if err != nil {
-		return "", err
+		return nil, errors.WithMessage(err, "failed to parse the response")
+	}
+
+	response := message.Header.Response
+	if response.Result != ResultSuccess {
+		return nil, newXMLAPIError(response)
 	}
 
-	return message.Body.BodyContent.PersonalMeetingRoom.PMRUrl, nil
+	pmr := message.Body.BodyContent.PersonalMeetingRoom
+	if pmr.PMRUrl == "" {
+		return nil, &XMLAPIError{Result: response.Result, Reason: "the user has no Personal Room", Err: ErrUserNotFound}
+	}
+
+	return &pmr, nil
 }
 
-func (c *client) roundTrip(payload string) (*bytes.Buffer, error) {
-	rq, err := http.NewRequest("POST", c.xmlURL, bytes.NewReader([]byte(payload)))
+// CheckSite looks email up once, without retrying, and reports how the site responded.
+func (c *client) CheckSite(ctx context.Context, email string) (*SiteCheck, error) {
+	payload, err := c.userCardPayload(GetUserCard{Email: email})
 	if err != nil {
 		return nil, err
 	}
-	rq.Header.Set("Content-Type", "text/xml")
-	rq.Close = true
 
-	rp, err := c.httpClient.Do(rq)
+	started := time.Now()
+	buf, _, err := c.roundTripOnce(ctx, payload)
+	check := &SiteCheck{URL: c.xmlURL, SiteName: c.siteName, Latency: time.Since(started)}
+	if err != nil {
+		return check, err
+	}
 
+	var message GetPMRR
+	if err = xml.Unmarshal(buf.Bytes(), &message); err != nil {
+		return check, errors.WithMessage(err, "failed to parse the response")
+	}
+	check.Response = message.Header.Response
+	check.PMRUrl = message.Body.BodyContent.PersonalMeetingRoom.PMRUrl
+	return check, nil
+}
+
+// userCardPayload returns the XML request looking lookup up.
+func (c *client) userCardPayload(lookup GetUserCard) ([]byte, error) {
+	lookup.Type = getUserCardType
+	payload, err := xml.Marshal(ServiceMessage{
+		XMLNSServ: xmlNSServ,
+		XMLNSXSI:  xmlNSXSI,
+		Header:    RequestHeader{SecurityContext: SecurityContext{SiteName: c.siteName}},
+		Body:      GetUserCardRQB{BodyContent: lookup},
+	})
 	if err != nil {
-		return nil, errors.WithMessagef(err, "failed request to %v", c.xmlURL)
-	} else if rp == nil {
-		return nil, errors.Errorf("received nil response when making request to %v", c.xmlURL)
+		return nil, err
 	}
+	return append([]byte(xml.Header), payload...), nil
+}
 
+// roundTrip posts payload to the XML API, retrying with backoff while Webex is throttling or unavailable.
+func (c *client) roundTrip(ctx context.Context, payload []byte) (*bytes.Buffer, error) {
+	for attempt := 0; ; attempt++ {
+		buf, retryAfter, err := c.roundTripOnce(ctx, payload)
+		if err == nil {
+			return buf, nil
+		}
+		if retryAfter < 0 || attempt >= c.retry.maxRetries {
+			return nil, err
+		}
+		backoff, ok := c.retry.backoff(attempt, retryAfter)
+		if !ok {
+			return nil, errors.WithMessagef(err, "not retrying, Webex asked to wait %v", retryAfter)
+		}
+		if waitErr := c.retry.wait(ctx, backoff); waitErr != nil {
+			return nil, errors.WithMessage(err, waitErr.Error())
+		}
+	}
+}
+
+// roundTripOnce makes a single attempt. The returned duration is negative when the request must not be retried,
+// and otherwise holds the wait Webex asked for, if any.
+func (c *client) roundTripOnce(ctx context.Context, payload []byte) (*bytes.Buffer, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.retry.requestTimeout)
+	defer cancel()
+
+	rq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.xmlURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, -1, err
+	}
+	rq.Header.Set("Content-Type", "text/xml")
+
+	rp, err := c.httpClient.Do(rq)
+	if err != nil {
+		return nil, -1, errors.WithMessagef(err, "failed request to %v", c.xmlURL)
+	}
 	defer func() { _ = rp.Body.Close() }()
 
 	if rp.StatusCode >= 300 {
-		return nil, errors.New("received status code above 300")
+		// Drain the body so the connection can be reused.
+		_, _ = io.Copy(io.Discard, io.LimitReader(rp.Body, maxDrainSize))
+
+		err = errors.Errorf("received status code %d from %v", rp.StatusCode, c.xmlURL)
+		if !isRetryableStatus(rp.StatusCode) {
+			return nil, -1, err
+		}
+		return nil, parseRetryAfter(rp.Header.Get("Retry-After"), time.Now()), err
 	}
 
 	buf := new(bytes.Buffer)
 	_, err = buf.ReadFrom(rp.Body)
 	if err != nil {
-		return nil, errors.Errorf("Failed to read response from %v", c.xmlURL)
+		return nil, -1, errors.Errorf("Failed to read response from %v", c.xmlURL)
 	}
 
-	return buf, nil
+	return buf, 0, nil
 }
 
 // For testing
@@ -158,7 +450,7 @@ type MockClient struct {
 	SiteHost string
 }
 
-func (mc MockClient) GetPersonalMeetingRoomURL(roomID, username, email string) (string, error) {
+func (mc MockClient) GetPersonalMeetingRoomURL(_ context.Context, roomID, username, email string) (string, error) {
 	room := roomID
 	if room == "" {
 		room = username
@@ -169,6 +461,23 @@ func (mc MockClient) GetPersonalMeetingRoomURL(roomID, username, email string) (
 	return "https://" + mc.SiteHost + "/meet/" + room, nil
 }
 
+func (mc MockClient) GetPersonalMeetingRoom(ctx context.Context, roomID string) (*PMR, error) {
+	pmrURL, err := mc.GetPersonalMeetingRoomURL(ctx, roomID, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return &PMR{Title: roomID + "'s Personal Room", PMRUrl: pmrURL}, nil
+}
+
+func (mc MockClient) CheckSite(_ context.Context, email string) (*SiteCheck, error) {
+	pmrURL, _ := mc.GetPersonalMeetingRoomURL(context.Background(), "", "", email)
+	return &SiteCheck{
+		URL:      "https://" + mc.SiteHost + "/WBXService/XMLService",
+		Response: Response{Result: ResultSuccess},
+		PMRUrl:   pmrURL,
+	}, nil
+}
+
 // only for testing
 func getUserFromEmail(email string) string {
 	ss := strings.Split(email, "@")
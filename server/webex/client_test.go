@@ -0,0 +1,363 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const successResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<serv:message xmlns:serv="http://www.webex.com/schemas/2002/06/service">
+    <serv:header>
+        <serv:response>
+            <serv:result>SUCCESS</serv:result>
+            <serv:gsbStatus>PRIMARY</serv:gsbStatus>
+        </serv:response>
+    </serv:header>
+    <serv:body>
+        <serv:bodyContent>
+            <use:personalMeetingRoom xmlns:use="http://www.webex.com/schemas/2002/06/service/user">
+                <use:title>Alice's Personal Room</use:title>
+                <use:personalMeetingRoomURL>https://site.webex.com/meet/%s</use:personalMeetingRoomURL>
+            </use:personalMeetingRoom>
+        </serv:bodyContent>
+    </serv:body>
+</serv:message>`
+
+const failureResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<serv:message xmlns:serv="http://www.webex.com/schemas/2002/06/service">
+    <serv:header>
+        <serv:response>
+            <serv:result>FAILURE</serv:result>
+            <serv:reason>%s</serv:reason>
+            <serv:gsbStatus>PRIMARY</serv:gsbStatus>
+            <serv:exceptionID>%s</serv:exceptionID>
+        </serv:response>
+    </serv:header>
+    <serv:body>
+        <serv:bodyContent/>
+    </serv:body>
+</serv:message>`
+
+// userCardRequest decodes the requests sent by the client.
+type userCardRequest struct {
+	XMLName xml.Name `xml:"message"`
+	Header  struct {
+		SiteName string `xml:"securityContext>siteName"`
+	} `xml:"header"`
+	Body struct {
+		BodyContent struct {
+			Type        string `xml:"type,attr"`
+			PersonalURL string `xml:"personalUrl"`
+			WebExID     string `xml:"webExId"`
+			Email       string `xml:"email"`
+		} `xml:"bodyContent"`
+	} `xml:"body"`
+}
+
+func newTestClient(t *testing.T, handler func(rq userCardRequest) string) Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rq userCardRequest
+		require.NoError(t, xml.NewDecoder(r.Body).Decode(&rq))
+		assert.Equal(t, "site", rq.Header.SiteName)
+		assert.Equal(t, getUserCardType, rq.Body.BodyContent.Type)
+		_, _ = fmt.Fprint(w, handler(rq))
+	}))
+	t.Cleanup(server.Close)
+
+	return &client{
+		httpClient:    server.Client(),
+		xmlURL:        server.URL,
+		siteName:      "site",
+		retry:         newRetryPolicy(ClientOptions{}),
+		lookupTimeout: DefaultLookupTimeout,
+	}
+}
+
+func TestGetPersonalMeetingRoomURL(t *testing.T) {
+	t.Run("escapes the lookup", func(t *testing.T) {
+		c := newTestClient(t, func(rq userCardRequest) string {
+			assert.Equal(t, "a<b&c>", rq.Body.BodyContent.PersonalURL)
+			return fmt.Sprintf(successResponse, "alice")
+		})
+
+		pmrURL, err := c.GetPersonalMeetingRoomURL(context.Background(), "a<b&c>", "", "")
+		require.NoError(t, err)
+		assert.Equal(t, "https://site.webex.com/meet/alice", pmrURL)
+	})
+
+	t.Run("falls back to the email", func(t *testing.T) {
+		c := newTestClient(t, func(rq userCardRequest) string {
+			if rq.Body.BodyContent.Email == "" {
+				return fmt.Sprintf(failureResponse, "Corresponding User not found", "030001")
+			}
+			return fmt.Sprintf(successResponse, "alice")
+		})
+
+		pmrURL, err := c.GetPersonalMeetingRoomURL(context.Background(), "", "alice.smith", "alice@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "https://site.webex.com/meet/alice", pmrURL)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		c := newTestClient(t, func(rq userCardRequest) string {
+			return fmt.Sprintf(failureResponse, "Corresponding User not found", "030001")
+		})
+
+		_, err := c.GetPersonalMeetingRoomURL(context.Background(), "", "alice", "alice@example.com")
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrUserNotFound))
+
+		var apiErr *XMLAPIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "030001", apiErr.ExceptionID)
+		assert.Equal(t, "Corresponding User not found", apiErr.Reason)
+	})
+
+	t.Run("site not found", func(t *testing.T) {
+		c := newTestClient(t, func(rq userCardRequest) string {
+			return fmt.Sprintf(failureResponse, "Site not found", "000035")
+		})
+
+		_, err := c.GetPersonalMeetingRoomURL(context.Background(), "alice", "alice", "alice@example.com")
+		assert.True(t, errors.Is(err, ErrSiteNotFound))
+		assert.False(t, IsUserNotFound(err))
+	})
+
+	t.Run("prefers the room ID", func(t *testing.T) {
+		c := newTestClient(t, func(rq userCardRequest) string {
+			if rq.Body.BodyContent.PersonalURL == "" {
+				return fmt.Sprintf(successResponse, "alice.email")
+			}
+			time.Sleep(50 * time.Millisecond)
+			return fmt.Sprintf(successResponse, "alice.room")
+		})
+
+		pmrURL, err := c.GetPersonalMeetingRoomURL(context.Background(), "alice", "alice", "alice@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "https://site.webex.com/meet/alice.room", pmrURL)
+	})
+
+	t.Run("doesn't wait for the lower priority lookups", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		c := newTestClient(t, func(rq userCardRequest) string {
+			if rq.Body.BodyContent.PersonalURL == "" {
+				<-release
+			}
+			return fmt.Sprintf(successResponse, "alice.room")
+		})
+
+		pmrURL, err := c.GetPersonalMeetingRoomURL(context.Background(), "alice", "", "alice@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "https://site.webex.com/meet/alice.room", pmrURL)
+	})
+
+	t.Run("explains each failure", func(t *testing.T) {
+		c := newTestClient(t, func(rq userCardRequest) string {
+			if rq.Body.BodyContent.Email != "" {
+				return `<not xml`
+			}
+			return fmt.Sprintf(failureResponse, "Corresponding User not found", "030001")
+		})
+
+		_, err := c.GetPersonalMeetingRoomURL(context.Background(), "", "alice", "alice@example.com")
+		var lookupErr *LookupError
+		require.True(t, errors.As(err, &lookupErr))
+		require.Len(t, lookupErr.Failures, 2)
+		assert.Equal(t, IdentifierUsername, lookupErr.Failures[0].Identifier)
+		assert.True(t, errors.Is(lookupErr.Failures[0].Err, ErrUserNotFound))
+		assert.Equal(t, IdentifierEmail, lookupErr.Failures[1].Identifier)
+		assert.Contains(t, err.Error(), "username `alice`: user not found")
+		assert.Contains(t, err.Error(), "email `alice@example.com`: failed to parse the response")
+		assert.False(t, IsUserNotFound(err), "a failed request doesn't mean the user doesn't exist")
+	})
+
+	t.Run("authentication required", func(t *testing.T) {
+		c := newTestClient(t, func(rq userCardRequest) string {
+			return fmt.Sprintf(failureResponse, "Authentication Server error", "030048")
+		})
+
+		_, err := c.GetPersonalMeetingRoomURL(context.Background(), "alice", "", "")
+		assert.True(t, errors.Is(err, ErrAuthRequired))
+	})
+}
+
+func TestRoundTrip(t *testing.T) {
+	newClient := func(t *testing.T, handler http.HandlerFunc, options ClientOptions) (*client, *[]time.Duration) {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+
+		var waits []time.Duration
+		c := &client{
+			httpClient: server.Client(),
+			xmlURL:     server.URL,
+			siteName:   "site",
+			retry:      newRetryPolicy(options),
+		}
+		c.retry.wait = func(_ context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		}
+		return c, &waits
+	}
+
+	t.Run("retries 5xx with exponential backoff", func(t *testing.T) {
+		requests := 0
+		c, waits := newClient(t, func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+			if requests < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = fmt.Fprint(w, "ok")
+		}, ClientOptions{MinBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+		buf, err := c.roundTrip(context.Background(), []byte("<payload/>"))
+		require.NoError(t, err)
+		assert.Equal(t, "ok", buf.String())
+		assert.Equal(t, 3, requests)
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *waits)
+	})
+
+	t.Run("honors Retry-After on 429", func(t *testing.T) {
+		requests := 0
+		c, waits := newClient(t, func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+			if requests == 1 {
+				w.Header().Set("Retry-After", "5")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			_, _ = fmt.Fprint(w, "ok")
+		}, ClientOptions{})
+
+		_, err := c.roundTrip(context.Background(), []byte("<payload/>"))
+		require.NoError(t, err)
+		assert.Equal(t, []time.Duration{5 * time.Second}, *waits)
+	})
+
+	t.Run("gives up when Retry-After is past the maximum backoff", func(t *testing.T) {
+		requests := 0
+		c, waits := newClient(t, func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}, ClientOptions{})
+
+		_, err := c.roundTrip(context.Background(), []byte("<payload/>"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not retrying")
+		assert.Equal(t, 1, requests)
+		assert.Empty(t, *waits)
+	})
+
+	t.Run("gives up after the retries", func(t *testing.T) {
+		requests := 0
+		c, _ := newClient(t, func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusBadGateway)
+		}, ClientOptions{MaxRetries: 2})
+
+		_, err := c.roundTrip(context.Background(), []byte("<payload/>"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "502")
+		assert.Equal(t, 3, requests)
+	})
+
+	t.Run("doesn't retry other errors", func(t *testing.T) {
+		requests := 0
+		c, _ := newClient(t, func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusForbidden)
+		}, ClientOptions{})
+
+		_, err := c.roundTrip(context.Background(), []byte("<payload/>"))
+		require.Error(t, err)
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("times out a hung request", func(t *testing.T) {
+		c, _ := newClient(t, func(_ http.ResponseWriter, r *http.Request) {
+			// The request context is only canceled once the body has been read.
+			_, _ = io.ReadAll(r.Body)
+			<-r.Context().Done()
+		}, ClientOptions{RequestTimeout: 50 * time.Millisecond})
+
+		started := time.Now()
+		_, err := c.roundTrip(context.Background(), []byte("<payload/>"))
+		require.Error(t, err)
+		assert.Less(t, time.Since(started), 5*time.Second)
+	})
+
+	t.Run("reuses connections", func(t *testing.T) {
+		remoteAddrs := map[string]bool{}
+		c, _ := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			remoteAddrs[r.RemoteAddr] = true
+			_, _ = fmt.Fprint(w, "ok")
+		}, ClientOptions{})
+
+		for i := 0; i < 3; i++ {
+			_, err := c.roundTrip(context.Background(), []byte("<payload/>"))
+			require.NoError(t, err)
+		}
+		assert.Len(t, remoteAddrs, 1)
+	})
+}
+
+func TestCheckSite(t *testing.T) {
+	t.Run("reports the response", func(t *testing.T) {
+		c := newTestClient(t, func(rq userCardRequest) string {
+			assert.Equal(t, "alice@example.com", rq.Body.BodyContent.Email)
+			return fmt.Sprintf(successResponse, "alice")
+		})
+
+		check, err := c.CheckSite(context.Background(), "alice@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, ResultSuccess, check.Response.Result)
+		assert.Equal(t, "https://site.webex.com/meet/alice", check.PMRUrl)
+		assert.Nil(t, check.Failure())
+	})
+
+	t.Run("reports the failure", func(t *testing.T) {
+		c := newTestClient(t, func(rq userCardRequest) string {
+			return fmt.Sprintf(failureResponse, "Site not found", "000035")
+		})
+
+		check, err := c.CheckSite(context.Background(), "alice@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, ResultFailure, check.Response.Result)
+		assert.Equal(t, "000035", check.Response.ExceptionID)
+		assert.True(t, errors.Is(check.Failure(), ErrSiteNotFound))
+	})
+}
+
+func TestGetPersonalMeetingRoom(t *testing.T) {
+	c := newTestClient(t, func(rq userCardRequest) string {
+		if rq.Body.BodyContent.PersonalURL == "alice" {
+			return fmt.Sprintf(successResponse, "alice")
+		}
+		return fmt.Sprintf(failureResponse, "Corresponding User not found", "030001")
+	})
+
+	pmr, err := c.GetPersonalMeetingRoom(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice's Personal Room", pmr.Title)
+	assert.Equal(t, "https://site.webex.com/meet/alice", pmr.PMRUrl)
+
+	_, err = c.GetPersonalMeetingRoom(context.Background(), "typo")
+	assert.True(t, IsUserNotFound(err))
+	assert.Contains(t, err.Error(), "room ID `typo`: user not found")
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Invitee is a meeting invitee, identified by their email.
+type Invitee struct {
+	Email       string `json:"email"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// CreateMeetingRequest describes a meeting to be scheduled. A zero Start creates a meeting starting now.
+type CreateMeetingRequest struct {
+	Title    string
+	Agenda   string
+	Password string
+	Start    time.Time
+	Duration time.Duration
+	Invitees []Invitee
+}
+
+type createMeetingBody struct {
+	Title    string    `json:"title"`
+	Agenda   string    `json:"agenda,omitempty"`
+	Password string    `json:"password,omitempty"`
+	Start    string    `json:"start"`
+	End      string    `json:"end"`
+	Invitees []Invitee `json:"invitees,omitempty"`
+}
+
+// Meeting is a Webex meeting as returned by the Meetings REST API.
+type Meeting struct {
+	ID              string    `json:"id"`
+	MeetingNumber   string    `json:"meetingNumber"`
+	Title           string    `json:"title"`
+	Agenda          string    `json:"agenda"`
+	Password        string    `json:"password"`
+	State           string    `json:"state"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	WebLink         string    `json:"webLink"`
+	HostEmail       string    `json:"hostEmail"`
+	HostDisplayName string    `json:"hostDisplayName"`
+	HostUserID      string    `json:"hostUserId"`
+}
+
+const (
+	MeetingStateActive     = "active"
+	MeetingStateScheduled  = "scheduled"
+	MeetingStateReady      = "ready"
+	MeetingStateInProgress = "inProgress"
+	MeetingStateEnded      = "ended"
+	MeetingStateMissed     = "missed"
+	MeetingStateExpired    = "expired"
+
+	maxListedMeetings = 100
+)
+
+// JoinLinks are the personalized links for a meeting.
+type JoinLinks struct {
+	JoinLink  string `json:"joinLink"`
+	StartLink string `json:"startLink"`
+}
+
+const defaultMeetingDuration = time.Hour
+
+// CreateMeeting schedules a new meeting hosted by the owner of token.
+func (c *restClient) CreateMeeting(ctx context.Context, token string, meeting CreateMeetingRequest) (*Meeting, error) {
+	start := meeting.Start
+	if start.IsZero() {
+		start = time.Now()
+	}
+	duration := meeting.Duration
+	if duration <= 0 {
+		duration = defaultMeetingDuration
+	}
+
+	body := createMeetingBody{
+		Title:    meeting.Title,
+		Agenda:   meeting.Agenda,
+		Password: meeting.Password,
+		Start:    start.UTC().Format(time.RFC3339),
+		End:      start.Add(duration).UTC().Format(time.RFC3339),
+		Invitees: meeting.Invitees,
+	}
+
+	created := &Meeting{}
+	if err := c.do(ctx, token, http.MethodPost, "/meetings", body, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetJoinLinks returns the join and start links of meetingID for the owner of token.
+func (c *restClient) GetJoinLinks(ctx context.Context, token, meetingID string) (*JoinLinks, error) {
+	links := &JoinLinks{}
+	body := map[string]interface{}{
+		"meetingId":    meetingID,
+		"joinDirectly": false,
+	}
+	if err := c.do(ctx, token, http.MethodPost, "/meetings/join", body, links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// ListMeetings returns the meeting occurrences of the owner of token between from and to, as host or invitee.
+func (c *restClient) ListMeetings(ctx context.Context, token string, from, to time.Time) ([]Meeting, error) {
+	query := url.Values{
+		"meetingType": {"scheduledMeeting"},
+		"from":        {from.UTC().Format(time.RFC3339)},
+		"to":          {to.UTC().Format(time.RFC3339)},
+		"max":         {strconv.Itoa(maxListedMeetings)},
+	}
+
+	var list struct {
+		Items []Meeting `json:"items"`
+	}
+	if err := c.do(ctx, token, http.MethodGet, "/meetings?"+query.Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// GetMeeting returns meetingID, which must be visible to the owner of token.
+func (c *restClient) GetMeeting(ctx context.Context, token, meetingID string) (*Meeting, error) {
+	meeting := &Meeting{}
+	if err := c.do(ctx, token, http.MethodGet, meetingPath(meetingID), nil, meeting); err != nil {
+		return nil, err
+	}
+	return meeting, nil
+}
+
+// EndMeeting ends the in-progress meetingID, which must be hosted by the owner of token.
+func (c *restClient) EndMeeting(ctx context.Context, token, meetingID string) error {
+	return c.do(ctx, token, http.MethodPost, meetingPath(meetingID)+"/end", nil, nil)
+}
+
+func meetingPath(meetingID string) string {
+	return "/meetings/" + url.PathEscape(meetingID)
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateMeeting(t *testing.T) {
+	start := time.Date(2026, 10, 20, 15, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer thetoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = fmt.Fprint(w, `{"message":"The request requires a valid access token set in the Authorization request header.","trackingId":"track1"}`)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/meetings":
+			var body createMeetingBody
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "Standup", body.Title)
+			assert.Equal(t, "secret", body.Password)
+			assert.Equal(t, "2026-10-20T15:00:00Z", body.Start)
+			assert.Equal(t, "2026-10-20T15:15:00Z", body.End)
+			assert.Equal(t, []Invitee{{Email: "bob@example.com"}}, body.Invitees)
+			_, _ = fmt.Fprint(w, `{"id":"m1","meetingNumber":"123456789","title":"Standup","password":"secret","state":"active","start":"2026-10-20T15:00:00Z","end":"2026-10-20T15:15:00Z","webLink":"https://site.webex.com/site/j.php?MTID=m1"}`)
+		case "/meetings/join":
+			_, _ = fmt.Fprint(w, `{"joinLink":"https://site.webex.com/join/m1","startLink":"https://site.webex.com/start/m1"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewRESTClient(server.URL, server.Client())
+
+	meeting, err := client.CreateMeeting(context.Background(), "thetoken", CreateMeetingRequest{
+		Title:    "Standup",
+		Password: "secret",
+		Start:    start,
+		Duration: 15 * time.Minute,
+		Invitees: []Invitee{{Email: "bob@example.com"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "m1", meeting.ID)
+	assert.Equal(t, "123456789", meeting.MeetingNumber)
+	assert.True(t, start.Equal(meeting.Start))
+
+	links, err := client.GetJoinLinks(context.Background(), "thetoken", meeting.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://site.webex.com/start/m1", links.StartLink)
+
+	_, err = client.CreateMeeting(context.Background(), "badtoken", CreateMeetingRequest{Title: "Standup"})
+	require.Error(t, err)
+	apiErr, ok := err.(*APIError)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, "track1", apiErr.TrackingID)
+}
+
+func TestListMeetings(t *testing.T) {
+	from := time.Date(2026, 10, 20, 4, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/meetings", r.URL.Path)
+		assert.Equal(t, "scheduledMeeting", r.URL.Query().Get("meetingType"))
+		assert.Equal(t, "2026-10-20T04:00:00Z", r.URL.Query().Get("from"))
+		assert.Equal(t, "2026-10-21T04:00:00Z", r.URL.Query().Get("to"))
+		_, _ = fmt.Fprint(w, `{"items":[{"id":"m1","title":"Standup","state":"inProgress"},{"id":"m2","title":"Retro","state":"scheduled"}]}`)
+	}))
+	defer server.Close()
+
+	meetings, err := NewRESTClient(server.URL, server.Client()).ListMeetings(context.Background(), "thetoken", from, to)
+	require.NoError(t, err)
+	require.Len(t, meetings, 2)
+	assert.Equal(t, MeetingStateInProgress, meetings[0].State)
+	assert.Equal(t, "Retro", meetings[1].Title)
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultAuthURL  = "https://webexapis.com/v1/authorize"
+	DefaultTokenURL = "https://webexapis.com/v1/access_token"
+
+	// expiryDelta is subtracted from the access token's lifetime so a token is refreshed slightly
+	// before Webex would start rejecting it.
+	expiryDelta = time.Minute
+)
+
+// OAuthConfig describes a Webex integration used for the OAuth 2.0 authorization-code flow.
+type OAuthConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// AuthURL and TokenURL default to the public Webex endpoints when empty.
+	AuthURL  string
+	TokenURL string
+}
+
+// Token holds the credentials returned by Webex for a user.
+type Token struct {
+	AccessToken        string    `json:"access_token"`
+	RefreshToken       string    `json:"refresh_token"`
+	Expiry             time.Time `json:"expiry"`
+	RefreshTokenExpiry time.Time `json:"refresh_token_expiry"`
+}
+
+// Valid reports whether the access token is present and not about to expire.
+func (t *Token) Valid() bool {
+	return t != nil && t.AccessToken != "" && time.Now().Add(expiryDelta).Before(t.Expiry)
+}
+
+// CanRefresh reports whether the refresh token can still be used to obtain a new access token.
+func (t *Token) CanRefresh() bool {
+	if t == nil || t.RefreshToken == "" {
+		return false
+	}
+	return t.RefreshTokenExpiry.IsZero() || time.Now().Before(t.RefreshTokenExpiry)
+}
+
+type tokenResponse struct {
+	AccessToken           string `json:"access_token"`
+	ExpiresIn             int64  `json:"expires_in"`
+	RefreshToken          string `json:"refresh_token"`
+	RefreshTokenExpiresIn int64  `json:"refresh_token_expires_in"`
+	Error                 string `json:"error"`
+	ErrorDescription      string `json:"error_description"`
+}
+
+func (c *OAuthConfig) authURL() string {
+	if c.AuthURL != "" {
+		return c.AuthURL
+	}
+	return DefaultAuthURL
+}
+
+func (c *OAuthConfig) tokenURL() string {
+	if c.TokenURL != "" {
+		return c.TokenURL
+	}
+	return DefaultTokenURL
+}
+
+// AuthCodeURL returns the URL the user should be sent to in order to grant the plugin access.
+func (c *OAuthConfig) AuthCodeURL(state string) string {
+	v := url.Values{
+		"response_type": {"code"},
+		"client_id":     {c.ClientID},
+		"redirect_uri":  {c.RedirectURL},
+		"scope":         {strings.Join(c.Scopes, " ")},
+		"state":         {state},
+	}
+	return c.authURL() + "?" + v.Encode()
+}
+
+// Exchange trades an authorization code for a token.
+func (c *OAuthConfig) Exchange(httpClient *http.Client, code string) (*Token, error) {
+	return c.requestToken(httpClient, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {c.RedirectURL},
+	})
+}
+
+// Refresh obtains a new access token using a refresh token.
+func (c *OAuthConfig) Refresh(httpClient *http.Client, refreshToken string) (*Token, error) {
+	return c.requestToken(httpClient, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+func (c *OAuthConfig) requestToken(httpClient *http.Client, v url.Values) (*Token, error) {
+	v.Set("client_id", c.ClientID)
+	v.Set("client_secret", c.ClientSecret)
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	rp, err := httpClient.PostForm(c.tokenURL(), v)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed request to %v", c.tokenURL())
+	}
+	defer func() { _ = rp.Body.Close() }()
+
+	var tr tokenResponse
+	if err = json.NewDecoder(rp.Body).Decode(&tr); err != nil {
+		return nil, errors.Wrap(err, "failed to decode token response")
+	}
+
+	if rp.StatusCode >= 300 || tr.Error != "" {
+		return nil, errors.Errorf("token request failed with status %d: %s %s", rp.StatusCode, tr.Error, tr.ErrorDescription)
+	}
+	if tr.AccessToken == "" {
+		return nil, errors.New("token response did not contain an access token")
+	}
+
+	now := time.Now()
+	token := &Token{
+		AccessToken:  tr.AccessToken,
+		RefreshToken: tr.RefreshToken,
+		Expiry:       now.Add(time.Duration(tr.ExpiresIn) * time.Second),
+	}
+	if tr.RefreshTokenExpiresIn > 0 {
+		token.RefreshTokenExpiry = now.Add(time.Duration(tr.RefreshTokenExpiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOAuthServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Form.Get("client_id") != "theclientid" || r.Form.Get("client_secret") != "thesecret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = fmt.Fprint(w, `{"error":"invalid_client"}`)
+			return
+		}
+
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			if r.Form.Get("code") != "thecode" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprint(w, `{"error":"invalid_grant","error_description":"bad code"}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"access_token":"access1","expires_in":3600,"refresh_token":"refresh1","refresh_token_expires_in":7200}`)
+		case "refresh_token":
+			if r.Form.Get("refresh_token") != "refresh1" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprint(w, `{"error":"invalid_grant"}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"access_token":"access2","expires_in":3600,"refresh_token":"refresh2"}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, `{"error":"unsupported_grant_type"}`)
+		}
+	}))
+}
+
+func TestOAuthConfig(t *testing.T) {
+	server := newTestOAuthServer(t)
+	defer server.Close()
+
+	config := &OAuthConfig{
+		ClientID:     "theclientid",
+		ClientSecret: "thesecret",
+		RedirectURL:  "https://mm.example.com/plugins/com.mattermost.webex/oauth2/complete",
+		Scopes:       []string{"meeting:schedules_read", "meeting:schedules_write"},
+		TokenURL:     server.URL,
+	}
+
+	t.Run("auth code URL", func(t *testing.T) {
+		u, err := url.Parse(config.AuthCodeURL("thestate"))
+		require.NoError(t, err)
+		assert.Equal(t, "webexapis.com", u.Host)
+		assert.Equal(t, "theclientid", u.Query().Get("client_id"))
+		assert.Equal(t, "thestate", u.Query().Get("state"))
+		assert.Equal(t, "meeting:schedules_read meeting:schedules_write", u.Query().Get("scope"))
+		assert.Equal(t, config.RedirectURL, u.Query().Get("redirect_uri"))
+	})
+
+	t.Run("exchange", func(t *testing.T) {
+		token, err := config.Exchange(server.Client(), "thecode")
+		require.NoError(t, err)
+		assert.Equal(t, "access1", token.AccessToken)
+		assert.Equal(t, "refresh1", token.RefreshToken)
+		assert.True(t, token.Valid())
+		assert.True(t, token.CanRefresh())
+		assert.WithinDuration(t, time.Now().Add(time.Hour), token.Expiry, time.Minute)
+	})
+
+	t.Run("exchange with a bad code", func(t *testing.T) {
+		_, err := config.Exchange(server.Client(), "badcode")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "bad code")
+	})
+
+	t.Run("refresh", func(t *testing.T) {
+		token, err := config.Refresh(server.Client(), "refresh1")
+		require.NoError(t, err)
+		assert.Equal(t, "access2", token.AccessToken)
+		assert.Equal(t, "refresh2", token.RefreshToken)
+		assert.True(t, token.RefreshTokenExpiry.IsZero())
+	})
+
+	t.Run("bad client credentials", func(t *testing.T) {
+		badConfig := *config
+		badConfig.ClientSecret = "wrong"
+		_, err := badConfig.Refresh(server.Client(), "refresh1")
+		require.Error(t, err)
+	})
+}
+
+func TestTokenValid(t *testing.T) {
+	var nilToken *Token
+	assert.False(t, nilToken.Valid())
+	assert.False(t, (&Token{AccessToken: "a", Expiry: time.Now().Add(30 * time.Second)}).Valid())
+	assert.True(t, (&Token{AccessToken: "a", Expiry: time.Now().Add(time.Hour)}).Valid())
+	assert.False(t, (&Token{RefreshToken: "r", RefreshTokenExpiry: time.Now().Add(-time.Hour)}).CanRefresh())
+}
@@ -0,0 +1,25 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"context"
+	"net/http"
+)
+
+// Person is a Webex user.
+type Person struct {
+	ID          string   `json:"id"`
+	Emails      []string `json:"emails"`
+	DisplayName string   `json:"displayName"`
+}
+
+// GetMe returns the user owning token.
+func (c *restClient) GetMe(ctx context.Context, token string) (*Person, error) {
+	var person Person
+	if err := c.do(ctx, token, http.MethodGet, "/people/me", nil, &person); err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
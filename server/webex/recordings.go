@@ -0,0 +1,53 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	RecordingStatusAvailable = "available"
+
+	maxListedRecordings = 100
+)
+
+// Recording is a meeting recording as returned by the Recordings REST API.
+type Recording struct {
+	ID              string    `json:"id"`
+	MeetingID       string    `json:"meetingId"`
+	Topic           string    `json:"topic"`
+	PlaybackURL     string    `json:"playbackUrl"`
+	DownloadURL     string    `json:"downloadUrl"`
+	Password        string    `json:"password"`
+	DurationSeconds int       `json:"durationSeconds"`
+	Status          string    `json:"status"`
+	CreateTime      time.Time `json:"createTime"`
+}
+
+// Duration returns the length of the recording.
+func (r *Recording) Duration() time.Duration {
+	return time.Duration(r.DurationSeconds) * time.Second
+}
+
+// ListRecordings returns the recordings of meetingID visible to the owner of token, which may be the ID of a meeting
+// series, of a scheduled meeting or of a meeting instance.
+func (c *restClient) ListRecordings(ctx context.Context, token, meetingID string) ([]Recording, error) {
+	query := url.Values{
+		"meetingId": {meetingID},
+		"max":       {strconv.Itoa(maxListedRecordings)},
+	}
+
+	var list struct {
+		Items []Recording `json:"items"`
+	}
+	if err := c.do(ctx, token, http.MethodGet, "/recordings?"+query.Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
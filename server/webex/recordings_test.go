@@ -0,0 +1,33 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListRecordings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/recordings", r.URL.Path)
+		assert.Equal(t, "series1_I_123", r.URL.Query().Get("meetingId"))
+		_, _ = fmt.Fprint(w, `{"items":[{"id":"r1","meetingId":"series1_I_123","topic":"Standup","playbackUrl":"https://site.webex.com/recordingservice/r1/playback","password":"secret","durationSeconds":754,"status":"available"}]}`)
+	}))
+	defer server.Close()
+
+	recordings, err := NewRESTClient(server.URL, server.Client()).ListRecordings(context.Background(), "thetoken", "series1_I_123")
+	require.NoError(t, err)
+	require.Len(t, recordings, 1)
+	assert.Equal(t, "https://site.webex.com/recordingservice/r1/playback", recordings[0].PlaybackURL)
+	assert.Equal(t, "secret", recordings[0].Password)
+	assert.Equal(t, RecordingStatusAvailable, recordings[0].Status)
+	assert.Equal(t, 12*time.Minute+34*time.Second, recordings[0].Duration())
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const DefaultAPIURL = "https://webexapis.com/v1"
+
+// RESTClient is a client for the Webex REST APIs. Every call is made on behalf of the user owning token, and gives
+// up when ctx is done.
+type RESTClient interface {
+	CreateMeeting(ctx context.Context, token string, meeting CreateMeetingRequest) (*Meeting, error)
+	GetJoinLinks(ctx context.Context, token, meetingID string) (*JoinLinks, error)
+	ListMeetings(ctx context.Context, token string, from, to time.Time) ([]Meeting, error)
+	GetMeeting(ctx context.Context, token, meetingID string) (*Meeting, error)
+	EndMeeting(ctx context.Context, token, meetingID string) error
+	ListRecordings(ctx context.Context, token, meetingID string) ([]Recording, error)
+	ListTranscripts(ctx context.Context, token, meetingID string) ([]Transcript, error)
+	DownloadTranscript(ctx context.Context, token, transcriptID string) ([]byte, error)
+	ListWebhooks(ctx context.Context, token string) ([]Webhook, error)
+	CreateWebhook(ctx context.Context, token string, webhook Webhook) (*Webhook, error)
+	DeleteWebhook(ctx context.Context, token, webhookID string) error
+	GetMe(ctx context.Context, token string) (*Person, error)
+}
+
+type restClient struct {
+	httpClient *http.Client
+	apiURL     string
+}
+
+// NewRESTClient returns a new Webex REST API client. apiURL defaults to DefaultAPIURL when empty.
+func NewRESTClient(apiURL string, httpClient *http.Client) RESTClient {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &restClient{
+		httpClient: httpClient,
+		apiURL:     apiURL,
+	}
+}
+
+// APIError is returned when Webex responds to a REST request with an error status.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"message"`
+	TrackingID string `json:"trackingId"`
+	Errors     []struct {
+		Description string `json:"description"`
+	} `json:"errors"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if len(e.Errors) > 0 && e.Errors[0].Description != "" {
+		msg = e.Errors[0].Description
+	}
+	return fmt.Sprintf("webex API error %d: %s (tracking id: %s)", e.StatusCode, msg, e.TrackingID)
+}
+
+// IsUnauthorized reports whether err is Webex refusing a request on behalf of a user, such as a user whose token
+// lacks the scope of the request.
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden
+}
+
+// do sends a request to path with in encoded as JSON, and decodes the response into out when it is not nil.
+func (c *restClient) do(ctx context.Context, token, method, path string, in, out interface{}) error {
+	rp, err := c.send(ctx, token, method, path, "application/json", in)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rp.Body.Close() }()
+
+	if out == nil || rp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err = json.NewDecoder(rp.Body).Decode(out); err != nil {
+		return errors.Wrapf(err, "failed to decode response from %v", c.apiURL+path)
+	}
+	return nil
+}
+
+// download returns the body of the response to a GET request to path, which must not exceed maxSize bytes.
+func (c *restClient) download(ctx context.Context, token, path string, maxSize int64) ([]byte, error) {
+	rp, err := c.send(ctx, token, http.MethodGet, path, "*/*", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(rp.Body, maxSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read response from %v", c.apiURL+path)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errors.Errorf("response from %v exceeds %d bytes", c.apiURL+path, maxSize)
+	}
+	return data, nil
+}
+
+// send sends a request to path accepting the accept media type, with in encoded as JSON, and returns the response
+// when its status is successful. The caller must close the body of the response.
+func (c *restClient) send(ctx context.Context, token, method, path, accept string, in interface{}) (*http.Response, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	u := c.apiURL + path
+	rq, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	rq.Header.Set("Authorization", "Bearer "+token)
+	rq.Header.Set("Accept", accept)
+	if in != nil {
+		rq.Header.Set("Content-Type", "application/json")
+	}
+
+	rp, err := c.httpClient.Do(rq)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed request to %v", u)
+	}
+
+	if rp.StatusCode >= 300 {
+		defer func() { _ = rp.Body.Close() }()
+		apiErr := &APIError{StatusCode: rp.StatusCode}
+		_ = json.NewDecoder(rp.Body).Decode(apiErr)
+		return nil, apiErr
+	}
+	return rp, nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxDrainSize limits how much of an error response is read so its connection can be reused.
+const maxDrainSize = 64 << 10
+
+type retryPolicy struct {
+	requestTimeout time.Duration
+	maxRetries     int
+	minBackoff     time.Duration
+	maxBackoff     time.Duration
+
+	// wait sleeps for d, or returns early with an error when ctx is done. Replaced in tests.
+	wait func(ctx context.Context, d time.Duration) error
+}
+
+func newRetryPolicy(options ClientOptions) retryPolicy {
+	policy := retryPolicy{
+		requestTimeout: options.RequestTimeout,
+		maxRetries:     options.MaxRetries,
+		minBackoff:     options.MinBackoff,
+		maxBackoff:     options.MaxBackoff,
+		wait:           waitContext,
+	}
+	if policy.requestTimeout <= 0 {
+		policy.requestTimeout = DefaultRequestTimeout
+	}
+	if policy.maxRetries == 0 {
+		policy.maxRetries = DefaultMaxRetries
+	}
+	if policy.minBackoff <= 0 {
+		policy.minBackoff = DefaultMinBackoff
+	}
+	if policy.maxBackoff < policy.minBackoff {
+		policy.maxBackoff = max(DefaultMaxBackoff, policy.minBackoff)
+	}
+	return policy
+}
+
+// backoff returns how long to wait before retrying after the given attempt (starting at 0). The exponential
+// backoff is capped at maxBackoff. A longer retryAfter requested by Webex is honored up to maxBackoff: past it, false
+// is returned and the request gives up rather than retrying before Webex accepts it.
+func (p retryPolicy) backoff(attempt int, retryAfter time.Duration) (time.Duration, bool) {
+	if retryAfter > p.maxBackoff {
+		return 0, false
+	}
+	d := p.minBackoff
+	for i := 0; i < attempt && d < p.maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, p.maxBackoff)
+	return max(d, retryAfter), true
+}
+
+func waitContext(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return errors.Errorf("not retrying, the next attempt would be after the deadline")
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// parseRetryAfter parses a Retry-After header, either in seconds or as an HTTP date, returning 0 if it's missing
+// or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
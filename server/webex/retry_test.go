@@ -0,0 +1,56 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := newRetryPolicy(ClientOptions{MinBackoff: time.Second, MaxBackoff: 5 * time.Second})
+
+	assert.Equal(t, time.Second, backoffOf(policy.backoff(0, 0)))
+	assert.Equal(t, 2*time.Second, backoffOf(policy.backoff(1, 0)))
+	assert.Equal(t, 4*time.Second, backoffOf(policy.backoff(2, 0)))
+	assert.Equal(t, 5*time.Second, backoffOf(policy.backoff(3, 0)))
+	assert.Equal(t, 5*time.Second, backoffOf(policy.backoff(30, 0)))
+	assert.Equal(t, 4*time.Second, backoffOf(policy.backoff(0, 4*time.Second)))
+
+	_, ok := policy.backoff(0, time.Minute)
+	assert.False(t, ok, "a Retry-After past the maximum backoff is not waited for")
+}
+
+func backoffOf(d time.Duration, ok bool) time.Duration {
+	if !ok {
+		return -1
+	}
+	return d
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, 7*time.Second, parseRetryAfter("7", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-7", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
+
+func TestWaitContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.Error(t, waitContext(ctx, time.Minute), "waiting past the deadline")
+	assert.NoError(t, waitContext(ctx, time.Millisecond))
+
+	cancel()
+	assert.Error(t, waitContext(ctx, time.Millisecond))
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	TranscriptStatusAvailable = "available"
+
+	maxListedTranscripts = 100
+
+	// maxTranscriptSize bounds the size of downloaded transcripts. A VTT transcript of an 8 hour meeting is a few MB.
+	maxTranscriptSize = 32 << 20
+)
+
+// Transcript is a meeting transcript as returned by the Meeting Transcripts REST API.
+type Transcript struct {
+	ID        string    `json:"id"`
+	MeetingID string    `json:"meetingId"`
+	Topic     string    `json:"meetingTopic"`
+	Status    string    `json:"status"`
+	StartTime time.Time `json:"startTime"`
+}
+
+// ListTranscripts returns the transcripts of meetingID visible to the owner of token, which may be the ID of a
+// meeting series, of a scheduled meeting or of a meeting instance.
+func (c *restClient) ListTranscripts(ctx context.Context, token, meetingID string) ([]Transcript, error) {
+	query := url.Values{
+		"meetingId": {meetingID},
+		"max":       {strconv.Itoa(maxListedTranscripts)},
+	}
+
+	var list struct {
+		Items []Transcript `json:"items"`
+	}
+	if err := c.do(ctx, token, http.MethodGet, "/meetingTranscripts?"+query.Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// DownloadTranscript returns the WebVTT content of transcriptID. Consult ParseVTT to read it.
+func (c *restClient) DownloadTranscript(ctx context.Context, token, transcriptID string) ([]byte, error) {
+	return c.download(ctx, token, "/meetingTranscripts/"+url.PathEscape(transcriptID)+"/download?format=vtt", maxTranscriptSize)
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscripts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/meetingTranscripts":
+			assert.Equal(t, "series1_I_123", r.URL.Query().Get("meetingId"))
+			_, _ = fmt.Fprint(w, `{"items":[{"id":"t1","meetingId":"series1_I_123","meetingTopic":"Standup","status":"available"}]}`)
+		case "/meetingTranscripts/t1/download":
+			assert.Equal(t, "vtt", r.URL.Query().Get("format"))
+			w.Header().Set("Content-Type", "text/vtt")
+			_, _ = fmt.Fprint(w, "WEBVTT\n\n1 \"Alice\" (1)\n00:00:01.000 --> 00:00:02.000\nAlice: Hello.\n")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"message":"The requested resource could not be found.","trackingId":"track1"}`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewRESTClient(server.URL, server.Client())
+
+	transcripts, err := client.ListTranscripts(context.Background(), "thetoken", "series1_I_123")
+	require.NoError(t, err)
+	require.Len(t, transcripts, 1)
+	assert.Equal(t, "Standup", transcripts[0].Topic)
+	assert.Equal(t, TranscriptStatusAvailable, transcripts[0].Status)
+
+	data, err := client.DownloadTranscript(context.Background(), "thetoken", "t1")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Alice: Hello.")
+
+	_, err = client.DownloadTranscript(context.Background(), "thetoken", "t2")
+	require.Error(t, err)
+	apiErr, ok := err.(*APIError)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.False(t, IsUnauthorized(err))
+}
+
+func TestIsUnauthorized(t *testing.T) {
+	assert.True(t, IsUnauthorized(&APIError{StatusCode: http.StatusForbidden}))
+	assert.True(t, IsUnauthorized(errors.WithMessage(&APIError{StatusCode: http.StatusUnauthorized}, "failed")))
+	assert.False(t, IsUnauthorized(&APIError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, IsUnauthorized(errors.New("timeout")))
+}
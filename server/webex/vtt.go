@@ -0,0 +1,203 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxSpeakerPrefixLength bounds the length of the "Name: " prefix read as the speaker of a cue.
+const maxSpeakerPrefixLength = 40
+
+// ErrInvalidVTT is returned when parsing a document that is not a WebVTT file.
+var ErrInvalidVTT = errors.New("not a WebVTT document")
+
+var (
+	// webexCueIdentifier matches the identifiers of the cues of Webex transcripts, e.g. `12 "Alice Smith" (123456)`.
+	webexCueIdentifier = regexp.MustCompile(`^\d+\s+"([^"]*)"`)
+
+	// voiceSpan matches the voice span opening a cue, e.g. `<v Alice>` or `<v.loud Alice>`.
+	voiceSpan = regexp.MustCompile(`^<v(?:\.[^\s>]*)?\s+([^>]+)>`)
+
+	// cueMarkup matches any other markup of a cue, e.g. `</v>`, `<i>` or `<00:00:01.000>`.
+	cueMarkup = regexp.MustCompile(`<[^>]*>`)
+)
+
+// Cue is a caption of a WebVTT transcript. Speaker is empty when the transcript doesn't attribute the cue.
+type Cue struct {
+	Start   time.Duration
+	End     time.Duration
+	Speaker string
+	Text    string
+}
+
+// ParseVTT parses a WebVTT transcript into its cues, in order. The speaker of a cue is read from its voice span, from
+// the cue identifiers of Webex transcripts, or else from a "Name: " prefix of its text. Notes, styles, regions and
+// malformed cues are skipped.
+func ParseVTT(r io.Reader) ([]Cue, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+
+	var blocks [][]string
+	var block []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if blocks == nil && block == nil {
+			line = strings.TrimPrefix(line, "\uFEFF")
+			if line != "WEBVTT" && !strings.HasPrefix(line, "WEBVTT ") && !strings.HasPrefix(line, "WEBVTT\t") {
+				return nil, ErrInvalidVTT
+			}
+		}
+
+		if strings.TrimSpace(line) == "" {
+			if block != nil {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			continue
+		}
+		block = append(block, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if block != nil {
+		blocks = append(blocks, block)
+	}
+	if len(blocks) == 0 {
+		return nil, ErrInvalidVTT
+	}
+
+	// The first block is the header.
+	var cues []Cue
+	for _, block := range blocks[1:] {
+		if cue, ok := parseCue(block); ok {
+			cues = append(cues, cue)
+		}
+	}
+	return cues, nil
+}
+
+// parseCue parses the lines of a cue block, and reports whether it is a cue with text.
+func parseCue(lines []string) (Cue, bool) {
+	if strings.HasPrefix(lines[0], "NOTE") || lines[0] == "STYLE" || lines[0] == "REGION" {
+		return Cue{}, false
+	}
+
+	timing := 0
+	if !strings.Contains(lines[0], "-->") {
+		timing = 1
+	}
+	if timing >= len(lines) {
+		return Cue{}, false
+	}
+	start, end, err := parseCueTimings(lines[timing])
+	if err != nil {
+		return Cue{}, false
+	}
+
+	cue := Cue{Start: start, End: end}
+	if timing == 1 {
+		if m := webexCueIdentifier.FindStringSubmatch(lines[0]); m != nil {
+			cue.Speaker = strings.TrimSpace(m[1])
+		}
+	}
+
+	text := strings.Join(lines[timing+1:], " ")
+	if m := voiceSpan.FindStringSubmatch(text); m != nil {
+		cue.Speaker = strings.TrimSpace(m[1])
+		text = text[len(m[0]):]
+	}
+	text = html.UnescapeString(cueMarkup.ReplaceAllString(text, ""))
+	text = strings.Join(strings.Fields(text), " ")
+
+	// Webex repeats the speaker at the start of the text, which is the only attribution of some other transcripts.
+	if prefix, rest, ok := strings.Cut(text, ": "); ok && prefix != "" && len(prefix) <= maxSpeakerPrefixLength {
+		if cue.Speaker == "" && !strings.ContainsAny(prefix, ".,;?!\"") {
+			cue.Speaker = prefix
+			text = rest
+		} else if strings.EqualFold(prefix, cue.Speaker) {
+			text = rest
+		}
+	}
+
+	cue.Text = text
+	return cue, text != ""
+}
+
+// parseCueTimings parses a cue timings line such as `00:01:02.500 --> 00:01:04.000 align:start`.
+func parseCueTimings(line string) (start, end time.Duration, err error) {
+	from, to, ok := strings.Cut(line, "-->")
+	fields := strings.Fields(to)
+	if !ok || len(fields) == 0 {
+		return 0, 0, errors.Errorf("invalid cue timings %q", line)
+	}
+
+	if start, err = parseTimestamp(strings.TrimSpace(from)); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parseTimestamp(fields[0]); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// parseTimestamp parses a WebVTT timestamp, `hh:mm:ss.ttt` or `mm:ss.ttt`.
+func parseTimestamp(s string) (time.Duration, error) {
+	clock, millis, ok := strings.Cut(s, ".")
+	units := strings.Split(clock, ":")
+	if !ok || len(millis) != 3 || len(units) < 2 || len(units) > 3 {
+		return 0, errors.Errorf("invalid timestamp %q", s)
+	}
+
+	seconds := 0
+	for _, unit := range units {
+		n, err := strconv.Atoi(unit)
+		if err != nil || n < 0 {
+			return 0, errors.Errorf("invalid timestamp %q", s)
+		}
+		seconds = seconds*60 + n
+	}
+	ms, err := strconv.Atoi(millis)
+	if err != nil || ms < 0 {
+		return 0, errors.Errorf("invalid timestamp %q", s)
+	}
+	return time.Duration(seconds)*time.Second + time.Duration(ms)*time.Millisecond, nil
+}
+
+// FormatTranscript renders cues as speaker-attributed text, with a paragraph for each turn of a speaker.
+func FormatTranscript(cues []Cue) string {
+	var paragraphs []string
+	var speaker string
+	var turn []string
+	flush := func() {
+		if len(turn) == 0 {
+			return
+		}
+		paragraph := strings.Join(turn, " ")
+		if speaker != "" {
+			paragraph = speaker + ": " + paragraph
+		}
+		paragraphs = append(paragraphs, paragraph)
+		turn = nil
+	}
+
+	for _, cue := range cues {
+		if cue.Speaker != speaker {
+			flush()
+			speaker = cue.Speaker
+		}
+		turn = append(turn, cue.Text)
+	}
+	flush()
+	return strings.Join(paragraphs, "\n\n")
+}
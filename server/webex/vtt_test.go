@@ -0,0 +1,93 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseVTTFile(t *testing.T, name string) []Cue {
+	f, err := os.Open(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	cues, err := ParseVTT(f)
+	require.NoError(t, err)
+	return cues
+}
+
+func TestParseVTT(t *testing.T) {
+	t.Run("webex transcript", func(t *testing.T) {
+		cues := parseVTTFile(t, "webex.vtt")
+		require.Len(t, cues, 5)
+		assert.Equal(t, Cue{
+			Start:   1520 * time.Millisecond,
+			End:     5120 * time.Millisecond,
+			Speaker: "Alice Smith",
+			Text:    "Good morning everyone, thanks for joining.",
+		}, cues[0])
+		assert.Equal(t, "Bob Jones", cues[2].Speaker)
+		assert.Equal(t, "The build is green. We agreed to ship on Friday if QA signs off.", cues[2].Text)
+		assert.Equal(t, "I'll follow up with QA & send the notes.", cues[3].Text)
+	})
+
+	t.Run("voice spans", func(t *testing.T) {
+		cues := parseVTTFile(t, "voices.vtt")
+		require.Len(t, cues, 4, "notes, styles and malformed cues are skipped")
+		assert.Equal(t, Cue{Start: time.Second, End: 4 * time.Second, Speaker: "Dana", Text: "Can everyone hear me?"}, cues[0])
+		assert.Equal(t, "Eve", cues[1].Speaker)
+		assert.Equal(t, "Yes, loud and clear.", cues[1].Text)
+		assert.Equal(t, "Dana", cues[2].Speaker, "with a cue identifier")
+		assert.Empty(t, cues[3].Speaker)
+	})
+
+	t.Run("speaker prefixes", func(t *testing.T) {
+		cues, err := ParseVTT(strings.NewReader("WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nFrank: Hello.\n\n00:00:02.000 --> 00:00:03.000\nWait. Really: no.\n"))
+		require.NoError(t, err)
+		require.Len(t, cues, 2)
+		assert.Equal(t, "Frank", cues[0].Speaker)
+		assert.Equal(t, "Hello.", cues[0].Text)
+		assert.Empty(t, cues[1].Speaker)
+		assert.Equal(t, "Wait. Really: no.", cues[1].Text)
+	})
+
+	t.Run("not a WebVTT document", func(t *testing.T) {
+		for _, doc := range []string{"", "1\n00:00:01,000 --> 00:00:02,000\nAn SRT file\n", "WEBVTTX\n"} {
+			_, err := ParseVTT(strings.NewReader(doc))
+			assert.Equal(t, ErrInvalidVTT, err, doc)
+		}
+	})
+}
+
+func TestFormatTranscript(t *testing.T) {
+	for _, name := range []string{"webex", "voices"} {
+		expected, err := os.ReadFile(filepath.Join("testdata", name+".txt"))
+		require.NoError(t, err)
+		assert.Equal(t, strings.TrimSpace(string(expected)), FormatTranscript(parseVTTFile(t, name+".vtt")), name)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	for s, expected := range map[string]time.Duration{
+		"00:00:01.500": 1500 * time.Millisecond,
+		"01:02:03.004": time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond,
+		"02:03.000":    2*time.Minute + 3*time.Second,
+	} {
+		d, err := parseTimestamp(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, d, s)
+	}
+
+	for _, s := range []string{"00:00:01,500", "1.500", "00:00:01.5", "aa:00:01.500", "00:00:00:01.500"} {
+		_, err := parseTimestamp(s)
+		assert.Error(t, err, s)
+	}
+}
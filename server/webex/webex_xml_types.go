@@ -39,6 +39,44 @@ type Header struct {
 }
 
 type Response struct {
-	XMLName xml.Name `xml:"response"`
-	Result  string   `xml:"result"`
+	XMLName     xml.Name `xml:"response"`
+	Result      string   `xml:"result"`
+	Reason      string   `xml:"reason"`
+	GSBStatus   string   `xml:"gsbStatus"`
+	ExceptionID string   `xml:"exceptionID"`
+}
+
+const (
+	ResultSuccess = "SUCCESS"
+	ResultFailure = "FAILURE"
+)
+
+// ServiceMessage is the envelope of the requests sent to the XML API.
+type ServiceMessage struct {
+	XMLName   xml.Name       `xml:"serv:message"`
+	XMLNSServ string         `xml:"xmlns:serv,attr"`
+	XMLNSXSI  string         `xml:"xmlns:xsi,attr"`
+	Header    RequestHeader  `xml:"header"`
+	Body      GetUserCardRQB `xml:"body"`
+}
+
+type RequestHeader struct {
+	SecurityContext SecurityContext `xml:"securityContext"`
+}
+
+type SecurityContext struct {
+	SiteName string `xml:"siteName"`
+}
+
+// GetUserCardRQB = GetUserCardRequestBody
+type GetUserCardRQB struct {
+	BodyContent GetUserCard `xml:"bodyContent"`
+}
+
+// GetUserCard looks a user up by exactly one of its fields.
+type GetUserCard struct {
+	Type        string `xml:"xsi:type,attr"`
+	PersonalURL string `xml:"personalUrl,omitempty"`
+	WebExID     string `xml:"webExId,omitempty"`
+	Email       string `xml:"email,omitempty"`
 }
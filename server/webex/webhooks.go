@@ -0,0 +1,146 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // Webex signs webhook payloads with HMAC-SHA1
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Spark-Signature"
+
+	ResourceMeetings            = "meetings"
+	ResourceMeetingParticipants = "meetingParticipants"
+	ResourceRecordings          = "recordings"
+	ResourceMeetingTranscripts  = "meetingTranscripts"
+
+	EventStarted = "started"
+	EventEnded   = "ended"
+	EventJoined  = "joined"
+	EventLeft    = "left"
+	EventCreated = "created"
+	EventAll     = "all"
+
+	maxListedWebhooks = 100
+
+	// instanceSeparator separates the meeting series ID from the instance number in meeting instance IDs.
+	instanceSeparator = "_I_"
+)
+
+// Webhook is a Webex webhook registration.
+type Webhook struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	TargetURL string `json:"targetUrl"`
+	Resource  string `json:"resource"`
+	Event     string `json:"event"`
+	Secret    string `json:"secret,omitempty"`
+}
+
+// WebhookEvent is the payload Webex sends to a webhook's target URL.
+type WebhookEvent struct {
+	ID       string           `json:"id"`
+	Name     string           `json:"name"`
+	Resource string           `json:"resource"`
+	Event    string           `json:"event"`
+	ActorID  string           `json:"actorId"`
+	Data     WebhookEventData `json:"data"`
+
+	// Created is when Webex created the event, which may be delivered later, or more than once.
+	Created time.Time `json:"created"`
+}
+
+// WebhookEventData holds the fields of both meeting and meeting participant events.
+type WebhookEventData struct {
+	ID                 string    `json:"id"`
+	MeetingID          string    `json:"meetingId"`
+	MeetingSeriesID    string    `json:"meetingSeriesId"`
+	ScheduledMeetingID string    `json:"scheduledMeetingId"`
+	Title              string    `json:"title"`
+	WebLink            string    `json:"webLink"`
+	Email              string    `json:"email"`
+	DisplayName        string    `json:"displayName"`
+	Start              time.Time `json:"start"`
+	End                time.Time `json:"end"`
+	JoinedTime         time.Time `json:"joinedTime"`
+	LeftTime           time.Time `json:"leftTime"`
+}
+
+// MeetingIDs returns the IDs the event may refer to a meeting by, most specific first.
+func (e *WebhookEvent) MeetingIDs() []string {
+	var candidates []string
+	switch e.Resource {
+	case ResourceMeetingParticipants:
+		candidates = []string{e.Data.MeetingID}
+	case ResourceRecordings, ResourceMeetingTranscripts:
+		candidates = []string{e.Data.MeetingID, e.Data.ScheduledMeetingID, e.Data.MeetingSeriesID}
+	default:
+		candidates = []string{e.Data.ScheduledMeetingID, e.Data.MeetingSeriesID, e.Data.ID}
+	}
+
+	seen := map[string]bool{}
+	var ids []string
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range candidates {
+		add(id)
+		if i := strings.Index(id, instanceSeparator); i > 0 {
+			add(id[:i])
+		}
+	}
+	return ids
+}
+
+// VerifySignature checks that signature is the hex encoded HMAC-SHA1 of body using secret.
+func VerifySignature(secret string, body []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha1.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// ListWebhooks returns the webhooks registered by the owner of token.
+func (c *restClient) ListWebhooks(ctx context.Context, token string) ([]Webhook, error) {
+	var list struct {
+		Items []Webhook `json:"items"`
+	}
+	if err := c.do(ctx, token, http.MethodGet, "/webhooks?max="+strconv.Itoa(maxListedWebhooks), nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// CreateWebhook registers webhook for the owner of token.
+func (c *restClient) CreateWebhook(ctx context.Context, token string, webhook Webhook) (*Webhook, error) {
+	created := &Webhook{}
+	if err := c.do(ctx, token, http.MethodPost, "/webhooks", webhook, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// DeleteWebhook removes the webhook registration webhookID of the owner of token.
+func (c *restClient) DeleteWebhook(ctx context.Context, token, webhookID string) error {
+	return c.do(ctx, token, http.MethodDelete, "/webhooks/"+url.PathEscape(webhookID), nil, nil)
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package webex
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // Webex signs webhook payloads with HMAC-SHA1
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"resource":"meetings","event":"started"}`)
+	mac := hmac.New(sha1.New, []byte("thesecret"))
+	_, _ = mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	assert.True(t, VerifySignature("thesecret", body, signature))
+	assert.False(t, VerifySignature("othersecret", body, signature))
+	assert.False(t, VerifySignature("thesecret", []byte(`{"resource":"meetings","event":"ended"}`), signature))
+	assert.False(t, VerifySignature("thesecret", body, "not hex"))
+	assert.False(t, VerifySignature("thesecret", body, ""))
+	assert.False(t, VerifySignature("", body, signature))
+}
+
+func TestWebhookEventMeetingIDs(t *testing.T) {
+	meetingEvent := &WebhookEvent{
+		Resource: ResourceMeetings,
+		Data: WebhookEventData{
+			ID:                 "series1_I_123",
+			MeetingSeriesID:    "series1",
+			ScheduledMeetingID: "series1_20261020T150000Z",
+		},
+	}
+	assert.Equal(t, []string{"series1_20261020T150000Z", "series1", "series1_I_123"}, meetingEvent.MeetingIDs())
+
+	participantEvent := &WebhookEvent{
+		Resource: ResourceMeetingParticipants,
+		Data: WebhookEventData{
+			ID:        "participant1",
+			MeetingID: "series1_I_123",
+		},
+	}
+	assert.Equal(t, []string{"series1_I_123", "series1"}, participantEvent.MeetingIDs())
+
+	recordingEvent := &WebhookEvent{
+		Resource: ResourceRecordings,
+		Data: WebhookEventData{
+			ID:              "recording1",
+			MeetingID:       "series1_I_123",
+			MeetingSeriesID: "series1",
+		},
+	}
+	assert.Equal(t, []string{"series1_I_123", "series1"}, recordingEvent.MeetingIDs())
+}
+
+func TestListWebhooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/webhooks", r.URL.Path)
+		assert.Equal(t, "Bearer thetoken", r.Header.Get("Authorization"))
+		_, _ = fmt.Fprint(w, `{"items":[{"id":"w1","name":"Webhook","targetUrl":"https://mattermost.example.com/webhook","resource":"meetings","event":"all"}]}`)
+	}))
+	defer server.Close()
+
+	webhooks, err := NewRESTClient(server.URL, server.Client()).ListWebhooks(context.Background(), "thetoken")
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, "w1", webhooks[0].ID)
+	assert.Equal(t, ResourceMeetings, webhooks[0].Resource)
+}
@@ -0,0 +1,334 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	webhookName = "Mattermost Webex plugin"
+
+	maxWebhookBodySize = 1 << 20
+)
+
+// webhookResources are the resources whose events are subscribed to for each connected user.
+var webhookResources = []string{
+	webex.ResourceMeetings,
+	webex.ResourceMeetingParticipants,
+	webex.ResourceRecordings,
+	webex.ResourceMeetingTranscripts,
+}
+
+// registerWebhooks subscribes to the meeting events of mattermostUserID, replacing any previous subscription.
+func (p *Plugin) registerWebhooks(mattermostUserID string, token *webex.Token) error {
+	secret := p.getConfiguration().WebhookSecret
+	if secret == "" {
+		return errors.New("the webhook secret has not been generated")
+	}
+
+	p.unregisterWebhooks(mattermostUserID, token)
+
+	var webhookIDs []string
+	var registerErr error
+	for _, resource := range webhookResources {
+		webhook, err := p.createWebhook(token, resource, secret)
+		if err != nil {
+			registerErr = err
+			break
+		}
+		webhookIDs = append(webhookIDs, webhook.ID)
+	}
+
+	// Keep track of whatever was registered so it can be removed later.
+	err := p.store.UpdateUserInfo(mattermostUserID, func(info *UserInfo) error {
+		info.WebhookIDs = webhookIDs
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return registerErr
+}
+
+// ensureWebhooks subscribes to the events of the resources missing from the webhooks of mattermostUserID, those
+// supported since the user connected their account. Failures are logged. The transcripts webhook needs the access to
+// transcripts that the tokens of these users may lack, so it waits for the user to connect again.
+func (p *Plugin) ensureWebhooks(mattermostUserID string, token *webex.Token) {
+	secret := p.getConfiguration().WebhookSecret
+	userInfo, err := p.store.LoadUserInfo(mattermostUserID)
+	if err != nil || secret == "" || userInfo.TranscriptsUnauthorized {
+		return
+	}
+	if len(userInfo.WebhookIDs) == 0 || len(userInfo.WebhookIDs) >= len(webhookResources) {
+		return
+	}
+
+	webhooks, err := p.webexRESTClient.ListWebhooks(context.Background(), token.AccessToken)
+	if err != nil {
+		p.API.LogWarn("unable to list the Webex webhooks of a user", "user_id", mattermostUserID, "error", err.Error())
+		return
+	}
+	registered := map[string]bool{}
+	for _, webhook := range webhooks {
+		for _, webhookID := range userInfo.WebhookIDs {
+			if webhook.ID == webhookID {
+				registered[webhook.Resource] = true
+			}
+		}
+	}
+
+	var added []string
+	for _, resource := range webhookResources {
+		if registered[resource] {
+			continue
+		}
+		webhook, createErr := p.createWebhook(token, resource, secret)
+		if createErr != nil {
+			p.API.LogWarn("unable to register a Webex webhook", "user_id", mattermostUserID, "error", createErr.Error())
+			continue
+		}
+		added = append(added, webhook.ID)
+	}
+	if len(added) == 0 {
+		return
+	}
+
+	err = p.store.UpdateUserInfo(mattermostUserID, func(info *UserInfo) error {
+		info.WebhookIDs = append(info.WebhookIDs, added...)
+		return nil
+	})
+	if err != nil {
+		p.errorf("error storing user info for mattermostUserID: %s, error: %v", mattermostUserID, err)
+	}
+}
+
+func (p *Plugin) createWebhook(token *webex.Token, resource, secret string) (*webex.Webhook, error) {
+	webhook, err := p.webexRESTClient.CreateWebhook(context.Background(), token.AccessToken, webex.Webhook{
+		Name:      webhookName,
+		TargetURL: p.GetPluginURL() + routeWebhook,
+		Resource:  resource,
+		Event:     webex.EventAll,
+		Secret:    secret,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to register the %s webhook: %v", resource, err)
+	}
+	return webhook, nil
+}
+
+// unregisterWebhooks removes the webhooks registered for mattermostUserID, logging any failure.
+func (p *Plugin) unregisterWebhooks(mattermostUserID string, token *webex.Token) {
+	userInfo, err := p.store.LoadUserInfo(mattermostUserID)
+	if err != nil || len(userInfo.WebhookIDs) == 0 {
+		return
+	}
+
+	p.deleteWebhooks(token, userInfo.WebhookIDs)
+
+	err = p.store.UpdateUserInfo(mattermostUserID, func(info *UserInfo) error {
+		info.WebhookIDs = nil
+		return nil
+	})
+	if err != nil {
+		p.errorf("error storing user info for mattermostUserID: %s, error: %v", mattermostUserID, err)
+	}
+}
+
+// deleteWebhooks removes webhookIDs from Webex, logging any failure.
+func (p *Plugin) deleteWebhooks(token *webex.Token, webhookIDs []string) {
+	for _, webhookID := range webhookIDs {
+		if err := p.webexRESTClient.DeleteWebhook(context.Background(), token.AccessToken, webhookID); err != nil {
+			p.API.LogWarn("unable to delete a Webex webhook", "webhook_id", webhookID, "error", err.Error())
+		}
+	}
+}
+
+func (p *Plugin) handleWebhook(_ http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed,
+			errors.New("method " + r.Method + " is not allowed, must be POST")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("failed to read the request body: %v", err)
+	}
+
+	if !webex.VerifySignature(p.getConfiguration().WebhookSecret, body, r.Header.Get(webex.SignatureHeader)) {
+		return http.StatusUnauthorized, errors.New("invalid signature")
+	}
+
+	var event webex.WebhookEvent
+	if err = json.Unmarshal(body, &event); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("err: %v", err)
+	}
+
+	err = p.processWebhookEvent(&event)
+	if err == ErrMeetingNotFound {
+		// Not a meeting shared by the plugin.
+		return http.StatusOK, nil
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusOK, nil
+}
+
+func (p *Plugin) processWebhookEvent(event *webex.WebhookEvent) error {
+	var meeting *Meeting
+	for _, id := range event.MeetingIDs() {
+		found, err := p.store.LoadMeetingByWebexID(id)
+		if err == ErrMeetingNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		meeting = found
+		break
+	}
+
+	// Webex reports the meetings of Personal Rooms with a meeting instance the plugin doesn't know about, only by
+	// their room's link. Once matched, the meeting is indexed by its instance for the following events.
+	matchedByRoom := false
+	if meeting == nil && event.Resource == webex.ResourceMeetings && event.Data.WebLink != "" {
+		found, err := p.store.LoadMeetingByRoomURL(event.Data.WebLink)
+		if err != nil && err != ErrMeetingNotFound {
+			return err
+		}
+		meeting = found
+		matchedByRoom = found != nil
+	}
+	if meeting == nil {
+		return ErrMeetingNotFound
+	}
+
+	// The lookup pending since the meeting ended is brought forward, rather than starting another one.
+	if event.Resource == webex.ResourceRecordings || event.Resource == webex.ResourceMeetingTranscripts {
+		if event.Event == webex.EventCreated {
+			return p.scheduleRecordingLookup(meeting.PostID, 0, time.Now())
+		}
+		return nil
+	}
+
+	return p.updateMeeting(meeting.PostID, func(meeting *Meeting) bool {
+		changed := applyWebhookEvent(meeting, event, time.Now())
+		if matchedByRoom && meeting.WebexInstanceID == "" {
+			// The post of a Personal Room is started when shared, so it's timed by the start of the meeting instead.
+			meeting.WebexInstanceID = event.Data.ID
+			if event.Event == webex.EventStarted {
+				meeting.StartedAt = eventTime(event, event.Data.Start, time.Now())
+			}
+			changed = true
+		}
+		return changed
+	})
+}
+
+// eventTime returns when event happened: at, as reported by Webex, or else when Webex created the event, or else now.
+// Times in the future, such as the scheduled end of a meeting that ended early, are ignored.
+func eventTime(event *webex.WebhookEvent, at, now time.Time) time.Time {
+	for _, t := range []time.Time{at, event.Created} {
+		if !t.IsZero() && !t.After(now) {
+			return t
+		}
+	}
+	return now
+}
+
+// applyWebhookEvent updates meeting according to event, received at now, and reports whether anything changed. The
+// meeting is timed by the event rather than by its delivery, which may be late.
+func applyWebhookEvent(meeting *Meeting, event *webex.WebhookEvent, now time.Time) bool {
+	switch event.Resource + "." + event.Event {
+	case webex.ResourceMeetings + "." + webex.EventStarted:
+		if meeting.Status == webex.StatusStarted {
+			return false
+		}
+		meeting.Status = webex.StatusStarted
+		meeting.StartedAt = eventTime(event, event.Data.Start, now)
+		meeting.EndedAt = time.Time{}
+
+	case webex.ResourceMeetings + "." + webex.EventEnded:
+		if meeting.Status == webex.StatusEnded {
+			return false
+		}
+		meeting.Status = webex.StatusEnded
+		meeting.EndedAt = eventTime(event, event.Data.End, now)
+		meeting.clearParticipants()
+
+	// Webex redelivers events, and attendees may leave and rejoin, so only the changes of presence are counted.
+	case webex.ResourceMeetingParticipants + "." + webex.EventJoined:
+		attendee := meeting.findAttendee(event.Data.ID)
+		if attendee == nil {
+			meeting.Attendees = append(meeting.Attendees, Attendee{
+				ID:          event.Data.ID,
+				Email:       event.Data.Email,
+				DisplayName: event.Data.DisplayName,
+			})
+			attendee = &meeting.Attendees[len(meeting.Attendees)-1]
+		} else if attendee.Present {
+			return false
+		}
+		attendee.Present = true
+		meeting.ParticipantCount++
+
+	case webex.ResourceMeetingParticipants + "." + webex.EventLeft:
+		attendee := meeting.findAttendee(event.Data.ID)
+		if attendee == nil || !attendee.Present {
+			return false
+		}
+		attendee.Present = false
+		meeting.ParticipantCount = max(meeting.ParticipantCount-1, 0)
+
+	default:
+		return false
+	}
+	return true
+}
+
+// updateMeeting applies update to the stored meeting of postID under a cluster-wide lock, then refreshes its post
+// when update reports a change. When update ended the meeting, it replies with its summary and starts looking up its
+// recordings.
+func (p *Plugin) updateMeeting(postID string, update func(meeting *Meeting) bool) error {
+	mutex, err := cluster.NewMutex(p.API, "meeting_"+postID)
+	if err != nil {
+		return err
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	meeting, err := p.store.LoadMeeting(postID)
+	if err != nil {
+		return err
+	}
+
+	wasEnded := meeting.Status == webex.StatusEnded
+	if !update(meeting) {
+		return nil
+	}
+
+	if err = p.store.StoreMeeting(meeting); err != nil {
+		return err
+	}
+
+	err = p.updateMeetingPost(meeting)
+	if !wasEnded && meeting.Status == webex.StatusEnded {
+		p.postMeetingSummary(meeting)
+		p.startRecordingLookups(meeting)
+	}
+	return err
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-webex/server/webex"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyWebhookEvent(t *testing.T) {
+	created := time.Date(2026, 10, 20, 14, 55, 0, 0, time.UTC)
+	started := created.Add(5 * time.Minute)
+	ended := started.Add(25 * time.Minute)
+
+	meeting := &Meeting{
+		PostID:    "thepostid",
+		Status:    webex.StatusScheduled,
+		CreatedAt: created,
+	}
+
+	event := func(resource, name, participantID string) *webex.WebhookEvent {
+		return &webex.WebhookEvent{
+			Resource: resource,
+			Event:    name,
+			Data: webex.WebhookEventData{
+				ID:    participantID,
+				Email: participantID + "@example.com",
+			},
+		}
+	}
+
+	assert.True(t, applyWebhookEvent(meeting, event(webex.ResourceMeetings, webex.EventStarted, ""), started))
+	assert.Equal(t, webex.StatusStarted, meeting.Status)
+	assert.True(t, meeting.IsActive(started))
+
+	// Webex may deliver the same event more than once.
+	assert.False(t, applyWebhookEvent(meeting, event(webex.ResourceMeetings, webex.EventStarted, ""), ended))
+	assert.Equal(t, started, meeting.StartedAt)
+
+	assert.True(t, applyWebhookEvent(meeting, event(webex.ResourceMeetingParticipants, webex.EventJoined, "alice"), started))
+	assert.True(t, applyWebhookEvent(meeting, event(webex.ResourceMeetingParticipants, webex.EventJoined, "bob"), started))
+	assert.True(t, applyWebhookEvent(meeting, event(webex.ResourceMeetingParticipants, webex.EventLeft, "alice"), started))
+	assert.True(t, applyWebhookEvent(meeting, event(webex.ResourceMeetingParticipants, webex.EventJoined, "alice"), started))
+	assert.False(t, applyWebhookEvent(meeting, event(webex.ResourceMeetingParticipants, webex.EventJoined, "alice"), started), "redelivered")
+	assert.Equal(t, 2, meeting.ParticipantCount)
+	assert.Len(t, meeting.Attendees, 2)
+	assert.Equal(t, "bob@example.com", meeting.Attendees[1].Email)
+
+	assert.True(t, applyWebhookEvent(meeting, event(webex.ResourceMeetingParticipants, webex.EventLeft, "alice"), started))
+	assert.False(t, applyWebhookEvent(meeting, event(webex.ResourceMeetingParticipants, webex.EventLeft, "alice"), started), "redelivered")
+	assert.True(t, applyWebhookEvent(meeting, event(webex.ResourceMeetingParticipants, webex.EventLeft, "bob"), started))
+	assert.Equal(t, 0, meeting.ParticipantCount)
+	assert.False(t, meeting.isRunning(started.Add(2*runningMeetingWindow)), "everyone left")
+	assert.True(t, applyWebhookEvent(meeting, event(webex.ResourceMeetingParticipants, webex.EventJoined, "bob"), started))
+
+	assert.True(t, applyWebhookEvent(meeting, event(webex.ResourceMeetings, webex.EventEnded, ""), ended))
+	assert.Equal(t, webex.StatusEnded, meeting.Status)
+	assert.False(t, meeting.IsActive(ended))
+	assert.Equal(t, 0, meeting.ParticipantCount)
+	assert.False(t, meeting.Attendees[1].Present)
+	assert.Equal(t, 25*time.Minute, meeting.Duration())
+
+	assert.False(t, applyWebhookEvent(meeting, event(webex.ResourceMeetingParticipants, webex.EventLeft, "bob"), ended))
+	assert.False(t, applyWebhookEvent(meeting, event("messages", "created", ""), ended))
+}
+
+func TestApplyDelayedWebhookEvent(t *testing.T) {
+	started := time.Date(2026, 10, 20, 15, 0, 0, 0, time.UTC)
+	ended := started.Add(25 * time.Minute)
+	received := ended.Add(time.Hour)
+	meeting := &Meeting{PostID: "thepostid", Status: webex.StatusScheduled}
+
+	// A meeting is timed by its events, however late they are delivered.
+	assert.True(t, applyWebhookEvent(meeting, &webex.WebhookEvent{
+		Resource: webex.ResourceMeetings,
+		Event:    webex.EventStarted,
+		Data:     webex.WebhookEventData{Start: started, End: started.Add(2 * time.Hour)},
+	}, received))
+	assert.Equal(t, started, meeting.StartedAt)
+
+	// The scheduled end of a meeting that ends early is ignored in favor of when the event was created.
+	assert.True(t, applyWebhookEvent(meeting, &webex.WebhookEvent{
+		Resource: webex.ResourceMeetings,
+		Event:    webex.EventEnded,
+		Data:     webex.WebhookEventData{Start: started, End: received.Add(time.Hour)},
+		Created:  ended,
+	}, received))
+	assert.Equal(t, ended, meeting.EndedAt)
+	assert.Equal(t, 25*time.Minute, meeting.Duration())
+}
+
+func TestProcessPersonalRoomWebhookEvents(t *testing.T) {
+	api, _ := newKVAPI()
+	api.On("GetPost", "thepostid").Return(&model.Post{Id: "thepostid", Type: "custom_webex"}, nil)
+	var updated *model.Post
+	api.On("UpdatePost", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+		updated = args.Get(0).(*model.Post)
+	}).Return(&model.Post{}, nil)
+	var summary *model.Post
+	api.On("CreatePost", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+		summary = args.Get(0).(*model.Post)
+	}).Return(&model.Post{Id: "thesummaryid"}, nil)
+	api.On("GetUserByEmail", "alice@example.com").Return(&model.User{Id: "aliceid", Username: "alice"}, nil)
+
+	p := &Plugin{botUserID: "thebotid"}
+	p.SetAPI(api)
+	p.store = NewStore(p)
+	require.NoError(t, p.store.StoreMeeting(&Meeting{
+		PostID:     "thepostid",
+		ChannelID:  "thechannelid",
+		HostUserID: "hostid",
+		RoomURL:    "https://site.webex.com/meet/alice",
+		Status:     webex.StatusStarted,
+		CreatedAt:  time.Now().Add(-time.Minute),
+	}))
+
+	// Meetings that the plugin didn't share are ignored.
+	assert.Equal(t, ErrMeetingNotFound, p.processWebhookEvent(&webex.WebhookEvent{
+		Resource: webex.ResourceMeetings,
+		Event:    webex.EventStarted,
+		Data:     webex.WebhookEventData{ID: "other_I_1", WebLink: "https://site.webex.com/meet/bob"},
+	}))
+
+	// The room's meeting is matched by its link, then by its meeting instance.
+	started := time.Now().Add(-30 * time.Minute)
+	require.NoError(t, p.processWebhookEvent(&webex.WebhookEvent{
+		Resource: webex.ResourceMeetings,
+		Event:    webex.EventStarted,
+		Data:     webex.WebhookEventData{ID: "pmr_I_1", WebLink: "HTTPS://site.webex.com/meet/alice/", Start: started},
+	}))
+	require.NoError(t, p.processWebhookEvent(&webex.WebhookEvent{
+		Resource: webex.ResourceMeetingParticipants,
+		Event:    webex.EventJoined,
+		Data:     webex.WebhookEventData{ID: "alice", MeetingID: "pmr_I_1", Email: "alice@example.com", DisplayName: "Alice"},
+	}))
+	require.NotNil(t, updated)
+	assert.Equal(t, 1, updated.GetProp("meeting_participant_count"))
+
+	require.NoError(t, p.processWebhookEvent(&webex.WebhookEvent{
+		Resource: webex.ResourceMeetings,
+		Event:    webex.EventEnded,
+		Data:     webex.WebhookEventData{ID: "pmr_I_1", WebLink: "https://site.webex.com/meet/alice"},
+	}))
+	assert.Equal(t, webex.StatusEnded, updated.GetProp("meeting_status"))
+	require.NotNil(t, summary)
+	assert.Equal(t, "thepostid", summary.RootId)
+
+	meeting, err := p.store.LoadMeeting("thepostid")
+	require.NoError(t, err)
+	assert.Equal(t, "pmr_I_1", meeting.WebexInstanceID)
+	assert.Equal(t, started.Unix(), meeting.StartedAt.Unix())
+
+	// A later meeting in the same room isn't matched to the ended one.
+	assert.Equal(t, ErrMeetingNotFound, p.processWebhookEvent(&webex.WebhookEvent{
+		Resource: webex.ResourceMeetings,
+		Event:    webex.EventStarted,
+		Data:     webex.WebhookEventData{ID: "pmr_I_2", WebLink: "https://site.webex.com/meet/alice"},
+	}))
+}
+
+func TestEnsureWebhooks(t *testing.T) {
+	var created []webex.Webhook
+
+	siteURL := "https://mattermost.example.com"
+	api, _ := newKVAPI()
+	api.On("GetConfig").Return(&model.Config{ServiceSettings: model.ServiceSettings{SiteURL: &siteURL}})
+	api.On("GetUser", "theuserid").Return(&model.User{Id: "theuserid", Email: "theuser@example.com"}, nil)
+
+	p := newTestPlugin(api, &configuration{WebhookSecret: "thesecret"}, nil, nil)
+	p.store = NewStore(p)
+	serveWebexAPI(t, p, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string][]webex.Webhook{"items": {
+				{ID: "w1", Resource: webex.ResourceMeetings},
+				{ID: "w2", Resource: webex.ResourceMeetingParticipants},
+				{ID: "w3", Resource: webex.ResourceRecordings},
+				{ID: "other", Resource: webex.ResourceMeetingTranscripts},
+			}})
+		case http.MethodPost:
+			var webhook webex.Webhook
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&webhook))
+			created = append(created, webhook)
+			webhook.ID = "w4"
+			_ = json.NewEncoder(w).Encode(webhook)
+		}
+	})
+
+	// The user connected before transcripts were supported.
+	require.NoError(t, p.store.UpdateUserInfo("theuserid", func(info *UserInfo) error {
+		info.WebhookIDs = []string{"w1", "w2", "w3"}
+		return nil
+	}))
+
+	token := &webex.Token{AccessToken: "thetoken"}
+	p.ensureWebhooks("theuserid", token)
+	require.Len(t, created, 1)
+	assert.Equal(t, webex.ResourceMeetingTranscripts, created[0].Resource)
+	assert.Equal(t, "https://mattermost.example.com/plugins/"+manifest.Id+routeWebhook, created[0].TargetURL)
+
+	userInfo, err := p.store.LoadUserInfo("theuserid")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"w1", "w2", "w3", "w4"}, userInfo.WebhookIDs)
+
+	p.ensureWebhooks("theuserid", token)
+	assert.Len(t, created, 1, "the webhooks are only registered once")
+}
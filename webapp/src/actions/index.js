@@ -45,3 +45,15 @@ export function startMeeting(channelId) {
         return {data: true};
     };
 }
+
+export function endMeeting(postId) {
+    return async () => {
+        try {
+            await Client.endMeeting(postId);
+        } catch (error) {
+            return {error};
+        }
+
+        return {data: true};
+    };
+}
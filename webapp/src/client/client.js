@@ -15,6 +15,39 @@ export default class Client {
         return this.doPost(`${this.url}/api/v1/meetings`, {channel_id: channelId, personal, topic, meeting_id: meetingId});
     };
 
+    endMeeting = async (postId) => {
+        return this.doPost(`${this.url}/api/v1/meetings/end`, {post_id: postId});
+    };
+
+    getActiveMeetings = async (channelId) => {
+        return this.doGet(`${this.url}/api/v1/meetings/active?channel_id=${encodeURIComponent(channelId)}`);
+    };
+
+    diagnose = async () => {
+        return this.doGet(`${this.url}/api/v1/admin/diagnose`);
+    };
+
+    doGet = async (url, headers = {}) => {
+        const options = {
+            method: 'get',
+            headers,
+        };
+
+        const response = await fetch(url, Client4.getOptions(options));
+
+        if (response.ok) {
+            return response.json();
+        }
+
+        const text = await response.text();
+
+        throw new ClientError(Client4.url, {
+            message: text || '',
+            status_code: response.status,
+            url,
+        });
+    };
+
     doPost = async (url, body, headers = {}) => {
         const options = {
             method: 'post',
@@ -0,0 +1,175 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+import React from 'react';
+import PropTypes from 'prop-types';
+
+import Client from '../client';
+
+// Diagnostics is the System Console setting that checks the saved configuration against Webex, as
+// `/webex admin diagnose` does.
+export default class Diagnostics extends React.PureComponent {
+    static propTypes = {
+
+        /**
+         * The help text of the setting, rendered by the System Console.
+         */
+        helpText: PropTypes.node,
+    };
+
+    constructor(props) {
+        super(props);
+
+        this.state = {
+            running: false,
+            diagnosis: null,
+            error: '',
+        };
+    }
+
+    runDiagnostics = async () => {
+        this.setState({running: true, error: ''});
+        try {
+            const diagnosis = await Client.diagnose();
+            this.setState({running: false, diagnosis});
+        } catch (error) {
+            this.setState({running: false, diagnosis: null, error: error.message || 'The diagnostics failed.'});
+        }
+    };
+
+    render() {
+        const {running, diagnosis, error} = this.state;
+
+        let result;
+        if (error) {
+            result = <div className='error-text'>{error}</div>;
+        } else if (diagnosis) {
+            result = renderDiagnosis(diagnosis);
+        }
+
+        return (
+            <div>
+                <button
+                    className='btn btn-tertiary'
+                    disabled={running}
+                    onClick={this.runDiagnostics}
+                >
+                    {running ? 'Running Diagnostics...' : 'Run Diagnostics'}
+                </button>
+                {result}
+                <div className='help-text'>{this.props.helpText}</div>
+            </div>
+        );
+    }
+}
+
+function renderDiagnosis(diagnosis) {
+    const sites = diagnosis.sites || [];
+    const problems = diagnosis.problems || [];
+
+    let sample;
+    if (diagnosis.sample_email) {
+        sample = <p>{`Looked up ${diagnosis.sample_email} on each site.`}</p>;
+    }
+
+    let table;
+    if (sites.length > 0) {
+        table = (
+            <table
+                className='table'
+                style={style.table}
+            >
+                <thead>
+                    <tr>
+                        <th>{'Site'}</th>
+                        <th>{'Site name'}</th>
+                        <th>{'Status'}</th>
+                        <th>{'Response'}</th>
+                        <th>{'Latency'}</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {sites.map((site) => (
+                        <tr key={site.site_host}>
+                            <td>{site.site_host}</td>
+                            <td>{site.site_name}</td>
+                            <td>{siteStatus(site)}</td>
+                            <td>{siteResponse(site)}</td>
+                            <td>{site.resolve_error ? '' : `${site.latency_ms} ms`}</td>
+                        </tr>
+                    ))}
+                </tbody>
+            </table>
+        );
+    }
+
+    let oauth;
+    if (diagnosis.oauth) {
+        let status = 'OAuth: configured. Connect your own account with /webex connect to check it.';
+        if (diagnosis.oauth.error) {
+            status = `OAuth: your Webex token doesn't work: ${diagnosis.oauth.error}`;
+        } else if (diagnosis.oauth.connected) {
+            status = `OAuth: your token works, connected as ${diagnosis.oauth.display_name} (${diagnosis.oauth.latency_ms} ms).`;
+        }
+        oauth = <p>{status}</p>;
+    }
+
+    let problemList;
+    if (problems.length > 0) {
+        problemList = (
+            <ul className='error-text'>
+                {problems.map((problem) => (
+                    <li key={problem}>{problem}</li>
+                ))}
+            </ul>
+        );
+    }
+
+    return (
+        <div style={style.result}>
+            {problemList}
+            {sample}
+            {table}
+            {oauth}
+        </div>
+    );
+}
+
+// siteStatus and siteResponse describe a site as `/webex admin diagnose` does.
+function siteStatus(site) {
+    if (site.resolve_error) {
+        return 'Hostname not found';
+    }
+    if (site.error) {
+        return 'Unreachable';
+    }
+    if (!site.ok) {
+        return 'Refused';
+    }
+    return 'OK';
+}
+
+function siteResponse(site) {
+    if (site.resolve_error) {
+        return site.resolve_error;
+    }
+    if (site.error) {
+        return site.error;
+    }
+    if (site.pmr_url) {
+        return `${site.result}, Personal Room: ${site.pmr_url}`;
+    }
+    if (site.reason) {
+        return `${site.result}: ${site.reason} (exception ${site.exception_id})`;
+    }
+    return site.result;
+}
+
+const style = {
+    result: {
+        marginTop: '12px',
+    },
+    table: {
+        marginBottom: '12px',
+    },
+};
@@ -5,6 +5,9 @@ import {connect} from 'react-redux';
 import {bindActionCreators} from 'redux';
 
 import {getBool} from 'mattermost-redux/selectors/entities/preferences';
+import {getCurrentUserId} from 'mattermost-redux/selectors/entities/users';
+
+import {endMeeting} from '../../actions';
 
 import {displayUsernameForUser} from '../../utils/user_utils';
 
@@ -19,12 +22,14 @@ function mapStateToProps(state, ownProps) {
         fromBot: ownProps.post.props.from_bot,
         creatorName: displayUsernameForUser(user, state),
         useMilitaryTime: getBool(state, 'display_settings', 'use_military_time', false),
+        currentUserId: getCurrentUserId(state),
     };
 }
 
 function mapDispatchToProps(dispatch) {
     return {
         actions: bindActionCreators({
+            endMeeting,
         }, dispatch),
     };
 }
@@ -46,6 +46,15 @@ export default class PostTypeWebex extends React.PureComponent {
          * Whether the post was sent from a bot. Used for backwards compatibility.
          */
         fromBot: PropTypes.bool.isRequired,
+
+        /**
+         * The ID of the logged in user.
+         */
+        currentUserId: PropTypes.string,
+
+        actions: PropTypes.shape({
+            endMeeting: PropTypes.func.isRequired,
+        }).isRequired,
     };
 
     static defaultProps = {
@@ -57,9 +66,19 @@ export default class PostTypeWebex extends React.PureComponent {
     constructor(props) {
         super(props);
 
-        this.state = {};
+        this.state = {
+            endMeetingError: '',
+        };
     }
 
+    endMeeting = async () => {
+        this.setState({endMeetingError: ''});
+        const {error} = await this.props.actions.endMeeting(this.props.post.id);
+        if (error) {
+            this.setState({endMeetingError: (error.message || '').trim() || 'Unknown error.'});
+        }
+    };
+
     render() {
         const style = getStyle(this.props.theme);
         const post = this.props.post;
@@ -72,7 +91,13 @@ export default class PostTypeWebex extends React.PureComponent {
         if (props.meeting_status === 'INVITED') {
             preText = `${subject} invited you to a meeting`;
         }
-        if (props.meeting_status === 'STARTED' || props.meeting_status === 'INVITED') {
+        if (props.meeting_status === 'SCHEDULED') {
+            preText = `${subject} scheduled a meeting`;
+            if (props.meeting_start) {
+                subtitle = 'Starts: ' + formatDate(new Date(props.meeting_start), this.props.useMilitaryTime);
+            }
+        }
+        if (props.meeting_status === 'STARTED' || props.meeting_status === 'INVITED' || props.meeting_status === 'SCHEDULED') {
             content = (
                 <a
                     className='btn btn-lg btn-primary d-inline-flex'
@@ -89,6 +114,33 @@ export default class PostTypeWebex extends React.PureComponent {
                     {'JOIN MEETING'}
                 </a>
             );
+
+            if (props.meeting_status === 'STARTED' && props.starting_user_id === this.props.currentUserId) {
+                let endMeetingError;
+                if (this.state.endMeetingError) {
+                    endMeetingError = (
+                        <div
+                            className='error-text'
+                            style={style.error}
+                        >
+                            {'The meeting could not be ended: ' + this.state.endMeetingError}
+                        </div>
+                    );
+                }
+                content = (
+                    <div>
+                        {content}
+                        <button
+                            className='btn btn-lg btn-link'
+                            style={style.button}
+                            onClick={this.endMeeting}
+                        >
+                            {'END MEETING'}
+                        </button>
+                        {endMeetingError}
+                    </div>
+                );
+            }
         } else if (props.meeting_status === 'ENDED') {
             preText = `${subject} ended the meeting`;
 
@@ -100,7 +152,38 @@ export default class PostTypeWebex extends React.PureComponent {
 
             const startDate = new Date(post.create_at);
             const start = formatDate(startDate);
-            const length = Math.ceil((new Date(post.update_at) - startDate) / 1000 / 60);
+            let length = Math.ceil((new Date(post.update_at) - startDate) / 1000 / 60);
+            if (props.meeting_duration) {
+                length = Math.ceil(props.meeting_duration / 60);
+            }
+
+            let participants;
+            if (props.meeting_attendee_count) {
+                participants = (
+                    <React.Fragment>
+                        <br/>
+                        <span style={style.summaryItem}>{'Participants: ' + props.meeting_attendee_count}</span>
+                    </React.Fragment>
+                );
+            }
+
+            let recording;
+            if (props.recording_url) {
+                recording = (
+                    <React.Fragment>
+                        <br/>
+                        <span style={style.summaryItem}>
+                            <a
+                                rel='noopener noreferrer'
+                                target='_blank'
+                                href={props.recording_url}
+                            >
+                                {'Recording'}
+                            </a>
+                        </span>
+                    </React.Fragment>
+                );
+            }
 
             content = (
                 <div>
@@ -110,6 +193,8 @@ export default class PostTypeWebex extends React.PureComponent {
                     <span style={style.summaryItem}>{'Date: ' + start}</span>
                     <br/>
                     <span style={style.summaryItem}>{'Meeting Length: ' + length + ' minute(s)'}</span>
+                    {participants}
+                    {recording}
                 </div>
             );
         }
@@ -203,5 +288,10 @@ const getStyle = makeStyleFromTheme((theme) => {
             fontSize: '14px',
             lineHeight: '26px',
         },
+        error: {
+            fontSize: '12px',
+            marginTop: '8px',
+            color: theme.errorTextColor,
+        },
     };
 });
@@ -7,6 +7,7 @@ import {getConfig} from 'mattermost-redux/selectors/entities/general';
 
 import manifest from './manifest';
 
+import Diagnostics from './components/diagnostics.jsx';
 import Icon from './components/icon.jsx';
 import PostTypeWebex from './components/post_type_webex';
 import {startMeeting} from './actions';
@@ -35,6 +36,7 @@ class Plugin {
         }
 
         registry.registerPostTypeComponent('custom_webex', PostTypeWebex);
+        registry.registerAdminConsoleCustomSetting('Diagnostics', Diagnostics, {showTitle: true});
         Client.setServerRoute(getServerRoute(store.getState()));
     }
 }
@@ -4,6 +4,7 @@
 
 /* eslint-disable max-nested-callbacks */
 
+jest.mock('./components/diagnostics.jsx', () => ({__esModule: true, default: () => null}));
 jest.mock('./components/icon.jsx', () => ({__esModule: true, default: () => null}));
 jest.mock('./components/post_type_webex', () => ({__esModule: true, default: () => null}));
 
@@ -37,6 +38,7 @@ describe('Plugin initialization', () => {
             registerChannelHeaderButtonAction: jest.fn(),
             registerAppBarComponent: jest.fn(),
             registerPostTypeComponent: jest.fn(),
+            registerAdminConsoleCustomSetting: jest.fn(),
         };
         mockStore = {
             getState: jest.fn(() => ({})),
@@ -61,7 +63,7 @@ describe('Plugin initialization', () => {
         }).not.toThrow();
     });
 
-    test('registers channel header button, app bar icon, and post type component', () => {
+    test('registers channel header button, app bar icon, post type component, and diagnostics setting', () => {
         jest.isolateModules(() => {
             require('./index'); // eslint-disable-line global-require
         });
@@ -72,5 +74,10 @@ describe('Plugin initialization', () => {
             'custom_webex',
             expect.anything(),
         );
+        expect(mockRegistry.registerAdminConsoleCustomSetting).toHaveBeenCalledWith(
+            'Diagnostics',
+            expect.anything(),
+            {showTitle: true},
+        );
     });
 });